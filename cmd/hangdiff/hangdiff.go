@@ -0,0 +1,190 @@
+// Command hangdiff compares two WissKI exports
+//
+//spellchecker:words main
+package main
+
+//spellchecker:words errors flag path filepath github hangover internal diff glass sparkl stats viewer wisski profile
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/FAU-CDI/hangover"
+	"github.com/FAU-CDI/hangover/internal/diff"
+	"github.com/FAU-CDI/hangover/internal/glass"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/viewer"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	"github.com/pkg/profile"
+)
+
+//spellchecker:words nquads pathbuilder hangdiff
+
+const usage = "Usage: hangdiff [-help] [...flags] /path/to/old /path/to/new\n   or: hangdiff [-help] [...flags] /path/to/old/pathbuilder /path/to/old/nquads /path/to/new/pathbuilder /path/to/new/nquads"
+
+var errWrongArgCount = errors.New("need either two or four arguments")
+
+func main() {
+	st := stats.NewStats(os.Stderr, debug)
+
+	if debugProfile != "" {
+		defer profile.Start(profile.ProfilePath(debugProfile)).Stop()
+	}
+
+	// split the arguments
+	var oldArgs, newArgs []string
+	switch len(nArgs) {
+	case 2:
+		oldArgs, newArgs = nArgs[:1], nArgs[1:]
+	case 4:
+		oldArgs, newArgs = nArgs[:2], nArgs[2:]
+	default:
+		st.Log(usage)
+		st.LogFatal("parse arguments", errWrongArgCount)
+	}
+
+	var flags viewer.RenderFlags
+	flags.Predicates.SameAs = sparkl.ParsePredicateString(sameAs)
+	flags.Predicates.InverseOf = sparkl.ParsePredicateString(inverseOf)
+
+	before, err := load("old", oldArgs, flags, st)
+	if err != nil {
+		st.LogFatal("load old export", err)
+	}
+	defer closeGlass(before, st)
+
+	after, err := load("new", newArgs, flags, st)
+	if err != nil {
+		st.LogFatal("load new export", err)
+	}
+	defer closeGlass(after, st)
+
+	// compare and write the output
+	result := diff.Compare(
+		diff.Dataset{Name: before.name, Bundles: before.Pathbuilder.Bundles(), Cache: before.Cache},
+		diff.Dataset{Name: after.name, Bundles: after.Pathbuilder.Bundles(), Cache: after.Cache},
+	)
+
+	if jsonPath == "" && markdownPath == "" && htmlPath == "" {
+		jsonPath = "-"
+	}
+	for _, output := range []struct {
+		path  string
+		write func(io.Writer) error
+	}{
+		{jsonPath, result.WriteJSON},
+		{markdownPath, result.WriteMarkdown},
+		{htmlPath, result.WriteHTML},
+	} {
+		if output.path == "" {
+			continue
+		}
+		if err := writeOutput(output.path, output.write); err != nil {
+			st.LogFatal("write output "+output.path, err)
+		}
+	}
+
+	st.Log("finished", "took", st.Diff(), "empty", result.Empty())
+}
+
+// export is a loaded export.
+type export struct {
+	glass.Glass
+	name string
+}
+
+// load loads an export from the given arguments.
+// cacheName is used as a sub-directory of the cache directory.
+func load(cacheName string, args []string, flags viewer.RenderFlags, st *stats.Stats) (*export, error) {
+	nq, pb, err := hangover.FindSource(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find source: %w", err)
+	}
+
+	var cacheDir string
+	if cache != "" {
+		cacheDir = filepath.Join(cache, cacheName)
+	}
+
+	st.Log("loading files", "pathbuilder", pb, "nquads", nq)
+	drincw, err := glass.Create(pb, nq, cacheDir, flags, st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load export: %w", err)
+	}
+	return &export{Glass: drincw, name: nq}, nil
+}
+
+func closeGlass(e *export, st *stats.Stats) {
+	if err := e.Close(); err != nil {
+		st.LogError("close export", err)
+	}
+}
+
+// writeOutput calls write with the file at path, or standard output if path is "-".
+func writeOutput(path string, write func(io.Writer) error) (e error) {
+	if path == "-" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(path) // #nosec G304 -- parametrized by user
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		if e2 := file.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close file: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	return write(file)
+}
+
+// ===================
+
+var nArgs []string
+var cache string
+var sameAs = string(wisski.DefaultSameAsProperties)
+var inverseOf = string(wisski.InverseOf)
+var debugProfile = ""
+
+var jsonPath string
+var markdownPath string
+var htmlPath string
+
+var debug bool
+
+func init() {
+	var legalFlag = false
+	flag.BoolVar(&legalFlag, "legal", legalFlag, "Display legal notices and exit")
+
+	flag.StringVar(&sameAs, "sameas", sameAs, "SameAs Properties")
+	flag.StringVar(&inverseOf, "inverseof", inverseOf, "InverseOf Properties")
+
+	flag.StringVar(&cache, "cache", cache, "During indexing, cache data in the given directory as opposed to memory")
+
+	flag.StringVar(&jsonPath, "json", jsonPath, "Write the diff as json to the given path, use '-' for standard output. This is the default if no other output is given.")
+	flag.StringVar(&markdownPath, "markdown", markdownPath, "Write a markdown report to the given path, use '-' for standard output")
+	flag.StringVar(&htmlPath, "html", htmlPath, "Write an html report to the given path, use '-' for standard output")
+
+	flag.BoolVar(&debug, "debug", debug, "Setup debug logging")
+	flag.StringVar(&debugProfile, "debug-profile", debugProfile, "write out a debugging profile to the given path")
+
+	defer func() {
+		if legalFlag {
+			fmt.Print(hangover.LegalText())
+			os.Exit(0)
+		}
+	}()
+
+	flag.Parse()
+	nArgs = flag.Args()
+}
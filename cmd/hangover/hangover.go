@@ -5,8 +5,9 @@ package main
 
 //spellchecker:words Wiss KI
 
-//spellchecker:words embed flag html template http time github hangover internal glass sparkl stats viewer wisski pkglib perf
+//spellchecker:words context embed flag html template http filepath time github hangover internal access citation geo glass render sparkl stats thumbnail viewer wisski pkglib perf
 import (
+	"context"
 	_ "embed"
 	"flag"
 	"fmt"
@@ -14,12 +15,18 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/FAU-CDI/hangover"
+	"github.com/FAU-CDI/hangover/internal/access"
+	"github.com/FAU-CDI/hangover/internal/citation"
+	"github.com/FAU-CDI/hangover/internal/geo"
 	"github.com/FAU-CDI/hangover/internal/glass"
+	"github.com/FAU-CDI/hangover/internal/render"
 	"github.com/FAU-CDI/hangover/internal/sparkl"
 	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/thumbnail"
 	"github.com/FAU-CDI/hangover/internal/viewer"
 	"github.com/FAU-CDI/hangover/internal/wisski"
 	"github.com/tkw1536/pkglib/perf"
@@ -41,9 +48,67 @@ func main() {
 	var listener net.Listener
 	var err error
 
+	// setup renderers
+	if renderFile != "" {
+		flags.Renderers.Overrides, err = render.LoadOverrides(renderFile)
+		if err != nil {
+			handler.Stats.LogFatal("load render overrides", err)
+		}
+	}
+
 	// prepare the handler
 	handler.RenderFlags = flags
 	handler.Footer = template.HTML(footerHTML) // #nosec G203 -- this is user-intended
+	handler.BasePath = basePath
+	handler.VersionName = versionName
+	if flags.MediaDir != "" && thumbnailSize > 0 {
+		handler.Thumbnails = &thumbnail.Thumbnailer{
+			Root: flags.MediaDir,
+			Size: thumbnailSize,
+		}
+		if cache != "" {
+			handler.Thumbnails.CacheDir = filepath.Join(cache, "thumbnails")
+		}
+	}
+	handler.TrustForwardedPrefix = trustForwardedPrefix
+
+	// setup access control
+	handler.Access, err = access.Load(htpasswdFile, tokensFile, accessFile)
+	if err != nil {
+		handler.Stats.LogFatal("load access policy", err)
+	}
+
+	// setup citations
+	if citationsFile != "" {
+		handler.Citations, err = citation.LoadMappings(citationsFile)
+		if err != nil {
+			handler.Stats.LogFatal("load citation mappings", err)
+		}
+	}
+
+	// setup geometries
+	if geoFile != "" {
+		handler.Geo, err = geo.LoadMappings(geoFile)
+		if err != nil {
+			handler.Stats.LogFatal("load geometry mappings", err)
+		}
+	}
+
+	// setup reloading
+	reloader := &glass.Reloader{
+		Viewer: handler,
+
+		Args:     nArgs,
+		CacheDir: cache,
+		Flags:    flags,
+
+		Output: os.Stderr,
+		Debug:  debug,
+	}
+	if adminToken != "" {
+		handler.AdminToken = adminToken
+		handler.Reloader = reloader
+	}
 
 	// create a channel to wait for being done listening
 	done := make(chan struct{})
@@ -100,6 +165,24 @@ func main() {
 	}
 
 	handler.Stats.Log("finished", "took", handler.Stats.Diff(), "now", perf.Now())
+	handler.LogMissingMedia()
+
+	// load older versions once the current version is being served
+	if versionsDir != "" {
+		st := stats.NewStats(os.Stderr, debug)
+		versions, err := glass.LoadVersions(versionsDir, cache, flags, st)
+		if err != nil {
+			handler.Stats.LogFatal("unable to load versions", err)
+		}
+		if err := handler.SetVersions(versions); err != nil {
+			handler.Stats.LogError("set versions", err)
+		}
+		st.Log("loaded versions", "count", len(versions))
+	}
+
+	if watch > 0 && !benchMode {
+		go reloader.Watch(context.Background(), watch)
+	}
 
 	<-done
 }
@@ -119,6 +202,25 @@ var cache string
 var debugServer string
 var benchMode bool
 
+var watch time.Duration
+var adminToken string = os.Getenv("HANGOVER_ADMIN_TOKEN")
+
+var thumbnailSize int = thumbnail.DefaultSize
+
+var basePath string
+var trustForwardedPrefix bool
+
+var versionsDir string
+var versionName string
+
+var htpasswdFile string
+var tokensFile string
+var accessFile string
+
+var citationsFile string
+var geoFile string
+var renderFile string
+
 func init() {
 	var legalFlag = false
 	flag.BoolVar(&legalFlag, "legal", legalFlag, "Display legal notices and exit")
@@ -130,8 +232,10 @@ func init() {
 	}()
 
 	flag.StringVar(&addr, "addr", addr, "Start up a server at the given address")
-	flag.BoolVar(&flags.ImageRender, "images", flags.ImageRender, "Enable rendering of images")
-	flag.BoolVar(&flags.HTMLRender, "html", flags.HTMLRender, "Enable rendering of html")
+	flag.BoolVar(&flags.ImageRender, "images", flags.ImageRender, "Enable rendering of image fields as images")
+	flag.BoolVar(&flags.HTMLRender, "html", flags.HTMLRender, "Enable rendering of html fields as sanitized html")
+	flag.StringVar(&renderFile, "render", renderFile, "override how fields are rendered using the given file, one 'field renderer' per line")
+	flag.BoolVar(&flags.HTMLStyles, "html-styles", flags.HTMLStyles, "Keep inline styles when rendering html. Relaxes the content-security-policy to allow inline styles")
 	flag.StringVar(&flags.PublicURL, "public", flags.PublicURL, "Public URL of the wisski the data comes from")
 	flag.StringVar(&sameAs, "sameas", sameAs, "SameAs Properties")
 	flag.StringVar(&inverseOf, "inverseof", inverseOf, "InverseOf Properties")
@@ -141,7 +245,20 @@ func init() {
 	flag.StringVar(&footerHTML, "footer", footerHTML, "html to include in footer of every page")
 	flag.BoolVar(&flags.StrictCSP, "strict-csp", flags.StrictCSP, "include a strict csp header in every page")
 	flag.BoolVar(&benchMode, "bench", benchMode, "benchmarking mode: only load for statistics and exit")
+	flag.StringVar(&flags.MediaDir, "media", flags.MediaDir, "serve public files referenced in the data from the given local directory")
+	flag.IntVar(&thumbnailSize, "thumbnail-size", thumbnailSize, "maximum width and height of thumbnails generated for images in the media directory. 0 to disable")
 	flag.StringVar(&flags.TipsyURL, "tipsy", flags.TipsyURL, "embed a tipsy at the given url. Must start with 'http://' or 'https://'")
+	flag.DurationVar(&watch, "watch", watch, "poll input files at the given interval, and reload them in the background when they change. 0 to disable")
+	flag.StringVar(&adminToken, "admin-token", adminToken, "enable the admin api using the given bearer token. Defaults to the HANGOVER_ADMIN_TOKEN environment variable")
+	flag.StringVar(&basePath, "base-path", basePath, "serve the viewer under the given path prefix, e.g. '/archive/kirmes'")
+	flag.BoolVar(&trustForwardedPrefix, "forwarded-prefix", trustForwardedPrefix, "additionally prefix all links with the 'X-Forwarded-Prefix' header set by a reverse proxy")
+	flag.StringVar(&versionsDir, "versions", versionsDir, "load older versions of the dataset from the sub-directories of the given directory, ordered by name")
+	flag.StringVar(&versionName, "version-name", versionName, "name of the current version of the dataset, shown next to older versions")
+	flag.StringVar(&htpasswdFile, "htpasswd", htpasswdFile, "allow users in the given htpasswd file to log in using http basic auth")
+	flag.StringVar(&tokensFile, "tokens", tokensFile, "allow api clients to authenticate using bearer tokens from the given file, one 'user:token' per line")
+	flag.StringVar(&accessFile, "access", accessFile, "restrict bundles according to the rules in the given file, one 'bundle who...' per line")
+	flag.StringVar(&citationsFile, "citations", citationsFile, "cite entities using the field mappings in the given file, one 'bundle key=field...' per line")
+	flag.StringVar(&geoFile, "geo", geoFile, "read geometries from the fields mapped in the given file, one 'bundle key=field...' per line. Other bundles have geometry fields detected automatically")
 
 	flag.Parse()
 	nArgs = flag.Args()
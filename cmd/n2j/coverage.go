@@ -0,0 +1,36 @@
+//spellchecker:words main
+package main
+
+//spellchecker:words github drincw pathbuilder hangover internal coverage sparkl storages stats triplestore igraph wisski
+import (
+	"fmt"
+	"os"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/coverage"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+// doCoverage writes a coverage report of the pathbuilder to standard output.
+func doCoverage(pb *pathbuilder.Pathbuilder, index *igraph.Index, bEngine storages.BundleEngine, st *stats.Stats) error {
+	var bundles map[string][]wisski.Entity
+	if err := st.DoStage(stats.StageExtractBundles, func() (err error) {
+		bundles, err = sparkl.LoadPathbuilder(pb, index, bEngine, st)
+		if err != nil {
+			return fmt.Errorf("failed to load pathbuilder: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to extract bundles: %w", err)
+	}
+
+	report := coverage.New(pb, func(bundle string) []wisski.Entity { return bundles[bundle] }, index.Stats())
+	if err := report.WriteMarkdown(os.Stdout); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+//spellchecker:words main
+package main
+
+//spellchecker:words errors path filepath unicode github drincw pathbuilder hangover internal sparkl exporter storages stats triplestore igraph
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+)
+
+var (
+	errCSVDelimiter = errors.New("-csv-delimiter must be a single character")
+	errCSVQuote     = errors.New("-csv-quote must be a single character")
+)
+
+// doCSV streams one csv file per bundle (and optionally per field) into the directory at path.
+func doCSV(pb *pathbuilder.Pathbuilder, index *igraph.Index, bEngine storages.BundleEngine, path string, st *stats.Stats) error {
+	comma, size := utf8.DecodeRuneInString(csvDelimiter)
+	if size == 0 || size != len(csvDelimiter) {
+		return errCSVDelimiter
+	}
+	quote, size := utf8.DecodeRuneInString(csvQuote)
+	if size == 0 || size != len(csvQuote) {
+		return errCSVQuote
+	}
+
+	cv := &exporter.CSV{
+		Create: func(name string) (io.WriteCloser, error) {
+			st.Log("exporting table", "name", name)
+			return os.Create(filepath.Join(path, name+".csv")) // #nosec G304 -- this is intended
+		},
+
+		Comma:     comma,
+		Quote:     quote,
+		QuoteAll:  csvQuoteAll,
+		Separator: sqlSeparator,
+		BOM:       csvBOM,
+
+		FieldFiles: sqlFieldTables,
+	}
+
+	if err := st.DoStage(stats.StageExportCSV, func() error {
+		return sparkl.ExportSelection(pb, selection, index, bEngine, cv, st)
+	}); err != nil {
+		return fmt.Errorf("failed to export csv: %w", err)
+	}
+	return nil
+}
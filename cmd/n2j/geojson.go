@@ -0,0 +1,62 @@
+//spellchecker:words main
+package main
+
+//spellchecker:words encoding json strings github drincw pathbuilder hangover internal sparkl storages stats triplestore igraph impl wisski
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/geo"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words geojson
+
+// doGeoJSON writes the geometries of all top-level bundles as a single GeoJSON feature collection to geoJSONPath.
+func doGeoJSON(pb *pathbuilder.Pathbuilder, index *igraph.Index, bEngine storages.BundleEngine, st *stats.Stats) error {
+	var mappings geo.Mappings
+	if geoFile != "" {
+		var err error
+		mappings, err = geo.LoadMappings(geoFile)
+		if err != nil {
+			return fmt.Errorf("failed to load geometry mappings: %w", err)
+		}
+	}
+
+	var bundles map[string][]wisski.Entity
+	if err := st.DoStage(stats.StageExtractBundles, func() (err error) {
+		bundles, err = sparkl.LoadSelection(pb, selection, index, bEngine, st)
+		if err != nil {
+			return fmt.Errorf("failed to load pathbuilder: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to extract bundles: %w", err)
+	}
+
+	var features []geo.Feature
+	for _, bundle := range pb.Bundles() {
+		var link func(uri impl.Label) string
+		if geoJSONBase != "" {
+			base := strings.TrimSuffix(geoJSONBase, "/") + "/entity/" + url.PathEscape(bundle.MachineName()) + "?uri="
+			link = func(uri impl.Label) string { return base + url.QueryEscape(string(uri)) }
+		}
+		features = append(features, mappings.Features(bundle, bundles[bundle.MachineName()], link)...)
+	}
+
+	return writeOutput(geoJSONPath, func(w io.Writer) error {
+		if err := json.NewEncoder(w).Encode(geo.NewFeatureCollection(features)); err != nil {
+			return fmt.Errorf("failed to encode json: %w", err)
+		}
+		return nil
+	})
+}
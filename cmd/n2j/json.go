@@ -19,8 +19,11 @@ func doJSON(pb *pathbuilder.Pathbuilder, index *igraph.Index, bEngine storages.B
 	// generate bundles
 	var bundles map[string][]wisski.Entity
 	if err := st.DoStage(stats.StageExtractBundles, func() error {
-		bundles, err = sparkl.LoadPathbuilder(pb, index, bEngine, st)
-		return fmt.Errorf("failed to load pathbuilder: %w", err)
+		bundles, err = sparkl.LoadSelection(pb, selection, index, bEngine, st)
+		if err != nil {
+			return fmt.Errorf("failed to load pathbuilder: %w", err)
+		}
+		return nil
 	}); err != nil {
 		st.LogFatal("extract bundles", err)
 	}
@@ -0,0 +1,44 @@
+//spellchecker:words main
+package main
+
+//spellchecker:words path filepath github drincw pathbuilder hangover internal sparkl exporter storages stats triplestore igraph
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+)
+
+//spellchecker:words ndjson
+
+// doNDJSON streams all entities as newline-delimited json to ndjsonPath.
+// When ndjsonSplit is set, ndjsonPath instead is a directory receiving one file per bundle.
+func doNDJSON(pb *pathbuilder.Pathbuilder, index *igraph.Index, bEngine storages.BundleEngine, st *stats.Stats) error {
+	export := func(nd *exporter.NDJSON) error {
+		if err := st.DoStage(stats.StageExportNDJSON, func() error {
+			return sparkl.ExportSelection(pb, selection, index, bEngine, nd, st)
+		}); err != nil {
+			return fmt.Errorf("failed to export ndjson: %w", err)
+		}
+		return nil
+	}
+
+	if ndjsonSplit {
+		return export(&exporter.NDJSON{
+			Create: func(bundle *pathbuilder.Bundle) (io.WriteCloser, error) {
+				return os.Create(filepath.Join(ndjsonPath, bundle.MachineName()+".ndjson")) // #nosec G304 -- parametrized by user
+			},
+		})
+	}
+
+	return writeOutput(ndjsonPath, func(w io.Writer) error {
+		return export(&exporter.NDJSON{Writer: w})
+	})
+}
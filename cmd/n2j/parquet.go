@@ -0,0 +1,39 @@
+//spellchecker:words main
+package main
+
+//spellchecker:words path filepath github drincw pathbuilder hangover internal sparkl exporter storages stats triplestore igraph
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+)
+
+//spellchecker:words parquet
+
+// doParquet writes one parquet file per bundle into the directory parquetPath.
+func doParquet(pb *pathbuilder.Pathbuilder, index *igraph.Index, bEngine storages.BundleEngine, st *stats.Stats) error {
+	pq := &exporter.Parquet{
+		Create: func(bundle *pathbuilder.Bundle) (io.WriteCloser, error) {
+			return os.Create(filepath.Join(parquetPath, bundle.MachineName()+".parquet")) // #nosec G304 -- parametrized by user
+		},
+	}
+
+	if err := st.DoStage(stats.StageExportParquet, func() error {
+		return sparkl.ExportSelection(pb, selection, index, bEngine, pq, st)
+	}); err != nil {
+		return fmt.Errorf("failed to export parquet: %w", err)
+	}
+
+	if skipped := pq.Skipped(); skipped > 0 {
+		st.Log("skipped values not matching the type of their field", "count", skipped)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+//spellchecker:words main
+package main
+
+//spellchecker:words database github drincw pathbuilder hangover internal sparkl exporter storages stats triplestore igraph
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	_ "github.com/lib/pq"
+)
+
+//spellchecker:words postgres
+
+const (
+	postgresMaxQueryVar = 65535 // see https://www.postgresql.org/docs/current/protocol-message-formats.html
+	postgresBatchSize   = 1000
+)
+
+// doPostgres exports the data into the PostgreSQL database at the connection string postgres.
+func doPostgres(pb *pathbuilder.Pathbuilder, index *igraph.Index, bEngine storages.BundleEngine, st *stats.Stats) error {
+	db, err := sql.Open("postgres", postgres)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	pg := &exporter.Postgres{
+		DB: db,
+
+		BatchSize:   postgresBatchSize,
+		MaxQueryVar: postgresMaxQueryVar,
+	}
+
+	if err := st.DoStage(stats.StageExportSQL, func() error {
+		return sparkl.ExportSelection(pb, selection, index, bEngine, pg, st)
+	}); err != nil {
+		return fmt.Errorf("failed to export postgres: %w", err)
+	}
+
+	if skipped := pg.Skipped(); skipped > 0 {
+		st.Log("skipped values not matching the type of their column", "count", skipped)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+//spellchecker:words main
+package main
+
+//spellchecker:words github drincw pathbuilder hangover internal sparkl exporter storages stats triplestore igraph
+import (
+	"fmt"
+	"io"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+)
+
+// doRDF writes all triples reachable via pathbuilder paths to rdfPath.
+func doRDF(pb *pathbuilder.Pathbuilder, index *igraph.Index, bEngine storages.BundleEngine, st *stats.Stats) error {
+	dump := &exporter.RDF{
+		Format:    exporter.RDFFormat(rdfFormat),
+		Canonical: rdfCanonical,
+	}
+	if err := writeOutput(rdfPath, func(w io.Writer) error {
+		dump.Writer = w
+		return st.DoStage(stats.StageExportRDF, func() error {
+			return sparkl.ExportSelection(pb, selection, index, bEngine, dump, st)
+		})
+	}); err != nil {
+		return fmt.Errorf("failed to export rdf: %w", err)
+	}
+
+	st.Log("finished writing triples", "count", dump.Written())
+	return nil
+}
@@ -0,0 +1,63 @@
+//spellchecker:words main
+package main
+
+//spellchecker:words encoding json github drincw pathbuilder hangover internal sparkl exporter storages stats triplestore igraph
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+)
+
+//spellchecker:words opensearch meilisearch
+
+// doSearchMapping writes the index definition for search bulk files to the path given by -search-mapping.
+func doSearchMapping(pb *pathbuilder.Pathbuilder) error {
+	mapping, err := exporter.SearchMapping(pb, exporter.SearchFormat(searchFormat))
+	if err != nil {
+		return fmt.Errorf("failed to generate mapping: %w", err)
+	}
+
+	if err := writeOutput(searchMapping, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(mapping)
+	}); err != nil {
+		return fmt.Errorf("failed to write mapping: %w", err)
+	}
+	return nil
+}
+
+// doSearch writes a search engine bulk file to the path given by -search,
+// and the matching index definition to the path given by -search-mapping (if any).
+func doSearch(pb *pathbuilder.Pathbuilder, index *igraph.Index, bEngine storages.BundleEngine, st *stats.Stats) error {
+	if searchMapping != "" {
+		if err := doSearchMapping(pb); err != nil {
+			return err
+		}
+	}
+
+	se := &exporter.Search{
+		Format: exporter.SearchFormat(searchFormat),
+		Index:  searchIndex,
+	}
+	if err := writeOutput(searchPath, func(w io.Writer) error {
+		se.Writer = w
+		return st.DoStage(stats.StageExportSearch, func() error {
+			return sparkl.ExportSelection(pb, selection, index, bEngine, se, st)
+		})
+	}); err != nil {
+		return fmt.Errorf("failed to export search documents: %w", err)
+	}
+
+	if skipped := se.Skipped(); skipped > 0 {
+		st.Log("skipped values not matching the type of their field", "count", skipped)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+//spellchecker:words main
+package main
+
+//spellchecker:words errors slices strings github hangover internal sparkl
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+)
+
+// selection holds the bundles and entities to export, see loadSelection.
+var selection sparkl.Selection
+
+// unselectableModes are the output modes that always cover all data.
+var unselectableModes = []string{"-coverage", "-shacl-shapes", "-shacl-report"}
+
+var errSelectionMode = errors.New("-bundles, -uris and -references cannot be used with")
+
+// loadSelection sets up selection from the -bundles, -uris and -references flags for the given output mode.
+func loadSelection(mode string) (e error) {
+	if (selectBundles != "" || selectURIs != "" || selectReferences) && slices.Contains(unselectableModes, mode) {
+		return fmt.Errorf("%w %s", errSelectionMode, mode)
+	}
+
+	for _, machine := range strings.Split(selectBundles, ",") {
+		if machine = strings.TrimSpace(machine); machine != "" {
+			selection.Bundles = append(selection.Bundles, machine)
+		}
+	}
+	selection.References = selectReferences
+
+	if selectURIs == "" {
+		return nil
+	}
+
+	file, err := os.Open(selectURIs) // #nosec G304 -- parametrized by user
+	if err != nil {
+		return fmt.Errorf("failed to open uri list: %w", err)
+	}
+	defer func() {
+		if e2 := file.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close uri list: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	selection.URIs, err = sparkl.ReadURIs(file)
+	if err != nil {
+		return fmt.Errorf("failed to read uri list: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+//spellchecker:words main
+package main
+
+//spellchecker:words errors github drincw pathbuilder hangover internal shacl stats triplestore igraph
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/shacl"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+)
+
+// doSHACLShapes writes shacl shapes generated from the pathbuilder to the path given by -shacl-shapes.
+func doSHACLShapes(pb *pathbuilder.Pathbuilder) error {
+	shapes := shacl.New(pb, shaclBase)
+	if err := writeOutput(shaclShapes, shapes.WriteTurtle); err != nil {
+		return fmt.Errorf("failed to write shapes: %w", err)
+	}
+	return nil
+}
+
+// doSHACL validates the data against shapes generated from the pathbuilder.
+// The report is written to the path given by -shacl-report, and the shapes to the path given by -shacl-shapes (if any).
+func doSHACL(pb *pathbuilder.Pathbuilder, index *igraph.Index, st *stats.Stats) error {
+	if shaclShapes != "" {
+		if err := doSHACLShapes(pb); err != nil {
+			return err
+		}
+	}
+
+	shapes := shacl.New(pb, shaclBase)
+
+	var report *shacl.Report
+	if err := st.DoStage(stats.StageValidateSHACL, func() (err error) {
+		report, err = shapes.Validate(index)
+		if err != nil {
+			return fmt.Errorf("failed to validate: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to validate shapes: %w", err)
+	}
+	st.Log("finished validation", "conforms", report.Conforms(), "results", len(report.Results))
+
+	if err := writeOutput(shaclReport, report.WriteTurtle); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// writeOutput calls write with the file at path, or standard output if path is "-".
+func writeOutput(path string, write func(io.Writer) error) (e error) {
+	if path == "-" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(path) // #nosec G304 -- parametrized by user
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		if e2 := file.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close file: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	return write(file)
+}
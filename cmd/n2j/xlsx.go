@@ -0,0 +1,29 @@
+//spellchecker:words main
+package main
+
+//spellchecker:words github drincw pathbuilder hangover internal sparkl exporter storages stats triplestore igraph
+import (
+	"fmt"
+	"io"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+)
+
+//spellchecker:words xlsx
+
+// doXLSX writes an Excel workbook with one worksheet per bundle to xlsxPath.
+func doXLSX(pb *pathbuilder.Pathbuilder, index *igraph.Index, bEngine storages.BundleEngine, st *stats.Stats) error {
+	return writeOutput(xlsxPath, func(w io.Writer) error {
+		if err := st.DoStage(stats.StageExportXLSX, func() error {
+			return sparkl.ExportSelection(pb, selection, index, bEngine, &exporter.XLSX{Writer: w, Separator: sqlSeparator}, st)
+		}); err != nil {
+			return fmt.Errorf("failed to export xlsx: %w", err)
+		}
+		return nil
+	})
+}
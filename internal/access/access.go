@@ -0,0 +1,295 @@
+// Package access implements access control for the viewer.
+//
+//spellchecker:words access
+package access
+
+//spellchecker:words bufio crypto sha256 errors http strings github drincw pathbuilder hangover htpasswd
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/pkg/htpasswd"
+)
+
+//spellchecker:words htpasswd pathbuilder
+
+// Anonymous is the user name of users that did not authenticate.
+const Anonymous = ""
+
+// Policy determines which users may access which bundles.
+//
+// A nil Policy allows everyone to access every bundle.
+type Policy struct {
+	Passwords htpasswd.File // users that may authenticate using http basic auth
+	Tokens    Tokens        // users that may authenticate using bearer tokens
+	Rules     Rules         // rules determining who may see which bundle
+}
+
+// Load loads a policy from the given htpasswd, tokens and rules files.
+// Empty paths are ignored.
+// If all paths are empty, returns a nil policy.
+func Load(passwordsPath, tokensPath, rulesPath string) (policy *Policy, err error) {
+	if passwordsPath == "" && tokensPath == "" && rulesPath == "" {
+		return nil, nil
+	}
+
+	policy = new(Policy)
+	if passwordsPath != "" {
+		policy.Passwords, err = htpasswd.Load(passwordsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load passwords: %w", err)
+		}
+	}
+	if tokensPath != "" {
+		if err := loadFile(tokensPath, func(r io.Reader) (err error) {
+			policy.Tokens, err = ParseTokens(r)
+			return
+		}); err != nil {
+			return nil, fmt.Errorf("failed to load tokens: %w", err)
+		}
+	}
+	if rulesPath != "" {
+		if err := loadFile(rulesPath, func(r io.Reader) (err error) {
+			policy.Rules, err = ParseRules(r)
+			return
+		}); err != nil {
+			return nil, fmt.Errorf("failed to load rules: %w", err)
+		}
+	}
+	return policy, nil
+}
+
+// ErrInvalidCredentials is returned by [Policy.Authenticate] when a request carries invalid credentials.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Authenticate determines the user making the given request.
+//
+// Requests without credentials are made by the [Anonymous] user.
+// Requests with credentials that are not valid return [ErrInvalidCredentials].
+func (policy *Policy) Authenticate(r *http.Request) (string, error) {
+	if policy == nil || r.Header.Get("Authorization") == "" {
+		return Anonymous, nil
+	}
+
+	if user, password, ok := r.BasicAuth(); ok {
+		if user != Anonymous && policy.Passwords.Verify(user, password) {
+			return user, nil
+		}
+		return Anonymous, ErrInvalidCredentials
+	}
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		if user, ok := policy.Tokens.User(token); ok {
+			return user, nil
+		}
+		return Anonymous, ErrInvalidCredentials
+	}
+
+	return Anonymous, ErrInvalidCredentials
+}
+
+// Challenge returns the value of the WWW-Authenticate header to send to users which need to authenticate.
+// If users can not authenticate, returns the empty string.
+func (policy *Policy) Challenge() string {
+	switch {
+	case policy == nil:
+		return ""
+	case len(policy.Passwords) > 0:
+		return `Basic realm="hangover", charset="UTF-8"`
+	case len(policy.Tokens) > 0:
+		return `Bearer realm="hangover"`
+	default:
+		return ""
+	}
+}
+
+// Allowed checks if the given user may see the given bundle.
+//
+// If no rule exists for the bundle, the rule of the closest parent bundle is used.
+// If no such rule exists, the default rule is used.
+func (policy *Policy) Allowed(user string, bundle *pathbuilder.Bundle) bool {
+	if policy == nil {
+		return true
+	}
+	for b := bundle; b != nil; b = b.Parent {
+		if rule, ok := policy.Rules[b.MachineName()]; ok {
+			return rule.Allows(user)
+		}
+	}
+	return policy.Rules.Default().Allows(user)
+}
+
+// Tokens holds users indexed by the hash of their bearer token.
+type Tokens map[[sha256.Size]byte]string
+
+// User returns the user with the given token.
+func (tokens Tokens) User(token string) (user string, ok bool) {
+	user, ok = tokens[sha256.Sum256([]byte(token))]
+	return
+}
+
+var (
+	errMissingColon = errors.New("missing ':'")
+	errEmptyUser    = errors.New("empty user name")
+	errEmptyToken   = errors.New("empty token")
+)
+
+// ParseTokens parses a tokens file.
+//
+// Each line of the file is of the form "user:token".
+// Empty lines and lines starting with '#' are ignored.
+func ParseTokens(reader io.Reader) (Tokens, error) {
+	tokens := make(Tokens)
+	err := scanLines(reader, func(line string) error {
+		user, token, ok := strings.Cut(line, ":")
+		switch {
+		case !ok:
+			return errMissingColon
+		case user == Anonymous:
+			return errEmptyUser
+		case token == "":
+			return errEmptyToken
+		}
+		tokens[sha256.Sum256([]byte(token))] = user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Rule determines who may see a bundle.
+type Rule struct {
+	Public        bool                // anyone may see the bundle, including anonymous users
+	Authenticated bool                // any authenticated user may see the bundle
+	Users         map[string]struct{} // specific users that may see the bundle
+}
+
+// Allows checks if this rule allows the given user.
+func (rule Rule) Allows(user string) bool {
+	if rule.Public {
+		return true
+	}
+	if user == Anonymous {
+		return false
+	}
+	if rule.Authenticated {
+		return true
+	}
+	_, ok := rule.Users[user]
+	return ok
+}
+
+// Rules holds rules by bundle machine name.
+type Rules map[string]Rule
+
+// DefaultBundle is the pseudo bundle name used for the default rule.
+const DefaultBundle = "*"
+
+// Default returns the rule for bundles without an explicit rule.
+// Unless set explicitly, it is public.
+func (rules Rules) Default() Rule {
+	if rule, ok := rules[DefaultBundle]; ok {
+		return rule
+	}
+	return Rule{Public: true}
+}
+
+// Keywords used in rule files.
+const (
+	KeywordPublic        = "public"
+	KeywordAuthenticated = "authenticated"
+)
+
+var (
+	errEmptyRule     = errors.New("rule needs at least one of 'public', 'authenticated' or a user name")
+	errDuplicateRule = errors.New("duplicate rule")
+)
+
+// ParseRules parses a rules file.
+//
+// Each line of the file is of the form "bundle who...".
+// bundle is the machine name of a bundle, or "*" for the default rule.
+// Each who is either "public", "authenticated", or the name of a user.
+// Empty lines and lines starting with '#' are ignored.
+func ParseRules(reader io.Reader) (Rules, error) {
+	rules := make(Rules)
+	err := scanLines(reader, func(line string) error {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return errEmptyRule
+		}
+
+		bundle := fields[0]
+		if _, ok := rules[bundle]; ok {
+			return fmt.Errorf("%w for bundle %q", errDuplicateRule, bundle)
+		}
+
+		var rule Rule
+		for _, who := range fields[1:] {
+			switch who {
+			case KeywordPublic:
+				rule.Public = true
+			case KeywordAuthenticated:
+				rule.Authenticated = true
+			default:
+				if rule.Users == nil {
+					rule.Users = make(map[string]struct{})
+				}
+				rule.Users[who] = struct{}{}
+			}
+		}
+		rules[bundle] = rule
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// scanLines calls f for every non-empty, non-comment line in reader.
+func scanLines(reader io.Reader, f func(line string) error) error {
+	scanner := bufio.NewScanner(reader)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := f(line); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	return nil
+}
+
+// loadFile opens the file at path and passes it to f.
+func loadFile(path string, f func(r io.Reader) error) (e error) {
+	file, err := os.Open(path) // #nosec G304 -- explicit parameter
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		if e2 := file.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close file: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	return f(file)
+}
@@ -0,0 +1,100 @@
+//spellchecker:words access
+package access_test
+
+//spellchecker:words http httptest strings testing github drincw pathbuilder hangover internal access
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/access"
+)
+
+//spellchecker:words pathbuilder
+
+const testRules = `
+# comments and empty lines are ignored
+
+*      authenticated
+person public
+notes  alice bob
+`
+
+func TestPolicy_Allowed(t *testing.T) {
+	t.Parallel()
+
+	rules, err := access.ParseRules(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatalf("ParseRules() returned error %v", err)
+	}
+	policy := &access.Policy{Rules: rules}
+
+	bundle := func(id string, parent *pathbuilder.Bundle) *pathbuilder.Bundle {
+		b := &pathbuilder.Bundle{Parent: parent}
+		b.ID = id
+		return b
+	}
+	person := bundle("person", nil)
+	name := bundle("name", person)
+	notes := bundle("notes", person)
+	place := bundle("place", nil)
+
+	tests := []struct {
+		user   string
+		bundle *pathbuilder.Bundle
+		want   bool
+	}{
+		{access.Anonymous, person, true},
+		{access.Anonymous, name, true},
+		{access.Anonymous, notes, false},
+		{access.Anonymous, place, false},
+		{"alice", notes, true},
+		{"alice", place, true},
+		{"carol", notes, false},
+		{"carol", place, true},
+	}
+	for _, tt := range tests {
+		if got := policy.Allowed(tt.user, tt.bundle); got != tt.want {
+			t.Errorf("Allowed(%q, %q) = %v, want %v", tt.user, tt.bundle.MachineName(), got, tt.want)
+		}
+	}
+
+	var nilPolicy *access.Policy
+	if !nilPolicy.Allowed(access.Anonymous, notes) {
+		t.Error("nil policy did not allow access")
+	}
+}
+
+func TestPolicy_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	tokens, err := access.ParseTokens(strings.NewReader("alice:s3cret\n"))
+	if err != nil {
+		t.Fatalf("ParseTokens() returned error %v", err)
+	}
+	policy := &access.Policy{Tokens: tokens}
+
+	tests := []struct {
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{"", access.Anonymous, false},
+		{"Bearer s3cret", "alice", false},
+		{"Bearer wrong", access.Anonymous, true},
+		{"Basic YWxpY2U6czNjcmV0", access.Anonymous, true},
+		{"Unknown s3cret", access.Anonymous, true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+
+		got, err := policy.Authenticate(r)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("Authenticate(%q) = %q, %v, want %q, error %v", tt.header, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
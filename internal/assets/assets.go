@@ -1,11 +1,12 @@
 //spellchecker:words assets
 package assets
 
-//spellchecker:words html template http embed
+//spellchecker:words html template http strings embed
 import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"strings"
 
 	_ "embed"
 )
@@ -66,14 +67,20 @@ func (assets *Assets) MustMakeFunc(name string, value string, funcMap template.F
 //
 // The template "scripts" will render all script tags required.
 // The template "styles" will render all style tags required.
+// Asset urls are prefixed with the ".Globals.BasePath" of the data the template is executed with.
 //
 // If either template already exists, it will be overwritten.
 func (assets *Assets) RegisterAssoc(t *template.Template) error {
-	if _, err := t.New("scripts").Parse(assets.Scripts); err != nil {
+	if _, err := t.New("scripts").Parse(withBasePath(assets.Scripts)); err != nil {
 		return fmt.Errorf("failed to parse scripts assets: %w", err)
 	}
-	if _, err := t.New("styles").Parse(assets.Styles); err != nil {
+	if _, err := t.New("styles").Parse(withBasePath(assets.Styles)); err != nil {
 		return fmt.Errorf("failed to parse styles assets: %w", err)
 	}
 	return nil
 }
+
+// withBasePath prefixes all asset urls in the given html with the base path template.
+func withBasePath(html string) string {
+	return strings.ReplaceAll(html, `="/assets/`, `="{{ .Globals.BasePath }}/assets/`)
+}
@@ -12,7 +12,7 @@ var Assetshangover = Assets{
 
 // Assetshangover_fallback contains assets for the 'hangover_fallback' entrypoint.
 var Assetshangover_fallback = Assets{
-	Scripts: `<script nomodule defer src="/assets/hangover.38938bc0.js"></script><script type="module" src="/assets/hangover.3e2a739a.js"></script><script type="module" src="/assets/hangover.c7abdb94.js"></script><script src="/assets/hangover.b0ea6023.js" nomodule defer></script><script type="module" src="/assets/hangover_fallback.50fe6883.js"></script><script src="/assets/hangover_fallback.35574774.js" nomodule defer></script>`,
+	Scripts: `<script nomodule defer src="/assets/hangover.38938bc0.js"></script><script type="module" src="/assets/hangover.3e2a739a.js"></script><script type="module" src="/assets/hangover.c7abdb94.js"></script><script src="/assets/hangover.b0ea6023.js" nomodule defer></script><script type="module" src="/assets/hangover_fallback.b41c147f.js"></script><script src="/assets/hangover_fallback.c57805df.js" nomodule defer></script>`,
 	Styles:  `<link rel="stylesheet" href="/assets/hangover.90bee0fa.css"><link rel="stylesheet" href="/assets/hangover_fallback.38d394c2.css">`,	
 }
 
@@ -0,0 +1,48 @@
+//spellchecker:words assets
+package assets_test
+
+//spellchecker:words html template strings testing github hangover internal assets
+import (
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/hangover/internal/assets"
+)
+
+func TestAssets_RegisterAssoc(t *testing.T) {
+	t.Parallel()
+
+	group := assets.Assets{
+		Scripts: `<script type="module" src="/assets/a.js"></script><script src="/external/b.js"></script>`,
+		Styles:  `<link rel="stylesheet" href="/assets/a.css">`,
+	}
+
+	tests := []struct {
+		basePath string
+		want     string
+	}{
+		{"", `<script type="module" src="/assets/a.js"></script><script src="/external/b.js"></script><link rel="stylesheet" href="/assets/a.css">`},
+		{"/archive", `<script type="module" src="/archive/assets/a.js"></script><script src="/external/b.js"></script><link rel="stylesheet" href="/archive/assets/a.css">`},
+		{"/proxy/archive", `<script type="module" src="/proxy/archive/assets/a.js"></script><script src="/external/b.js"></script><link rel="stylesheet" href="/proxy/archive/assets/a.css">`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.basePath, func(t *testing.T) {
+			t.Parallel()
+
+			tpl := group.MustParse(template.New("page"), `{{ template "scripts" . }}{{ template "styles" . }}`)
+
+			var data struct{ Globals struct{ BasePath string } }
+			data.Globals.BasePath = tt.basePath
+
+			var builder strings.Builder
+			if err := tpl.Execute(&builder, data); err != nil {
+				t.Fatal(err)
+			}
+			if got := builder.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
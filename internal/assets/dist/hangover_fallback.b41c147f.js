@@ -1 +1 @@
-import"./hangover.3e2a739a.js";var e="undefined"!=typeof globalThis?globalThis:"undefined"!=typeof self?self:"undefined"!=typeof window?window:"undefined"!=typeof global?global:{},r={},n={},o=e.parcelRequireafa4;null==o&&((o=function(e){if(e in r)return r[e].exports;if(e in n){var o=n[e];delete n[e];var t={id:e,exports:{}};return r[e]=t,o.call(t.exports,t,t.exports),t.exports}var l=Error("Cannot find module '"+e+"'");throw l.code="MODULE_NOT_FOUND",l}).register=function(e,r){n[e]=r},e.parcelRequireafa4=o),o.register,o("iLQcs");let t=document.getElementById("progress"),l=0,a=null;async function i(){let e=++l;try{let r=await fetch("/api/v1/progress").then(e=>e.json());if(e!==l)return void console.warn("Received out-of-order response");if(r.Done){t.innerHTML="Finished, reloading page ...",null!==a&&clearInterval(a),location.reload();return}if(0!==r.Total){let e=`<code>${r.Stage}</code> (<code>`;r.Total!==r.Current?e+=`${r.Current}/${r.Total}`:e+=r.Current.toString(),t.innerHTML=e+="</code>)"}else""!=r.Stage&&(t.innerHTML=`<code>${r.Stage}</code>`)}catch(e){console.error(e)}}i(),null===a&&(a=setInterval(i,500));
\ No newline at end of file
+import"./hangover.3e2a739a.js";var e="undefined"!=typeof globalThis?globalThis:"undefined"!=typeof self?self:"undefined"!=typeof window?window:"undefined"!=typeof global?global:{},r={},n={},o=e.parcelRequireafa4;null==o&&((o=function(e){if(e in r)return r[e].exports;if(e in n){var o=n[e];delete n[e];var t={id:e,exports:{}};return r[e]=t,o.call(t.exports,t,t.exports),t.exports}var l=Error("Cannot find module '"+e+"'");throw l.code="MODULE_NOT_FOUND",l}).register=function(e,r){n[e]=r},e.parcelRequireafa4=o),o.register,o("iLQcs");let t=document.getElementById("progress"),l=0,a=null;async function i(){let e=++l;try{let r=await fetch(t.dataset.progress||"/api/v1/progress").then(e=>e.json());if(e!==l)return void console.warn("Received out-of-order response");if(r.Done){t.innerHTML="Finished, reloading page ...",null!==a&&clearInterval(a),location.reload();return}if(0!==r.Total){let e=`<code>${r.Stage}</code> (<code>`;r.Total!==r.Current?e+=`${r.Current}/${r.Total}`:e+=r.Current.toString(),t.innerHTML=e+="</code>)"}else""!=r.Stage&&(t.innerHTML=`<code>${r.Stage}</code>`)}catch(e){console.error(e)}}i(),null===a&&(a=setInterval(i,500));
\ No newline at end of file
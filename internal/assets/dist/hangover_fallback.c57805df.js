@@ -1 +1 @@
-!function(){var e="undefined"!=typeof globalThis?globalThis:"undefined"!=typeof self?self:"undefined"!=typeof window?window:"undefined"!=typeof global?global:{},n={},r={},o=e.parcelRequireafa4;null==o&&((o=function(e){if(e in n)return n[e].exports;if(e in r){var o=r[e];delete r[e];var t={id:e,exports:{}};return n[e]=t,o.call(t.exports,t,t.exports),t.exports}var l=Error("Cannot find module '"+e+"'");throw l.code="MODULE_NOT_FOUND",l}).register=function(e,n){r[e]=n},e.parcelRequireafa4=o),o.register,o("do6MR");let t=document.getElementById("progress"),l=0,i=null;async function a(){let e=++l;try{let n=await fetch("/api/v1/progress").then(e=>e.json());if(e!==l)return void console.warn("Received out-of-order response");if(n.Done){t.innerHTML="Finished, reloading page ...",null!==i&&clearInterval(i),location.reload();return}if(0!==n.Total){let e=`<code>${n.Stage}</code> (<code>`;n.Total!==n.Current?e+=`${n.Current}/${n.Total}`:e+=n.Current.toString(),t.innerHTML=e+="</code>)"}else""!=n.Stage&&(t.innerHTML=`<code>${n.Stage}</code>`)}catch(e){console.error(e)}}a(),null===i&&(i=setInterval(a,500))}();
\ No newline at end of file
+!function(){var e="undefined"!=typeof globalThis?globalThis:"undefined"!=typeof self?self:"undefined"!=typeof window?window:"undefined"!=typeof global?global:{},n={},r={},o=e.parcelRequireafa4;null==o&&((o=function(e){if(e in n)return n[e].exports;if(e in r){var o=r[e];delete r[e];var t={id:e,exports:{}};return n[e]=t,o.call(t.exports,t,t.exports),t.exports}var l=Error("Cannot find module '"+e+"'");throw l.code="MODULE_NOT_FOUND",l}).register=function(e,n){r[e]=n},e.parcelRequireafa4=o),o.register,o("do6MR");let t=document.getElementById("progress"),l=0,i=null;async function a(){let e=++l;try{let n=await fetch(t.dataset.progress||"/api/v1/progress").then(e=>e.json());if(e!==l)return void console.warn("Received out-of-order response");if(n.Done){t.innerHTML="Finished, reloading page ...",null!==i&&clearInterval(i),location.reload();return}if(0!==n.Total){let e=`<code>${n.Stage}</code> (<code>`;n.Total!==n.Current?e+=`${n.Current}/${n.Total}`:e+=n.Current.toString(),t.innerHTML=e+="</code>)"}else""!=n.Stage&&(t.innerHTML=`<code>${n.Stage}</code>`)}catch(e){console.error(e)}}a(),null===i&&(i=setInterval(a,500))}();
\ No newline at end of file
@@ -1,6 +1,6 @@
 
 const progressElement = document.getElementById('progress') as HTMLElement
-const API_PROGRESS = '/api/v1/progress';
+const API_PROGRESS = progressElement.dataset.progress || '/api/v1/progress';
 
 
 interface Progress {
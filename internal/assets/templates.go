@@ -14,9 +14,11 @@ var templates embed.FS
 var (
 	shared *template.Template = template.Must(
 		template.New("").Funcs(template.FuncMap{
-			"renderhtml": func(args ...any) any { panic("not implemented") },
-			"combine":    func(args ...any) any { panic("not implemented") },
-			"debug":      func(args ...any) any { panic("not implemented") },
+			"renderhtml":  func(args ...any) any { panic("not implemented") },
+			"combine":     func(args ...any) any { panic("not implemented") },
+			"debug":       func(args ...any) any { panic("not implemented") },
+			"datevalue":   func(args ...any) any { panic("not implemented") },
+			"numbervalue": func(args ...any) any { panic("not implemented") },
 		}).ParseFS(templates, "templates/*.html"),
 	)
 )
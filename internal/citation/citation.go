@@ -0,0 +1,247 @@
+// Package citation generates citations for entities.
+//
+//spellchecker:words citation
+package citation
+
+//spellchecker:words bufio errors regexp strconv strings github drincw pathbuilder hangover internal wisski
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+// Mapping determines which fields of a bundle make up a citation.
+// Each key holds the machine names of fields, the first field with a value is used.
+type Mapping struct {
+	Type    string   // CSL type of the cited entities; defaults to [DefaultType]
+	Title   []string // fields holding the title
+	Creator []string // fields holding the creators; values of all fields are used
+	Date    []string // fields holding the date
+}
+
+// DefaultType is the type of entities whose mapping does not specify a type.
+const DefaultType = "document"
+
+// Mappings holds mappings by bundle machine name.
+type Mappings map[string]Mapping
+
+// Keys used in mapping files.
+const (
+	KeyType    = "type"
+	KeyTitle   = "title"
+	KeyCreator = "creator"
+	KeyDate    = "date"
+)
+
+var (
+	errEmptyMapping     = errors.New("mapping needs at least one 'key=field' pair")
+	errDuplicateMapping = errors.New("duplicate mapping")
+	errMissingEquals    = errors.New("missing '='")
+	errUnknownKey       = errors.New("unknown key")
+)
+
+// ParseMappings parses a mappings file.
+//
+// Each line of the file is of the form "bundle key=field[,field...]...".
+// bundle is the machine name of a bundle, and each key is one of "type", "title", "creator" or "date".
+// For "type" the value is a CSL type instead of a list of fields.
+// Empty lines and lines starting with '#' are ignored.
+func ParseMappings(reader io.Reader) (Mappings, error) {
+	mappings := make(Mappings)
+
+	scanner := bufio.NewScanner(reader)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		bundle, mapping, err := parseMapping(line)
+		if err == nil {
+			if _, ok := mappings[bundle]; ok {
+				err = fmt.Errorf("%w for bundle %q", errDuplicateMapping, bundle)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		mappings[bundle] = mapping
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return mappings, nil
+}
+
+func parseMapping(line string) (bundle string, mapping Mapping, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return "", mapping, errEmptyMapping
+	}
+
+	for _, pair := range fields[1:] {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return "", mapping, fmt.Errorf("%w in %q", errMissingEquals, pair)
+		}
+		switch key {
+		case KeyType:
+			mapping.Type = value
+		case KeyTitle:
+			mapping.Title = append(mapping.Title, strings.Split(value, ",")...)
+		case KeyCreator:
+			mapping.Creator = append(mapping.Creator, strings.Split(value, ",")...)
+		case KeyDate:
+			mapping.Date = append(mapping.Date, strings.Split(value, ",")...)
+		default:
+			return "", mapping, fmt.Errorf("%w %q", errUnknownKey, key)
+		}
+	}
+	return fields[0], mapping, nil
+}
+
+// LoadMappings loads mappings from the file at path.
+func LoadMappings(path string) (mappings Mappings, e error) {
+	file, err := os.Open(path) // #nosec G304 -- explicit parameter
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		if e2 := file.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close file: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	return ParseMappings(file)
+}
+
+// Citation holds the data needed to cite an entity.
+type Citation struct {
+	Key      string // key to identify the citation with
+	Type     string // CSL type
+	Title    string
+	Creators []string
+	Date     Date
+	URL      string // stable url of the entity in the viewer
+	URI      string // original uri of the entity
+}
+
+// New creates a citation for an entity of the given bundle.
+//
+// Bundles without a mapping use the first field with a value as title.
+// Entities without a title use their uri.
+func (mappings Mappings) New(bundle *pathbuilder.Bundle, entity *wisski.Entity, url string) Citation {
+	mapping, ok := mappings[bundle.MachineName()]
+	if !ok {
+		for _, field := range bundle.Fields() {
+			mapping.Title = append(mapping.Title, field.MachineName())
+		}
+	}
+
+	citation := Citation{
+		Key:   key(bundle.MachineName(), string(entity.URI)),
+		Type:  mapping.Type,
+		Title: first(entity, mapping.Title),
+		Date:  ParseDate(first(entity, mapping.Date)),
+		URL:   url,
+		URI:   string(entity.URI),
+	}
+	if citation.Type == "" {
+		citation.Type = DefaultType
+	}
+	if citation.Title == "" {
+		citation.Title = citation.URI
+	}
+	for _, field := range mapping.Creator {
+		for _, value := range entity.Fields[field] {
+			if creator := strings.TrimSpace(value.Datum.Value); creator != "" {
+				citation.Creators = append(citation.Creators, creator)
+			}
+		}
+	}
+	return citation
+}
+
+// first returns the first non-empty value of the given fields.
+func first(entity *wisski.Entity, fields []string) string {
+	for _, field := range fields {
+		for _, value := range entity.Fields[field] {
+			if value := strings.TrimSpace(value.Datum.Value); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// key generates a citation key from a bundle and uri.
+func key(bundle, uri string) string {
+	// use the last segment of the uri, which usually identifies the entity
+	if i := strings.LastIndexAny(strings.TrimRight(uri, "/#"), "/#"); i >= 0 {
+		uri = uri[i+1:]
+	}
+
+	var builder strings.Builder
+	for _, part := range []string{bundle, uri} {
+		if builder.Len() > 0 {
+			builder.WriteByte(':')
+		}
+		for _, r := range part {
+			if r < 128 && (r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+				builder.WriteRune(r)
+			}
+		}
+	}
+	return builder.String()
+}
+
+// Date is a possibly partial date.
+type Date struct {
+	Raw   string // date as found in the data
+	Parts []int  // year, month and day, as far as they could be parsed from the date
+}
+
+var datePattern = regexp.MustCompile(`^(-?\d{4})(?:-(\d{2})(?:-(\d{2}))?)?`)
+
+// ParseDate parses a date starting with "YYYY", "YYYY-MM" or "YYYY-MM-DD".
+// Dates that do not start with a year have no parts.
+func ParseDate(raw string) Date {
+	date := Date{Raw: raw}
+
+	match := datePattern.FindStringSubmatch(raw)
+	if match == nil {
+		return date
+	}
+	for _, part := range match[1:] {
+		if part == "" {
+			break
+		}
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		date.Parts = append(date.Parts, value)
+	}
+	return date
+}
+
+// Year returns the year of this date, or the empty string if it is not known.
+func (date Date) Year() string {
+	if len(date.Parts) == 0 {
+		return ""
+	}
+	return strconv.Itoa(date.Parts[0])
+}
@@ -0,0 +1,190 @@
+//spellchecker:words citation
+package citation_test
+
+//spellchecker:words reflect strings testing github drincw pathbuilder hangover internal citation triplestore impl wisski
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/citation"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder bibtex csl
+
+func TestParseMappings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    citation.Mappings
+		wantErr bool
+	}{
+		{
+			name: "mappings",
+			input: "# comment\n\nbook type=book title=title creator=author,editor date=published\n" +
+				"letter title=subject,title\n",
+			want: citation.Mappings{
+				"book":   {Type: "book", Title: []string{"title"}, Creator: []string{"author", "editor"}, Date: []string{"published"}},
+				"letter": {Title: []string{"subject", "title"}},
+			},
+		},
+		{name: "missing pairs", input: "book\n", wantErr: true},
+		{name: "missing equals", input: "book title\n", wantErr: true},
+		{name: "unknown key", input: "book publisher=p\n", wantErr: true},
+		{name: "duplicate bundle", input: "book title=a\nbook title=b\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := citation.ParseMappings(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMappings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMappings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newCitation() citation.Citation {
+	bundle := &pathbuilder.Bundle{Path: pathbuilder.Path{ID: "book", Name: "Book"}}
+	bundle.ChildFields = []pathbuilder.Field{
+		{Path: pathbuilder.Path{ID: "title", Name: "Title"}},
+		{Path: pathbuilder.Path{ID: "author", Name: "Author"}},
+	}
+
+	value := func(v string) wisski.FieldValue { return wisski.FieldValue{Datum: impl.Datum{Value: v}} }
+	entity := &wisski.Entity{
+		URI: "http://example.com/book/42",
+		Fields: map[string][]wisski.FieldValue{
+			"title":  {value("Cats & Dogs {2nd ed.}")},
+			"author": {value("Doe, Jane"), value("Roe, Richard")},
+			"date":   {value("1999-07")},
+		},
+	}
+
+	mappings := citation.Mappings{
+		"book": {Type: "book", Title: []string{"title"}, Creator: []string{"author"}, Date: []string{"date"}},
+	}
+	return mappings.New(bundle, entity, "https://viewer.example.com/entity/book?uri=x")
+}
+
+func TestMappings_New(t *testing.T) {
+	t.Parallel()
+
+	want := citation.Citation{
+		Key:      "book:42",
+		Type:     "book",
+		Title:    "Cats & Dogs {2nd ed.}",
+		Creators: []string{"Doe, Jane", "Roe, Richard"},
+		Date:     citation.Date{Raw: "1999-07", Parts: []int{1999, 7}},
+		URL:      "https://viewer.example.com/entity/book?uri=x",
+		URI:      "http://example.com/book/42",
+	}
+	if got := newCitation(); !reflect.DeepEqual(got, want) {
+		t.Errorf("New() = %#v, want %#v", got, want)
+	}
+
+	// without a mapping the first field is used as a title
+	bundle := &pathbuilder.Bundle{Path: pathbuilder.Path{ID: "thing"}}
+	bundle.ChildFields = []pathbuilder.Field{{Path: pathbuilder.Path{ID: "label"}}}
+	entity := &wisski.Entity{URI: "http://example.com/thing/1"}
+
+	got := citation.Mappings{}.New(bundle, entity, "")
+	if got.Title != "http://example.com/thing/1" || got.Type != citation.DefaultType {
+		t.Errorf("New() without mapping = %#v", got)
+	}
+}
+
+func TestFormat_Write(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "bibtex",
+			want: "@book{book:42,\n" +
+				"  title = {Cats \\& Dogs \\{2nd ed.\\}},\n" +
+				"  author = {Doe, Jane and Roe, Richard},\n" +
+				"  year = {1999},\n" +
+				"  url = {https://viewer.example.com/entity/book?uri=x},\n" +
+				"  note = {http://example.com/book/42},\n" +
+				"}\n",
+		},
+		{
+			format: "ris",
+			want: "TY  - BOOK\r\n" +
+				"ID  - book:42\r\n" +
+				"TI  - Cats & Dogs {2nd ed.}\r\n" +
+				"AU  - Doe, Jane\r\n" +
+				"AU  - Roe, Richard\r\n" +
+				"PY  - 1999\r\n" +
+				"DA  - 1999/07//\r\n" +
+				"UR  - https://viewer.example.com/entity/book?uri=x\r\n" +
+				"N1  - http://example.com/book/42\r\n" +
+				"ER  - \r\n",
+		},
+		{
+			format: "csl-json",
+			want: `[
+  {
+    "id": "book:42",
+    "type": "book",
+    "title": "Cats & Dogs {2nd ed.}",
+    "author": [
+      {
+        "literal": "Doe, Jane"
+      },
+      {
+        "literal": "Roe, Richard"
+      }
+    ],
+    "issued": {
+      "date-parts": [
+        [
+          1999,
+          7
+        ]
+      ]
+    },
+    "URL": "https://viewer.example.com/entity/book?uri=x",
+    "note": "http://example.com/book/42"
+  }
+]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+
+			format, err := citation.FormatByName(tt.format)
+			if err != nil {
+				t.Fatalf("FormatByName() returned error %v", err)
+			}
+
+			var builder strings.Builder
+			if err := format.Write(&builder, newCitation()); err != nil {
+				t.Fatalf("Write() returned error %v", err)
+			}
+			if got := builder.String(); got != tt.want {
+				t.Errorf("Write() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := citation.FormatByName("docx"); err == nil {
+		t.Error("FormatByName() did not return an error for an unknown format")
+	}
+}
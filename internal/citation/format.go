@@ -0,0 +1,219 @@
+//spellchecker:words citation
+package citation
+
+//spellchecker:words encoding json errors strings
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//spellchecker:words bibtex csl incollection phdthesis manuscript unpublished JOUR CHAP THES MANSCPT ELEC
+
+// Format is a format citations can be written in.
+type Format struct {
+	Name        string // name used to select the format
+	Label       string // human-readable name
+	ContentType string
+	Extension   string // file extension, including the leading '.'
+
+	write func(w io.Writer, citation Citation) error
+}
+
+// Write writes citation to w in this format.
+func (format Format) Write(w io.Writer, citation Citation) error {
+	return format.write(w, citation)
+}
+
+// Formats lists all supported formats.
+var Formats = []Format{
+	{Name: "bibtex", Label: "BibTeX", ContentType: "application/x-bibtex", Extension: ".bib", write: writeBibTeX},
+	{Name: "ris", Label: "RIS", ContentType: "application/x-research-info-systems", Extension: ".ris", write: writeRIS},
+	{Name: "csl-json", Label: "CSL-JSON", ContentType: "application/vnd.citationstyles.csl+json", Extension: ".json", write: writeCSLJSON},
+}
+
+var errUnknownFormat = errors.New("unknown citation format")
+
+// FormatByName returns the format with the given name.
+func FormatByName(name string) (Format, error) {
+	for _, format := range Formats {
+		if format.Name == name {
+			return format, nil
+		}
+	}
+	return Format{}, fmt.Errorf("%w %q", errUnknownFormat, name)
+}
+
+// types maps CSL types to the corresponding BibTeX and RIS types.
+// Types not listed here are cited as generic documents.
+var types = map[string]struct{ BibTeX, RIS string }{
+	"book":            {"book", "BOOK"},
+	"article-journal": {"article", "JOUR"},
+	"chapter":         {"incollection", "CHAP"},
+	"thesis":          {"phdthesis", "THES"},
+	"manuscript":      {"unpublished", "MANSCPT"},
+	"dataset":         {"misc", "DATA"},
+	"webpage":         {"misc", "ELEC"},
+	"map":             {"misc", "MAP"},
+	"graphic":         {"misc", "ART"},
+}
+
+func typeOf(csl string) (bibtex, ris string) {
+	if t, ok := types[csl]; ok {
+		return t.BibTeX, t.RIS
+	}
+	return "misc", "GEN"
+}
+
+// writer writes strings to an underlying writer, remembering the first error.
+type writer struct {
+	w   io.Writer
+	err error
+}
+
+func (w *writer) print(parts ...string) {
+	for _, part := range parts {
+		if w.err != nil {
+			return
+		}
+		_, w.err = io.WriteString(w.w, part)
+	}
+}
+
+func writeBibTeX(w io.Writer, citation Citation) error {
+	bibtex, _ := typeOf(citation.Type)
+
+	out := writer{w: w}
+	out.print("@", bibtex, "{", citation.Key, ",\n")
+	field := func(name, value string) {
+		if value != "" {
+			out.print("  ", name, " = {", bibEscaper.Replace(value), "},\n")
+		}
+	}
+	verbatim := func(name, value string) {
+		// verbatim fields may not contain unbalanced braces
+		if value != "" && !strings.ContainsAny(value, "{}") {
+			out.print("  ", name, " = {", value, "},\n")
+		}
+	}
+	field("title", citation.Title)
+	field("author", strings.Join(citation.Creators, " and "))
+	field("year", citation.Date.Year())
+	if len(citation.Date.Parts) == 0 {
+		field("date", citation.Date.Raw)
+	}
+	verbatim("url", citation.URL)
+	field("note", citation.URI)
+	out.print("}\n")
+
+	if out.err != nil {
+		return fmt.Errorf("failed to write citation: %w", out.err)
+	}
+	return nil
+}
+
+var bibEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+	"\n", " ",
+)
+
+func writeRIS(w io.Writer, citation Citation) error {
+	_, ris := typeOf(citation.Type)
+
+	out := writer{w: w}
+	field := func(tag, value string) {
+		if value != "" {
+			out.print(tag, "  - ", strings.ReplaceAll(value, "\n", " "), "\r\n")
+		}
+	}
+	field("TY", ris)
+	field("ID", citation.Key)
+	field("TI", citation.Title)
+	for _, creator := range citation.Creators {
+		field("AU", creator)
+	}
+	field("PY", citation.Date.Year())
+	field("DA", risDate(citation.Date))
+	field("UR", citation.URL)
+	field("N1", citation.URI)
+	out.print("ER  - \r\n")
+
+	if out.err != nil {
+		return fmt.Errorf("failed to write citation: %w", out.err)
+	}
+	return nil
+}
+
+// risDate formats a date as "YYYY/MM/DD/", leaving out unknown parts.
+func risDate(date Date) string {
+	if len(date.Parts) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	for i := range 3 {
+		if i < len(date.Parts) {
+			fmt.Fprintf(&builder, "%0*d", []int{4, 2, 2}[i], date.Parts[i])
+		}
+		builder.WriteByte('/')
+	}
+	return builder.String()
+}
+
+// cslItem is a single item of CSL-JSON.
+type cslItem struct {
+	ID     string    `json:"id"`
+	Type   string    `json:"type"`
+	Title  string    `json:"title"`
+	Author []cslName `json:"author,omitempty"`
+	Issued *cslDate  `json:"issued,omitempty"`
+	URL    string    `json:"URL,omitempty"`
+	Note   string    `json:"note,omitempty"`
+}
+
+type cslName struct {
+	Literal string `json:"literal"`
+}
+
+type cslDate struct {
+	DateParts [][]int `json:"date-parts,omitempty"`
+	Raw       string  `json:"raw,omitempty"`
+}
+
+func writeCSLJSON(w io.Writer, citation Citation) error {
+	item := cslItem{
+		ID:    citation.Key,
+		Type:  citation.Type,
+		Title: citation.Title,
+		URL:   citation.URL,
+		Note:  citation.URI,
+	}
+	for _, creator := range citation.Creators {
+		item.Author = append(item.Author, cslName{Literal: creator})
+	}
+	switch {
+	case len(citation.Date.Parts) > 0:
+		item.Issued = &cslDate{DateParts: [][]int{citation.Date.Parts}}
+	case citation.Date.Raw != "":
+		item.Issued = &cslDate{Raw: citation.Date.Raw}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode([]cslItem{item}); err != nil {
+		return fmt.Errorf("failed to write citation: %w", err)
+	}
+	return nil
+}
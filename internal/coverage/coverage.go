@@ -0,0 +1,173 @@
+// Package coverage reports how well a pathbuilder covers the data it was applied to.
+//
+//spellchecker:words coverage
+package coverage
+
+//spellchecker:words github drincw pathbuilder hangover internal triplestore igraph wisski
+import (
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+// Report describes the coverage of a pathbuilder.
+type Report struct {
+	Bundles []Bundle // top-level bundles
+
+	// Triples in the dump not covered by any path.
+	// These are masked when building the index, see [igraph.Stats].
+	UncoveredTriples     uint64 // triples whose predicate does not occur in any path
+	UncoveredDataTriples uint64 // datatype triples whose property is not used by any field
+}
+
+// Bundle describes the coverage of a single bundle.
+type Bundle struct {
+	MachineName string
+	Name        string
+
+	Entities int // total number of entities
+	Values       // number of entities per parent entity; zero for top-level bundles
+
+	Fields  []Field
+	Bundles []Bundle // child bundles
+}
+
+// Field describes the coverage of a single field.
+type Field struct {
+	MachineName string
+	Name        string
+	FieldType   string
+
+	Values // number of values per entity
+}
+
+// Values holds statistics about the number of values per entity.
+type Values struct {
+	Cardinality int     // declared cardinality; values <= 0 mean unlimited
+	Present     int     // number of entities with at least one value
+	Missing     int     // number of entities without any values
+	Exceeding   int     // number of entities with more values than the declared cardinality
+	Min, Max    int     // minimal and maximal number of values per entity
+	Average     float64 // average number of values per entity
+}
+
+// Unmatched checks if no entity has any value.
+func (v Values) Unmatched() bool {
+	return v.Present == 0
+}
+
+// add records that an entity has count values.
+func (v *Values) add(count int) {
+	total := v.Present + v.Missing
+	if total == 0 || count < v.Min {
+		v.Min = count
+	}
+	v.Max = max(v.Max, count)
+	v.Average = (v.Average*float64(total) + float64(count)) / float64(total+1)
+
+	if count == 0 {
+		v.Missing++
+	} else {
+		v.Present++
+	}
+	if v.Cardinality > 0 && count > v.Cardinality {
+		v.Exceeding++
+	}
+}
+
+// New computes the coverage of the given pathbuilder.
+// entities returns the entities of the top-level bundle with the given machine name.
+// stats are the statistics of the index the entities were extracted from.
+func New(pb *pathbuilder.Pathbuilder, entities func(bundle string) []wisski.Entity, stats igraph.Stats) *Report {
+	report := &Report{
+		UncoveredTriples:     stats.MaskedPredTriples,
+		UncoveredDataTriples: stats.MaskedDataTriples,
+	}
+
+	for _, bundle := range pb.Bundles() {
+		es := entities(bundle.MachineName())
+
+		ptrs := make([]*wisski.Entity, len(es))
+		for i := range es {
+			ptrs[i] = &es[i]
+		}
+		report.Bundles = append(report.Bundles, newBundle(bundle, ptrs))
+	}
+	return report
+}
+
+func newBundle(bundle *pathbuilder.Bundle, entities []*wisski.Entity) Bundle {
+	result := Bundle{
+		MachineName: bundle.MachineName(),
+		Name:        bundle.Name,
+		Entities:    len(entities),
+	}
+
+	for _, field := range bundle.Fields() {
+		stats := Field{
+			MachineName: field.MachineName(),
+			Name:        field.Name,
+			FieldType:   field.FieldType,
+			Values:      Values{Cardinality: field.Cardinality},
+		}
+		for _, entity := range entities {
+			stats.add(len(entity.Fields[field.MachineName()]))
+		}
+		result.Fields = append(result.Fields, stats)
+	}
+
+	for _, child := range bundle.Bundles() {
+		counts := Values{Cardinality: child.Cardinality}
+
+		var children []*wisski.Entity
+		for _, entity := range entities {
+			values := entity.Children[child.MachineName()]
+			counts.add(len(values))
+			for i := range values {
+				children = append(children, &values[i])
+			}
+		}
+
+		stats := newBundle(child, children)
+		stats.Values = counts
+		result.Bundles = append(result.Bundles, stats)
+	}
+
+	return result
+}
+
+// Path is a field or bundle that does not match any data.
+type Path struct {
+	Path []string // machine names of the containing bundles and the field or bundle itself
+	Name string   // human-readable name
+}
+
+// Unmatched returns all fields and bundles that do not match any data.
+// Child bundles and fields of unmatched bundles are not included.
+func (report *Report) Unmatched() []Path {
+	var paths []Path
+
+	var walk func(prefix []string, bundle Bundle)
+	walk = func(prefix []string, bundle Bundle) {
+		path := append(prefix[:len(prefix):len(prefix)], bundle.MachineName)
+		if bundle.Entities == 0 {
+			paths = append(paths, Path{Path: path, Name: bundle.Name})
+			return
+		}
+		for _, field := range bundle.Fields {
+			if field.Unmatched() {
+				paths = append(paths, Path{Path: append(path[:len(path):len(path)], field.MachineName), Name: field.Name})
+			}
+		}
+		for _, child := range bundle.Bundles {
+			walk(path, child)
+		}
+	}
+	for _, bundle := range report.Bundles {
+		walk(nil, bundle)
+	}
+
+	return paths
+}
@@ -0,0 +1,85 @@
+//spellchecker:words coverage
+package coverage_test
+
+//spellchecker:words bytes reflect strings testing github drincw pathbuilder hangover internal coverage triplestore igraph impl wisski
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/coverage"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+func newPathbuilder() *pathbuilder.Pathbuilder {
+	pb := pathbuilder.NewPathbuilder()
+
+	person := pb.GetOrCreate("person")
+	person.Path = pathbuilder.Path{ID: "person", Name: "Person", IsGroup: true, Enabled: true}
+	person.ChildFields = []pathbuilder.Field{
+		{Path: pathbuilder.Path{ID: "name", Name: "Name", Cardinality: 1}},
+		{Path: pathbuilder.Path{ID: "nickname", Name: "Nickname", Cardinality: -1}},
+	}
+
+	return &pb
+}
+
+func values(v ...string) []wisski.FieldValue {
+	result := make([]wisski.FieldValue, len(v))
+	for i, value := range v {
+		result[i] = wisski.FieldValue{Datum: impl.Datum{Value: value}}
+	}
+	return result
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	entities := map[string][]wisski.Entity{
+		"person": {
+			{URI: "http://example.com/alice", Fields: map[string][]wisski.FieldValue{"name": values("Alice")}},
+			{URI: "http://example.com/bob", Fields: map[string][]wisski.FieldValue{"name": values("Bob", "Robert")}},
+			{URI: "http://example.com/carol", Fields: map[string][]wisski.FieldValue{}},
+		},
+	}
+
+	report := coverage.New(newPathbuilder(), func(bundle string) []wisski.Entity { return entities[bundle] }, igraph.Stats{MaskedPredTriples: 42})
+
+	if report.UncoveredTriples != 42 {
+		t.Errorf("UncoveredTriples = %d, want %d", report.UncoveredTriples, 42)
+	}
+	if len(report.Bundles) != 1 {
+		t.Fatalf("got %d bundles, want 1", len(report.Bundles))
+	}
+
+	bundle := report.Bundles[0]
+	if bundle.Entities != 3 {
+		t.Errorf("Entities = %d, want 3", bundle.Entities)
+	}
+
+	wantName := coverage.Values{Cardinality: 1, Present: 2, Missing: 1, Exceeding: 1, Min: 0, Max: 2, Average: 1}
+	if got := bundle.Fields[0].Values; !reflect.DeepEqual(got, wantName) {
+		t.Errorf("name = %#v, want %#v", got, wantName)
+	}
+
+	wantUnmatched := []coverage.Path{{Path: []string{"person", "nickname"}, Name: "Nickname"}}
+	if got := report.Unmatched(); !reflect.DeepEqual(got, wantUnmatched) {
+		t.Errorf("Unmatched() = %#v, want %#v", got, wantUnmatched)
+	}
+
+	var markdown bytes.Buffer
+	if err := report.WriteMarkdown(&markdown); err != nil {
+		t.Fatalf("WriteMarkdown() returned error %v", err)
+	}
+	for _, part := range []string{"- Nickname (`person/nickname`)", "| Name (`name`) |  | 1 | 2 | 1 | 0 | 2 | 1.00 | 1 |"} {
+		if !strings.Contains(markdown.String(), part) {
+			t.Errorf("WriteMarkdown() does not contain %q:\n%s", part, markdown.String())
+		}
+	}
+}
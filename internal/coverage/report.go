@@ -0,0 +1,39 @@
+//spellchecker:words coverage
+package coverage
+
+//spellchecker:words embed strings text template
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/report.md
+var reportMarkdownSource string
+
+var reportMarkdown = template.Must(template.New("report.md").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"dict": func(pairs ...any) map[string]any {
+		result := make(map[string]any, len(pairs)/2)
+		for i := 0; i+1 < len(pairs); i += 2 {
+			result[fmt.Sprint(pairs[i])] = pairs[i+1]
+		}
+		return result
+	},
+	"cardinality": func(cardinality int) string {
+		if cardinality <= 0 {
+			return "unlimited"
+		}
+		return fmt.Sprint(cardinality)
+	},
+}).Parse(reportMarkdownSource))
+
+// WriteMarkdown writes a human-readable version of the report in markdown format to w.
+func (report *Report) WriteMarkdown(w io.Writer) error {
+	if err := reportMarkdown.Execute(w, report); err != nil {
+		return fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return nil
+}
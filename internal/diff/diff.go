@@ -0,0 +1,309 @@
+// Package diff compares two datasets extracted from a pathbuilder.
+//
+//spellchecker:words diff
+package diff
+
+//spellchecker:words slices strings github drincw pathbuilder hangover internal sparkl triplestore impl wisski
+import (
+	"slices"
+	"strings"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+// fieldTypeReference is the field type of fields referencing other entities.
+const fieldTypeReference = "entity_reference"
+
+// Dataset is one side of a diff.
+type Dataset struct {
+	Name    string                // human-readable name, e.g. the path it was loaded from
+	Bundles []*pathbuilder.Bundle // top-level bundles of the pathbuilder
+	Cache   *sparkl.Cache
+}
+
+// Diff holds the differences between an old and a new dataset.
+type Diff struct {
+	Old, New string // names of the datasets
+	Bundles  []Bundle
+}
+
+// Empty checks if the two datasets contain the same data.
+func (diff *Diff) Empty() bool {
+	for _, bundle := range diff.Bundles {
+		if !bundle.Empty() {
+			return false
+		}
+	}
+	return true
+}
+
+// Bundle holds the differences of a single top-level bundle.
+type Bundle struct {
+	MachineName string
+	Name        string
+
+	Old, New int // number of entities in the old and new dataset
+
+	Added   []impl.Label `json:",omitempty"` // entities only in the new dataset
+	Removed []impl.Label `json:",omitempty"` // entities only in the old dataset
+	Changed []Entity     `json:",omitempty"` // entities in both datasets with different values
+}
+
+// Empty checks if the bundle is unchanged.
+func (bundle Bundle) Empty() bool {
+	return len(bundle.Added) == 0 && len(bundle.Removed) == 0 && len(bundle.Changed) == 0
+}
+
+// Entity holds the changed values of an entity that exists in both datasets.
+type Entity struct {
+	URI    impl.Label // uri in the new dataset
+	OldURI impl.Label `json:",omitempty"` // uri in the old dataset, if different
+	Fields []Field
+}
+
+// Field holds the changed values of a single field or child bundle.
+type Field struct {
+	Path    string   // machine names of the child bundles and the field, separated by '/'
+	Name    string   // human-readable names of the child bundles and the field, separated by ' / '
+	Added   []string `json:",omitempty"` // values only in the new dataset
+	Removed []string `json:",omitempty"` // values only in the old dataset
+}
+
+// Compare compares the old and the new dataset.
+//
+// Entities are matched by uri, taking the sameAs mappings of both datasets into account.
+// Fields are compared by value, ignoring order and duplicates.
+// Values of entity references and child entities are canonicalized before comparing.
+func Compare(before, after Dataset) *Diff {
+	c := Comparer{Before: before.Cache, After: after.Cache}
+
+	diff := &Diff{Old: before.Name, New: after.Name}
+
+	// compare the bundles in the order of the new pathbuilder, followed by removed bundles
+	olds := make(map[string]*pathbuilder.Bundle, len(before.Bundles))
+	for _, bundle := range before.Bundles {
+		olds[bundle.MachineName()] = bundle
+	}
+	for _, bundle := range after.Bundles {
+		diff.Bundles = append(diff.Bundles, c.bundle(olds[bundle.MachineName()], bundle))
+		delete(olds, bundle.MachineName())
+	}
+	for _, bundle := range before.Bundles {
+		if _, ok := olds[bundle.MachineName()]; ok {
+			diff.Bundles = append(diff.Bundles, c.bundle(bundle, nil))
+		}
+	}
+
+	return diff
+}
+
+// Comparer compares individual entities of an old and a new dataset.
+type Comparer struct {
+	Before, After *sparkl.Cache
+}
+
+// bundle compares the entities of a single bundle.
+// Either of before or after may be nil when the bundle only exists in one of the pathbuilders.
+func (c Comparer) bundle(before, after *pathbuilder.Bundle) (diff Bundle) {
+	var olds, news []wisski.Entity
+	if before != nil {
+		diff.MachineName, diff.Name = before.MachineName(), before.Name
+		olds = c.Before.Entities(before.MachineName())
+	}
+	if after != nil {
+		diff.MachineName, diff.Name = after.MachineName(), after.Name
+		news = c.After.Entities(after.MachineName())
+	}
+	diff.Old, diff.New = len(olds), len(news)
+
+	paths, names := fieldNames(before, after)
+
+	matched := make(map[impl.Label]struct{}, len(olds))
+	for i := range news {
+		entity := &news[i]
+
+		var old *wisski.Entity
+		if before != nil {
+			old = c.Match(entity.URI, before.MachineName())
+		}
+		if old == nil {
+			diff.Added = append(diff.Added, entity.URI)
+			continue
+		}
+		matched[old.URI] = struct{}{}
+
+		fields := c.fields(paths, names, before, after, old, entity)
+		if len(fields) == 0 {
+			continue
+		}
+
+		changed := Entity{URI: entity.URI, Fields: fields}
+		if old.URI != entity.URI {
+			changed.OldURI = old.URI
+		}
+		diff.Changed = append(diff.Changed, changed)
+	}
+
+	for _, entity := range olds {
+		if _, ok := matched[entity.URI]; !ok {
+			diff.Removed = append(diff.Removed, entity.URI)
+		}
+	}
+
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	slices.SortFunc(diff.Changed, func(a, b Entity) int {
+		return strings.Compare(string(a.URI), string(b.URI))
+	})
+	return diff
+}
+
+// Match finds the entity in the given bundle of the old dataset that corresponds to uri in the new dataset.
+// If no such entity exists, returns nil.
+func (c Comparer) Match(uri impl.Label, bundle string) *wisski.Entity {
+	if entity, ok := c.Before.Entity(uri, bundle); ok {
+		return entity
+	}
+	for _, alias := range c.After.Aliases(uri) {
+		if entity, ok := c.Before.Entity(alias, bundle); ok {
+			return entity
+		}
+	}
+	return nil
+}
+
+// Fields returns the changed fields and child bundles of an entity.
+// old and current are the entity in the old and new dataset, belonging to the before and after bundle respectively.
+func (c Comparer) Fields(before, after *pathbuilder.Bundle, old, current *wisski.Entity) []Field {
+	paths, names := fieldNames(before, after)
+	return c.fields(paths, names, before, after, old, current)
+}
+
+func (c Comparer) fields(paths []string, names map[string]string, before, after *pathbuilder.Bundle, old, current *wisski.Entity) []Field {
+	oldValues := make(map[string][]string)
+	c.values(oldValues, "", before, old)
+
+	newValues := make(map[string][]string)
+	c.values(newValues, "", after, current)
+
+	var fields []Field
+	for _, path := range paths {
+		added, removed := difference(oldValues[path], newValues[path])
+		if len(added) == 0 && len(removed) == 0 {
+			continue
+		}
+		fields = append(fields, Field{Path: path, Name: names[path], Added: added, Removed: removed})
+	}
+	return fields
+}
+
+// canonical returns a representation of uri that is the same for both datasets, if either considers them to be the same entity.
+func (c Comparer) canonical(uri impl.Label) impl.Label {
+	if canon := c.After.Canonical(uri); canon != "" {
+		return canon
+	}
+	canon := c.Before.Canonical(uri)
+	if canon == "" {
+		return uri
+	}
+
+	// the after dataset might know it under one of its aliases
+	for _, alias := range append([]impl.Label{canon}, c.Before.Aliases(canon)...) {
+		if acanon := c.After.Canonical(alias); acanon != "" {
+			return acanon
+		}
+	}
+	return canon
+}
+
+// values stores the values of all fields and child bundles of entity in values.
+// Keys are the paths used in [Field], prefixed with prefix.
+func (c Comparer) values(values map[string][]string, prefix string, bundle *pathbuilder.Bundle, entity *wisski.Entity) {
+	for _, field := range bundle.ChildFields {
+		key := prefix + field.MachineName()
+		for _, value := range entity.Fields[field.MachineName()] {
+			text := value.Datum.Value
+			if field.FieldType == fieldTypeReference {
+				text = string(c.canonical(impl.Label(text)))
+			}
+			if value.Datum.Language != "" {
+				text += "@" + value.Datum.Language
+			}
+			values[key] = append(values[key], text)
+		}
+	}
+
+	for _, child := range bundle.ChildBundles {
+		key := prefix + child.MachineName()
+		children := entity.Children[child.MachineName()]
+		for i := range children {
+			values[key] = append(values[key], string(c.canonical(children[i].URI)))
+			c.values(values, key+"/", child, &children[i])
+		}
+	}
+}
+
+// fieldNames returns the paths of all fields and child bundles of the given bundles in pathbuilder order,
+// along with their human-readable names.
+func fieldNames(before, after *pathbuilder.Bundle) (paths []string, names map[string]string) {
+	names = make(map[string]string)
+	collectNames(names, &paths, "", "", after)
+	collectNames(names, &paths, "", "", before)
+	return paths, names
+}
+
+// collectNames stores the human-readable names of all fields and child bundles of bundle in names.
+// Paths not yet contained in names are appended to paths.
+func collectNames(names map[string]string, paths *[]string, prefix, namePrefix string, bundle *pathbuilder.Bundle) {
+	if bundle == nil {
+		return
+	}
+
+	add := func(path, name string) {
+		if _, ok := names[path]; ok {
+			return
+		}
+		names[path] = name
+		*paths = append(*paths, path)
+	}
+
+	for _, field := range bundle.Fields() {
+		add(prefix+field.MachineName(), namePrefix+field.Name)
+	}
+	for _, child := range bundle.Bundles() {
+		add(prefix+child.MachineName(), namePrefix+child.Name)
+		collectNames(names, paths, prefix+child.MachineName()+"/", namePrefix+child.Name+" / ", child)
+	}
+}
+
+// difference returns the sorted values that only occur in after and in before respectively.
+func difference(before, after []string) (added, removed []string) {
+	beforeSet := make(map[string]struct{}, len(before))
+	for _, value := range before {
+		beforeSet[value] = struct{}{}
+	}
+	afterSet := make(map[string]struct{}, len(after))
+	for _, value := range after {
+		afterSet[value] = struct{}{}
+	}
+
+	for value := range afterSet {
+		if _, ok := beforeSet[value]; !ok {
+			added = append(added, value)
+		}
+	}
+	for value := range beforeSet {
+		if _, ok := afterSet[value]; !ok {
+			removed = append(removed, value)
+		}
+	}
+
+	slices.Sort(added)
+	slices.Sort(removed)
+	return added, removed
+}
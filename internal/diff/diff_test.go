@@ -0,0 +1,132 @@
+//spellchecker:words diff
+package diff_test
+
+//spellchecker:words bytes reflect strings testing github drincw pathbuilder hangover internal diff sparkl triplestore imap impl wisski
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/diff"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/triplestore/imap"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+func newBundle() *pathbuilder.Bundle {
+	bundle := &pathbuilder.Bundle{Path: pathbuilder.Path{ID: "person", Name: "Person", IsGroup: true}}
+	bundle.ChildFields = []pathbuilder.Field{
+		{Path: pathbuilder.Path{ID: "name", Name: "Name"}},
+		{Path: pathbuilder.Path{ID: "friend", Name: "Friend", FieldType: "entity_reference"}},
+	}
+	return bundle
+}
+
+func newDataset(t *testing.T, name string, sameAs map[impl.Label]impl.Label, entities ...wisski.Entity) diff.Dataset {
+	t.Helper()
+
+	identities := imap.MakeMemory[impl.Label, impl.Label](len(sameAs))
+	for alias, canon := range sameAs {
+		if err := identities.Set(alias, canon); err != nil {
+			t.Fatalf("failed to set identity: %v", err)
+		}
+	}
+
+	cache, err := sparkl.NewCache(map[string][]wisski.Entity{"person": entities}, &identities, nil)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := cache.Close(); err != nil {
+			t.Errorf("failed to close cache: %v", err)
+		}
+	})
+
+	return diff.Dataset{Name: name, Bundles: []*pathbuilder.Bundle{newBundle()}, Cache: &cache}
+}
+
+func person(uri impl.Label, name string, friends ...string) wisski.Entity {
+	entity := wisski.Entity{URI: uri, Fields: map[string][]wisski.FieldValue{}}
+	entity.Fields["name"] = []wisski.FieldValue{{Datum: impl.Datum{Value: name}}}
+	for _, friend := range friends {
+		entity.Fields["friend"] = append(entity.Fields["friend"], wisski.FieldValue{Datum: impl.Datum{Value: friend}})
+	}
+	return entity
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	before := newDataset(t, "old", nil,
+		person("http://example.com/alice", "Alice", "http://example.com/bob"),
+		person("http://example.com/bob", "Bob"),
+		person("http://example.com/carol", "Carol"),
+	)
+	after := newDataset(t, "new", map[impl.Label]impl.Label{"http://example.com/bob": "http://example.org/bob"},
+		person("http://example.com/alice", "Alice", "http://example.com/bob"),
+		person("http://example.org/bob", "Robert"),
+		person("http://example.com/dave", "Dave"),
+	)
+
+	got := diff.Compare(before, after)
+	want := &diff.Diff{
+		Old: "old",
+		New: "new",
+		Bundles: []diff.Bundle{
+			{
+				MachineName: "person",
+				Name:        "Person",
+				Old:         3,
+				New:         3,
+				Added:       []impl.Label{"http://example.com/dave"},
+				Removed:     []impl.Label{"http://example.com/carol"},
+				Changed: []diff.Entity{
+					{
+						URI:    "http://example.org/bob",
+						OldURI: "http://example.com/bob",
+						Fields: []diff.Field{
+							{Path: "name", Name: "Name", Added: []string{"Robert"}, Removed: []string{"Bob"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() = %#v, want %#v", got, want)
+	}
+
+	var markdown bytes.Buffer
+	if err := got.WriteMarkdown(&markdown); err != nil {
+		t.Fatalf("WriteMarkdown() returned error %v", err)
+	}
+	for _, part := range []string{"## Person (`person`)", "- `http://example.com/dave`", "  - added `Robert`"} {
+		if !strings.Contains(markdown.String(), part) {
+			t.Errorf("WriteMarkdown() does not contain %q:\n%s", part, markdown.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := got.WriteHTML(&html); err != nil {
+		t.Fatalf("WriteHTML() returned error %v", err)
+	}
+	if !strings.Contains(html.String(), `<code>Robert</code>`) {
+		t.Errorf("WriteHTML() does not contain the added value:\n%s", html.String())
+	}
+}
+
+func TestCompare_unchanged(t *testing.T) {
+	t.Parallel()
+
+	before := newDataset(t, "old", nil, person("http://example.com/alice", "Alice"))
+	after := newDataset(t, "new", nil, person("http://example.com/alice", "Alice"))
+
+	if got := diff.Compare(before, after); !got.Empty() {
+		t.Errorf("Compare() of equal datasets = %#v, want empty", got)
+	}
+}
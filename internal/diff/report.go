@@ -0,0 +1,88 @@
+//spellchecker:words diff
+package diff
+
+//spellchecker:words embed encoding json html template strings text
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	ttemplate "text/template"
+	"unicode/utf8"
+)
+
+// MaxReportValue is the maximal number of characters of a value shown in a report.
+// Longer values are truncated; the json output always contains full values.
+const MaxReportValue = 200
+
+// WriteJSON writes the diff as indented json to w.
+func (diff *Diff) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(diff); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
+
+//go:embed templates/report.md
+var reportMarkdownSource string
+
+var reportMarkdown = ttemplate.Must(ttemplate.New("report.md").Funcs(ttemplate.FuncMap{
+	"code":     markdownCode,
+	"truncate": truncate,
+}).Parse(reportMarkdownSource))
+
+// WriteMarkdown writes a human-readable report of the diff in markdown format to w.
+func (diff *Diff) WriteMarkdown(w io.Writer) error {
+	if err := reportMarkdown.Execute(w, diff); err != nil {
+		return fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return nil
+}
+
+//go:embed templates/report.html
+var reportHTMLSource string
+
+var reportHTML = template.Must(template.New("report.html").Funcs(template.FuncMap{
+	"truncate": truncate,
+}).Parse(reportHTMLSource))
+
+// WriteHTML writes a human-readable report of the diff as a stand-alone html page to w.
+func (diff *Diff) WriteHTML(w io.Writer) error {
+	if err := reportHTML.Execute(w, diff); err != nil {
+		return fmt.Errorf("failed to render html: %w", err)
+	}
+	return nil
+}
+
+// truncate truncates value to at most [MaxReportValue] characters.
+func truncate(value string) string {
+	if utf8.RuneCountInString(value) <= MaxReportValue {
+		return value
+	}
+	return string([]rune(value)[:MaxReportValue]) + "…"
+}
+
+// markdownCode formats value as markdown inline code.
+func markdownCode(value any) string {
+	text := strings.Join(strings.Fields(fmt.Sprint(value)), " ")
+
+	// use a fence that is longer than any run of backticks inside the text
+	longest, current := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if longest > 0 || text == "" {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
@@ -0,0 +1,135 @@
+//spellchecker:words geo
+package geo_test
+
+//spellchecker:words encoding json strings testing github drincw pathbuilder hangover internal triplestore impl wisski
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/geo"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words geojson pathbuilder linestring multipoint multipolygon
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value string
+		want  string // json encoding of the geometry
+		ok    bool
+	}{
+		{"POINT(11.0 49.6)", `{"type":"Point","coordinates":[11,49.6]}`, true},
+		{"Point Z (11 49.6 300)", `{"type":"Point","coordinates":[11,49.6,300]}`, true},
+		{"<http://www.opengis.net/def/crs/OGC/1.3/CRS84> POINT(11 49.6)", `{"type":"Point","coordinates":[11,49.6]}`, true},
+		{"<http://www.opengis.net/def/crs/EPSG/0/4326> POINT(49.6 11)", `{"type":"Point","coordinates":[11,49.6]}`, true},
+		{"LINESTRING (1 2, 3 4)", `{"type":"LineString","coordinates":[[1,2],[3,4]]}`, true},
+		{"POLYGON ((0 0, 1 0, 1 1, 0 0))", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`, true},
+		{"MULTIPOINT ((1 2), (3 4))", `{"type":"MultiPoint","coordinates":[[1,2],[3,4]]}`, true},
+		{"MULTIPOINT (1 2, 3 4)", `{"type":"MultiPoint","coordinates":[[1,2],[3,4]]}`, true},
+		{"MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)))", `{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]]]}`, true},
+		{"geo:49.6,11", `{"type":"Point","coordinates":[11,49.6]}`, true},
+		{"GEO:49.6,11,300;u=10", `{"type":"Point","coordinates":[11,49.6,300]}`, true},
+
+		{"", "", false},
+		{"Erlangen", "", false},
+		{"POINT(11 49.6", "", false},
+		{"POINT(11 49.6) trailing", "", false},
+		{"POINT(1 2, 3 4)", "", false},
+		{"POINT(200 0)", "", false},
+		{"POINT EMPTY", "", false},
+		{"POLYGON (0 0, 1 0, 1 1, 0 0)", "", false},
+		{"CIRCLE (0 0)", "", false},
+		{"<http://example.com/crs> POINT(11 49.6)", "", false},
+		{"geo:49.6", "", false},
+		{"geo:49.6,11;crs=other", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+
+			geometry, ok := geo.Parse(tt.value)
+			if ok != tt.ok {
+				t.Fatalf("Parse() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+
+			got, err := json.Marshal(geometry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMappings(t *testing.T) {
+	t.Parallel()
+
+	mappings, err := geo.ParseMappings(strings.NewReader("# places\nplace lat=latitude long=longitude\nregion geometry=outline,center\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mappings["place"]; got.Lat != "latitude" || got.Long != "longitude" || got.Geometry != nil {
+		t.Errorf("mappings[place] = %v", got)
+	}
+	if got := mappings["region"]; len(got.Geometry) != 2 || got.Geometry[0] != "outline" || got.Geometry[1] != "center" {
+		t.Errorf("mappings[region] = %v", got)
+	}
+
+	for _, invalid := range []string{"place", "place lat=latitude", "place radius=r", "place geometry", "place geometry=a\nplace geometry=b"} {
+		if _, err := geo.ParseMappings(strings.NewReader(invalid)); err == nil {
+			t.Errorf("ParseMappings(%q) did not return an error", invalid)
+		}
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	t.Parallel()
+
+	bundle := &pathbuilder.Bundle{Path: pathbuilder.Path{ID: "place", Name: "Place"}}
+	bundle.ChildFields = []pathbuilder.Field{
+		{Path: pathbuilder.Path{ID: "name", Name: "Name"}},
+		{Path: pathbuilder.Path{ID: "location", Name: "Location"}},
+		{Path: pathbuilder.Path{ID: "lat", Name: "Latitude"}},
+		{Path: pathbuilder.Path{ID: "long", Name: "Longitude"}},
+	}
+
+	value := func(v string) []wisski.FieldValue { return []wisski.FieldValue{{Datum: impl.Datum{Value: v}}} }
+	entities := []wisski.Entity{
+		{URI: "a", Fields: map[string][]wisski.FieldValue{"name": value("Erlangen"), "location": value("POINT(11.0 49.6)"), "lat": value("49.6"), "long": value("11.0")}},
+		{URI: "b", Fields: map[string][]wisski.FieldValue{"name": value("Nürnberg"), "location": value("geo:49.45,11.08")}},
+		{URI: "c", Fields: map[string][]wisski.FieldValue{"name": value("Nowhere"), "location": value("unknown")}},
+	}
+	link := func(uri impl.Label) string { return "/entity/place?uri=" + string(uri) }
+
+	// detected automatically
+	features := geo.Mappings(nil).Features(bundle, entities, link)
+	if len(features) != 2 {
+		t.Fatalf("Features() returned %d features, want 2", len(features))
+	}
+	if got := features[0].Properties; got != (geo.Properties{Title: "Erlangen", URI: "a", URL: "/entity/place?uri=a", Bundle: "place", Field: "location"}) {
+		t.Errorf("Features()[0].Properties = %v", got)
+	}
+	if got := features[1].Properties; got.URI != "b" || got.Title != "Nürnberg" {
+		t.Errorf("Features()[1].Properties = %v", got)
+	}
+
+	// explicit latitude and longitude
+	features = geo.Mappings{"place": {Lat: "lat", Long: "long"}}.Features(bundle, entities, nil)
+	if len(features) != 1 || features[0].Properties.URI != "a" || features[0].Properties.Field != "lat,long" || features[0].Properties.URL != "" {
+		t.Fatalf("Features() = %v", features)
+	}
+	if got, _ := json.Marshal(features[0].Geometry); string(got) != `{"type":"Point","coordinates":[11,49.6]}` {
+		t.Errorf("Features()[0].Geometry = %s", got)
+	}
+}
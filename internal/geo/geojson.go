@@ -0,0 +1,238 @@
+//spellchecker:words geo
+package geo
+
+//spellchecker:words bufio errors strings github drincw pathbuilder hangover internal overview triplestore impl wisski
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/overview"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words geojson pathbuilder
+
+// MinGeometryRatio is the minimal fraction of values of a field that must be geometries for the field to be detected automatically.
+const MinGeometryRatio = 0.5
+
+// Mapping determines which fields of a bundle hold geometries.
+type Mapping struct {
+	Geometry []string // fields holding WKT literals or geo uris
+	Lat      string   // field holding the latitude, used together with Long
+	Long     string   // field holding the longitude, used together with Lat
+}
+
+// Mappings holds mappings by bundle machine name.
+// Bundles without a mapping have their geometry fields detected automatically.
+type Mappings map[string]Mapping
+
+// Keys used in mapping files.
+const (
+	KeyGeometry = "geometry"
+	KeyLat      = "lat"
+	KeyLong     = "long"
+)
+
+var (
+	errEmptyMapping     = errors.New("mapping needs at least one 'key=field' pair")
+	errDuplicateMapping = errors.New("duplicate mapping")
+	errMissingEquals    = errors.New("missing '='")
+	errUnknownKey       = errors.New("unknown key")
+	errIncompletePair   = errors.New("'lat' and 'long' must be given together")
+)
+
+// ParseMappings parses a mappings file.
+//
+// Each line of the file is of the form "bundle key=field[,field...]...".
+// bundle is the machine name of a bundle, and each key is one of "geometry", "lat" or "long".
+// "lat" and "long" take a single field each, and must be given together.
+// Empty lines and lines starting with '#' are ignored.
+func ParseMappings(reader io.Reader) (Mappings, error) {
+	mappings := make(Mappings)
+
+	scanner := bufio.NewScanner(reader)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		bundle, mapping, err := parseMapping(line)
+		if err == nil {
+			if _, ok := mappings[bundle]; ok {
+				err = fmt.Errorf("%w for bundle %q", errDuplicateMapping, bundle)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		mappings[bundle] = mapping
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return mappings, nil
+}
+
+func parseMapping(line string) (bundle string, mapping Mapping, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return "", mapping, errEmptyMapping
+	}
+
+	for _, pair := range fields[1:] {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return "", mapping, fmt.Errorf("%w in %q", errMissingEquals, pair)
+		}
+		switch key {
+		case KeyGeometry:
+			mapping.Geometry = append(mapping.Geometry, strings.Split(value, ",")...)
+		case KeyLat:
+			mapping.Lat = value
+		case KeyLong:
+			mapping.Long = value
+		default:
+			return "", mapping, fmt.Errorf("%w %q", errUnknownKey, key)
+		}
+	}
+	if (mapping.Lat == "") != (mapping.Long == "") {
+		return "", mapping, errIncompletePair
+	}
+	return fields[0], mapping, nil
+}
+
+// LoadMappings loads mappings from the file at path.
+func LoadMappings(path string) (mappings Mappings, e error) {
+	file, err := os.Open(path) // #nosec G304 -- explicit parameter
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		if e2 := file.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close file: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	return ParseMappings(file)
+}
+
+// FeatureCollection is a GeoJSON feature collection.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// NewFeatureCollection creates a feature collection holding the given features.
+func NewFeatureCollection(features []Feature) FeatureCollection {
+	if features == nil {
+		features = []Feature{}
+	}
+	return FeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+// Feature is a GeoJSON feature for a single geometry of an entity.
+type Feature struct {
+	Type       string     `json:"type"`
+	Geometry   *Geometry  `json:"geometry"`
+	Properties Properties `json:"properties"`
+}
+
+// Properties are the properties of a feature.
+type Properties struct {
+	Title  string     `json:"title"`
+	URI    impl.Label `json:"uri"`
+	URL    string     `json:"url,omitempty"` // link to the entity in the viewer
+	Bundle string     `json:"bundle"`
+	Field  string     `json:"field"` // machine name of the field(s) holding the geometry
+}
+
+// Features returns one feature for every geometry of the given entities of a bundle.
+//
+// If the bundle has a mapping, the fields given by the mapping are used.
+// Otherwise, every field where at least [MinGeometryRatio] of values are geometries is used, see [Parse].
+// Values that are not geometries are ignored.
+//
+// link returns the url of an entity, it may be nil.
+func (mappings Mappings) Features(bundle *pathbuilder.Bundle, entities []wisski.Entity, link func(uri impl.Label) string) []Feature {
+	machine := bundle.MachineName()
+	fields := bundle.Fields()
+
+	feature := func(entity *wisski.Entity, field string, geometry *Geometry) Feature {
+		properties := Properties{
+			Title:  overview.Title(fields, entity),
+			URI:    entity.URI,
+			Bundle: machine,
+			Field:  field,
+		}
+		if link != nil {
+			properties.URL = link(entity.URI)
+		}
+		return Feature{Type: "Feature", Geometry: geometry, Properties: properties}
+	}
+
+	mapping, ok := mappings[machine]
+	if !ok {
+		mapping = Mapping{Geometry: detect(fields, entities)}
+	}
+
+	var features []Feature
+	for i := range entities {
+		entity := &entities[i]
+		for _, field := range mapping.Geometry {
+			for _, value := range entity.Fields[field] {
+				if geometry, ok := Parse(value.Datum.Value); ok {
+					features = append(features, feature(entity, field, geometry))
+				}
+			}
+		}
+
+		if mapping.Lat == "" {
+			continue
+		}
+		lats, longs := entity.Fields[mapping.Lat], entity.Fields[mapping.Long]
+		for j := range min(len(lats), len(longs)) {
+			if geometry, ok := ParseLatLong(lats[j].Datum.Value, longs[j].Datum.Value); ok {
+				features = append(features, feature(entity, mapping.Lat+","+mapping.Long, geometry))
+			}
+		}
+	}
+	return features
+}
+
+// detect returns the machine names of fields holding geometries.
+func detect(fields []pathbuilder.Field, entities []wisski.Entity) []string {
+	var detected []string
+	for _, field := range fields {
+		var total, geometries int
+		for i := range entities {
+			for _, value := range entities[i].Fields[field.MachineName()] {
+				if strings.TrimSpace(value.Datum.Value) == "" {
+					continue
+				}
+				total++
+
+				if _, ok := Parse(value.Datum.Value); ok {
+					geometries++
+				}
+			}
+		}
+
+		if geometries == 0 || float64(geometries) < MinGeometryRatio*float64(total) {
+			continue
+		}
+		detected = append(detected, field.MachineName())
+	}
+	return detected
+}
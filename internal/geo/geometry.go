@@ -0,0 +1,269 @@
+// Package geo recognizes geometries in field values and turns them into GeoJSON.
+//
+//spellchecker:words geo
+package geo
+
+//spellchecker:words strconv strings
+import (
+	"strconv"
+	"strings"
+)
+
+//spellchecker:words geojson linestring multipoint multilinestring multipolygon opengis EPSG
+
+// Geometry is a GeoJSON geometry.
+// Coordinates are in WGS 84, with longitude before latitude.
+type Geometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// Position is a single GeoJSON position.
+type Position []float64
+
+// Coordinate reference systems that may prefix a WKT literal, see GeoSPARQL.
+const (
+	CRS84     = "http://www.opengis.net/def/crs/OGC/1.3/CRS84"
+	EPSG4326  = "http://www.opengis.net/def/crs/EPSG/0/4326"
+	geoScheme = "geo:"
+)
+
+// Parse parses a WKT literal or a geo uri into a geometry.
+// Returns false if value is neither.
+func Parse(value string) (*Geometry, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) >= len(geoScheme) && strings.EqualFold(value[:len(geoScheme)], geoScheme) {
+		return ParseGeoURI(value)
+	}
+	return ParseWKT(value)
+}
+
+// ParseGeoURI parses a geo uri of the form "geo:lat,long[,alt][;params]" as defined in RFC 5870.
+func ParseGeoURI(value string) (*Geometry, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) < len(geoScheme) || !strings.EqualFold(value[:len(geoScheme)], geoScheme) {
+		return nil, false
+	}
+	coords, params, _ := strings.Cut(value[len(geoScheme):], ";")
+
+	// only the default reference system is supported
+	for _, param := range strings.Split(params, ";") {
+		if key, crs, ok := strings.Cut(param, "="); ok && strings.EqualFold(key, "crs") && !strings.EqualFold(crs, "wgs84") {
+			return nil, false
+		}
+	}
+
+	parts := strings.Split(coords, ",")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, false
+	}
+	numbers := make([]float64, len(parts))
+	for i, part := range parts {
+		number, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, false
+		}
+		numbers[i] = number
+	}
+
+	position := Position{numbers[1], numbers[0]}
+	if len(numbers) == 3 {
+		position = append(position, numbers[2])
+	}
+	if !position.valid() {
+		return nil, false
+	}
+	return &Geometry{Type: "Point", Coordinates: position}, true
+}
+
+// ParseLatLong parses a position from separate latitude and longitude values in decimal degrees.
+func ParseLatLong(lat, long string) (*Geometry, bool) {
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil {
+		return nil, false
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(long), 64)
+	if err != nil {
+		return nil, false
+	}
+
+	position := Position{longitude, latitude}
+	if !position.valid() {
+		return nil, false
+	}
+	return &Geometry{Type: "Point", Coordinates: position}, true
+}
+
+// valid checks that the position is within the bounds of WGS 84.
+func (position Position) valid() bool {
+	return -180 <= position[0] && position[0] <= 180 && -90 <= position[1] && position[1] <= 90
+}
+
+// geometry types and the nesting depth of their coordinates in WKT.
+var wktTypes = map[string]struct {
+	Name  string
+	Depth int
+}{
+	"POINT":           {"Point", 1},
+	"LINESTRING":      {"LineString", 1},
+	"POLYGON":         {"Polygon", 2},
+	"MULTIPOINT":      {"MultiPoint", 1},
+	"MULTILINESTRING": {"MultiLineString", 2},
+	"MULTIPOLYGON":    {"MultiPolygon", 3},
+}
+
+// ParseWKT parses a geometry in well-known text, optionally prefixed by the iri of a coordinate reference system.
+// Only points, line strings, polygons and their multi-variants are supported.
+// Supported reference systems are [CRS84] (the default) and [EPSG4326].
+func ParseWKT(value string) (*Geometry, bool) {
+	value = strings.TrimSpace(value)
+
+	// the reference system may be given as an iri
+	var swap bool
+	if rest, ok := strings.CutPrefix(value, "<"); ok {
+		crs, rest, ok := strings.Cut(rest, ">")
+		if !ok {
+			return nil, false
+		}
+		switch crs {
+		case CRS84:
+		case EPSG4326:
+			// uses latitude before longitude
+			swap = true
+		default:
+			return nil, false
+		}
+		value = strings.TrimSpace(rest)
+	}
+
+	// find the type
+	name, rest, ok := strings.Cut(value, "(")
+	if !ok {
+		return nil, false
+	}
+	name = strings.ToUpper(strings.TrimSpace(name))
+	for _, dimension := range []string{" ZM", " Z", " M"} {
+		name = strings.TrimSuffix(name, dimension)
+	}
+	typ, ok := wktTypes[strings.TrimSpace(name)]
+	if !ok {
+		return nil, false
+	}
+
+	p := wktParser{input: "(" + rest, swap: swap}
+	coordinates, depth, ok := p.list()
+	if !ok || strings.TrimSpace(p.input) != "" {
+		return nil, false
+	}
+
+	// "MULTIPOINT ((1 2), (3 4))" is the same as "MULTIPOINT (1 2, 3 4)"
+	if typ.Name == "MultiPoint" && depth == 2 {
+		points := coordinates.([]any)
+		for i, point := range points {
+			list := point.([]any)
+			if len(list) != 1 {
+				return nil, false
+			}
+			points[i] = list[0]
+		}
+		depth = 1
+	}
+
+	if depth != typ.Depth {
+		return nil, false
+	}
+	if typ.Name == "Point" {
+		list := coordinates.([]any)
+		if len(list) != 1 {
+			return nil, false
+		}
+		coordinates = list[0]
+	}
+	return &Geometry{Type: typ.Name, Coordinates: coordinates}, true
+}
+
+// wktParser parses the coordinates of a WKT geometry.
+type wktParser struct {
+	input string
+	swap  bool // swap the first two ordinates of every position
+}
+
+// list parses a parenthesized, comma-separated list of positions or lists.
+// depth is the nesting depth of the list; a list of positions has depth 1.
+func (p *wktParser) list() (list any, depth int, ok bool) {
+	if !p.consume('(') {
+		return nil, 0, false
+	}
+
+	var items []any
+	for {
+		var item any
+		var itemDepth int
+		if p.peek() == '(' {
+			item, itemDepth, ok = p.list()
+		} else {
+			item, ok = p.position()
+		}
+		if !ok || (len(items) > 0 && itemDepth+1 != depth) {
+			return nil, 0, false
+		}
+		items = append(items, item)
+		depth = itemDepth + 1
+
+		if p.consume(',') {
+			continue
+		}
+		if p.consume(')') {
+			return items, depth, true
+		}
+		return nil, 0, false
+	}
+}
+
+// position parses a single position of space-separated ordinates.
+func (p *wktParser) position() (Position, bool) {
+	end := strings.IndexAny(p.input, ",)")
+	if end < 0 {
+		return nil, false
+	}
+
+	fields := strings.Fields(p.input[:end])
+	if len(fields) < 2 || len(fields) > 4 {
+		return nil, false
+	}
+	position := make(Position, len(fields))
+	for i, field := range fields {
+		number, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, false
+		}
+		position[i] = number
+	}
+	if p.swap {
+		position[0], position[1] = position[1], position[0]
+	}
+	if !position.valid() {
+		return nil, false
+	}
+
+	p.input = p.input[end:]
+	return position, true
+}
+
+// peek returns the next non-space byte of the input, or 0 if there is none.
+func (p *wktParser) peek() byte {
+	p.input = strings.TrimLeft(p.input, " \t\r\n")
+	if p.input == "" {
+		return 0
+	}
+	return p.input[0]
+}
+
+// consume consumes c if it is the next non-space byte of the input.
+func (p *wktParser) consume(c byte) bool {
+	if p.peek() != c {
+		return false
+	}
+	p.input = p.input[1:]
+	return true
+}
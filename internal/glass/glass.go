@@ -3,7 +3,7 @@
 //spellchecker:words glass
 package glass
 
-//spellchecker:words errors runtime debug github drincw pathbuilder pbxml hangover internal sparkl storages stats triplestore imap impl viewer wisski
+//spellchecker:words errors runtime debug github drincw pathbuilder pbxml hangover internal overview problems sparkl storages stats triplestore imap impl viewer wisski
 import (
 	"errors"
 	"fmt"
@@ -11,6 +11,8 @@ import (
 
 	"github.com/FAU-CDI/drincw/pathbuilder"
 	"github.com/FAU-CDI/drincw/pathbuilder/pbxml"
+	"github.com/FAU-CDI/hangover/internal/overview"
+	"github.com/FAU-CDI/hangover/internal/problems"
 	"github.com/FAU-CDI/hangover/internal/sparkl"
 	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
 	"github.com/FAU-CDI/hangover/internal/stats"
@@ -89,6 +91,19 @@ func Create(pathbuilderPath string, nquadsPath string, cacheDir string, flags vi
 		return drincw, fmt.Errorf("failed to extract bundles: %w", err)
 	}
 
+	// find problems in the data
+	if err := st.DoStage(stats.StageFindProblems, func() error {
+		report, err := problems.Find(index.Triples, bundles, problems.DefaultLimit)
+		if err != nil {
+			return fmt.Errorf("failed to find problems: %w", err)
+		}
+		st.StoreProblems(report)
+		st.Log("found problems", "dangling", report.DanglingCount, "orphaned", report.OrphanedCount, "duplicates", report.DuplicateCount)
+		return nil
+	}); err != nil {
+		return drincw, fmt.Errorf("failed to find problems: %w", err)
+	}
+
 	// extract the cache
 
 	identities := imap.MakeMemory[impl.Label, impl.Label](0)
@@ -110,6 +125,19 @@ func Create(pathbuilderPath string, nquadsPath string, cacheDir string, flags vi
 		return Glass{}, fmt.Errorf("failed to extract cache: %w", err)
 	}
 
+	// summarize the data
+	if err := st.DoStage(stats.StageOverview, func() error {
+		summary, err := overview.New(&drincw.Pathbuilder, drincw.Cache.Entities, st.IndexStats(), index.Triples, overview.DefaultTop)
+		if err != nil {
+			return fmt.Errorf("failed to compute overview: %w", err)
+		}
+		st.StoreOverview(summary)
+		st.Log("computed overview", "entities", summary.Entities, "triples", summary.Triples)
+		return nil
+	}); err != nil {
+		return Glass{}, fmt.Errorf("failed to compute overview: %w", err)
+	}
+
 	// We close the index early, because it's no longer needed
 	if err := index.Close(); err != nil {
 		return drincw, fmt.Errorf("failed to close index: %w", err)
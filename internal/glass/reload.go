@@ -0,0 +1,246 @@
+//spellchecker:words glass
+package glass
+
+//spellchecker:words context errors sync atomic time github hangover internal stats viewer
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FAU-CDI/hangover"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/viewer"
+)
+
+//spellchecker:words nquads pathbuilder
+
+// Reloader rebuilds a Glass from its source files in the background, and swaps it into a viewer.
+// While a rebuild is in progress, the viewer continues to serve the previous data.
+// If a rebuild fails, the previous data remains live.
+//
+// Reloader implements [viewer.Reloader].
+type Reloader struct {
+	Viewer *viewer.Viewer // viewer to replace data in
+
+	Args []string // arguments used to find the source files, see [hangover.FindSource]
+
+	// CacheDir is the directory to cache data in during a rebuild, see [Create].
+	// Each rebuild uses a new "generation-*" subdirectory, so that the data being served is never overwritten.
+	// The directory of the previous rebuild is removed once its data is no longer served.
+	CacheDir string
+
+	Flags viewer.RenderFlags // flags to use for a rebuild, see [Create]
+
+	Output io.Writer // Output receives log messages of rebuilds, and of the viewer once it serves rebuilt data; may be nil
+	Debug  bool      // enable debug logging for rebuilds
+
+	running    atomic.Bool
+	generation string // cache directory of the data currently served, if created by a rebuild; protected by running
+
+	m      sync.Mutex // protects status
+	status viewer.ReloadStatus
+}
+
+var _ viewer.Reloader = (*Reloader)(nil)
+
+// Status returns the status of the current or most recent reload.
+func (reloader *Reloader) Status() viewer.ReloadStatus {
+	reloader.m.Lock()
+	defer reloader.m.Unlock()
+
+	return reloader.status
+}
+
+// Start starts reloading in the background.
+// If a reload is already in progress, returns [viewer.ErrReloading].
+func (reloader *Reloader) Start() error {
+	if !reloader.running.CompareAndSwap(false, true) {
+		return viewer.ErrReloading
+	}
+
+	go func() {
+		defer reloader.running.Store(false)
+		_ = reloader.reload() // error is recorded in the status
+	}()
+	return nil
+}
+
+// Reload reloads the data and waits for it to complete.
+// If a reload is already in progress, returns [viewer.ErrReloading].
+func (reloader *Reloader) Reload() error {
+	if !reloader.running.CompareAndSwap(false, true) {
+		return viewer.ErrReloading
+	}
+	defer reloader.running.Store(false)
+
+	return reloader.reload()
+}
+
+// reload implements reloading.
+// The caller must have set running to true.
+func (reloader *Reloader) reload() (err error) {
+	func() {
+		reloader.m.Lock()
+		defer reloader.m.Unlock()
+
+		reloader.status.Running = true
+		reloader.status.LastStart = time.Now()
+	}()
+
+	st := stats.NewStats(reloader.Output, reloader.Debug)
+	defer func() {
+		reloader.m.Lock()
+		defer reloader.m.Unlock()
+
+		reloader.status.Running = false
+		reloader.status.LastEnd = time.Now()
+		reloader.status.LastError = ""
+
+		if err != nil {
+			reloader.status.LastError = err.Error()
+			st.LogError("reload", err)
+			return
+		}
+
+		reloader.status.Generation++
+		st.Close()
+		st.Log("reload finished", "generation", reloader.status.Generation, "took", st.Diff())
+	}()
+
+	nq, pb, err := hangover.FindSource(reloader.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to find source: %w", err)
+	}
+
+	// never build into the directory of the data being served
+	var cacheDir string
+	if reloader.CacheDir != "" {
+		if err := os.MkdirAll(reloader.CacheDir, 0o750); err != nil {
+			return fmt.Errorf("failed to create cache directory: %w", err)
+		}
+		cacheDir, err = os.MkdirTemp(reloader.CacheDir, "generation-")
+		if err != nil {
+			return fmt.Errorf("failed to create cache directory: %w", err)
+		}
+	}
+
+	st.Log("reloading files", "pathbuilder", pb, "nquads", nq)
+	drincw, err := Create(pb, nq, cacheDir, reloader.Flags, st)
+	if err != nil {
+		reloader.removeGeneration(cacheDir, st)
+		return fmt.Errorf("failed to create glass: %w", err)
+	}
+
+	previous := reloader.generation
+	reloader.generation = cacheDir
+
+	if err := reloader.Viewer.Replace(drincw.Cache, &drincw.Pathbuilder, st); err != nil {
+		// the new data is live at this point, only cleaning up the old data failed.
+		// the old data may still be open, so keep its directory around.
+		st.LogError("replace data", err)
+	} else {
+		reloader.removeGeneration(previous, st)
+	}
+	reloader.Viewer.LogMissingMedia()
+	return nil
+}
+
+// removeGeneration removes the given cache directory of a rebuild, if any.
+// Failures are only logged.
+func (reloader *Reloader) removeGeneration(dir string, st *stats.Stats) {
+	if dir == "" {
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		st.LogError("remove previous cache directory", err)
+	}
+}
+
+// sourceState describes the state of the source files on disk.
+type sourceState struct {
+	NQuads, Pathbuilder               string
+	NQuadsModTime, PathbuilderModTime time.Time
+	NQuadsSize, PathbuilderSize       int64
+}
+
+// sourceState returns the current state of the source files.
+func (reloader *Reloader) sourceState() (state sourceState, err error) {
+	state.NQuads, state.Pathbuilder, err = hangover.FindSource(reloader.Args...)
+	if err != nil {
+		return state, fmt.Errorf("failed to find source: %w", err)
+	}
+
+	nq, err := os.Stat(state.NQuads)
+	if err != nil {
+		return state, fmt.Errorf("failed to stat nquads: %w", err)
+	}
+	state.NQuadsModTime, state.NQuadsSize = nq.ModTime(), nq.Size()
+
+	pb, err := os.Stat(state.Pathbuilder)
+	if err != nil {
+		return state, fmt.Errorf("failed to stat pathbuilder: %w", err)
+	}
+	state.PathbuilderModTime, state.PathbuilderSize = pb.ModTime(), pb.Size()
+
+	return state, nil
+}
+
+// Watch polls the source files every interval, and reloads once they have changed.
+// Changes are only acted upon once the files have been unchanged for a full interval,
+// so that partially uploaded files are not picked up.
+//
+// Files that have failed to load are not reloaded again until they change.
+// Watch blocks until ctx is cancelled.
+func (reloader *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	st := stats.NewStats(reloader.Output, reloader.Debug)
+
+	loaded, err := reloader.sourceState()
+	if err != nil {
+		st.LogError("watch source files", err)
+	}
+	st.Log("watching source files", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var pending sourceState
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := reloader.sourceState()
+		if err != nil {
+			st.LogDebug("watch source files", "err", err)
+			continue
+		}
+
+		// nothing changed since the last load
+		if current == loaded {
+			pending = sourceState{}
+			continue
+		}
+
+		// files are still changing, wait for them to settle
+		if current != pending {
+			st.Log("source files changed", "pathbuilder", current.Pathbuilder, "nquads", current.NQuads)
+			pending = current
+			continue
+		}
+
+		// a manual reload is in progress, try again later.
+		// any other error has already been logged by the reload itself.
+		if err := reloader.Reload(); errors.Is(err, viewer.ErrReloading) {
+			continue
+		}
+		loaded = current
+		pending = sourceState{}
+	}
+}
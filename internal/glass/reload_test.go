@@ -0,0 +1,249 @@
+//spellchecker:words glass
+package glass_test
+
+//spellchecker:words context encoding json http httptest path filepath testing time github hangover internal glass problems viewer
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FAU-CDI/hangover/internal/glass"
+	"github.com/FAU-CDI/hangover/internal/problems"
+	"github.com/FAU-CDI/hangover/internal/viewer"
+)
+
+//spellchecker:words nquads pathbuilder
+
+const reloadPathbuilder = `<pathbuilderinterface>
+<path><id>person</id><weight>0</weight><enabled>1</enabled><group_id>0</group_id><bundle>person</bundle><field>person</field><cardinality>-1</cardinality><path_array><x>http://example.com/Person</x></path_array><datatype_property>empty</datatype_property><is_group>1</is_group><name>Person</name></path>
+<path><id>name</id><weight>0</weight><enabled>1</enabled><group_id>person</group_id><bundle>person</bundle><field>name</field><cardinality>1</cardinality><path_array><x>http://example.com/Person</x></path_array><datatype_property>http://example.com/name</datatype_property><is_group>0</is_group><name>Name</name></path>
+</pathbuilderinterface>
+`
+
+// person returns nquads for a person with the given name.
+func person(name string) string {
+	uri := "<http://example.com/" + name + ">"
+	return uri + " <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://example.com/Person> <http://example.com/graph> .\n" +
+		uri + " <http://example.com/name> \"" + name + "\" <http://example.com/graph> .\n"
+}
+
+// writeSource writes a pathbuilder and the given nquads into dir, and returns the arguments to load them.
+func writeSource(t *testing.T, dir string, nquads string) []string {
+	t.Helper()
+
+	pb := filepath.Join(dir, "pathbuilder.xml")
+	nq := filepath.Join(dir, "data.nq")
+	if err := os.WriteFile(pb, []byte(reloadPathbuilder), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(nq, []byte(nquads), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return []string{pb, nq}
+}
+
+// countPersons returns the number of persons currently served by handler.
+func countPersons(handler *viewer.Viewer) int {
+	return len(handler.Cache.Entities("person"))
+}
+
+func TestReloader_Reload(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name  string
+		cache bool
+	}{
+		{"memory", false},
+		{"disk", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var cacheDir string
+			if tt.cache {
+				cacheDir = t.TempDir()
+			}
+
+			handler := new(viewer.Viewer)
+			defer func() {
+				if err := handler.Close(); err != nil {
+					t.Error(err)
+				}
+			}()
+
+			source := t.TempDir()
+			reloader := &glass.Reloader{
+				Viewer:   handler,
+				Args:     writeSource(t, source, person("alice")),
+				CacheDir: cacheDir,
+			}
+
+			if err := reloader.Reload(); err != nil {
+				t.Fatal(err)
+			}
+			if got := countPersons(handler); got != 1 {
+				t.Errorf("got %d person(s) after first reload, want 1", got)
+			}
+
+			writeSource(t, source, person("alice")+person("bob"))
+			if err := reloader.Reload(); err != nil {
+				t.Fatal(err)
+			}
+			if got := countPersons(handler); got != 2 {
+				t.Errorf("got %d person(s) after second reload, want 2", got)
+			}
+
+			status := reloader.Status()
+			if status.Generation != 2 || status.Running || status.LastError != "" {
+				t.Errorf("got status %#v, want two successful reloads", status)
+			}
+
+			// a failed reload keeps the previous data
+			if err := os.WriteFile(reloader.Args[0], []byte("not a pathbuilder"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := reloader.Reload(); err == nil {
+				t.Error("reload of broken pathbuilder did not fail")
+			}
+			if got := countPersons(handler); got != 2 {
+				t.Errorf("got %d person(s) after failed reload, want 2", got)
+			}
+			if status := reloader.Status(); status.Generation != 2 || status.LastError == "" {
+				t.Errorf("got status %#v, want failed reload", status)
+			}
+
+			// only the directory of the data being served remains
+			if tt.cache {
+				generations, err := filepath.Glob(filepath.Join(cacheDir, "generation-*"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(generations) != 1 {
+					t.Errorf("got cache directories %v, want exactly one", generations)
+				}
+			}
+		})
+	}
+}
+
+// getProblems returns the problems currently served by handler.
+func getProblems(t *testing.T, handler *viewer.Viewer) problems.Report {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/problems", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d for problems, want %d", rec.Code, http.StatusOK)
+	}
+
+	var report problems.Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func TestReloader_Stats(t *testing.T) {
+	t.Parallel()
+
+	handler := new(viewer.Viewer)
+	defer func() {
+		if err := handler.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	source := t.TempDir()
+	reloader := &glass.Reloader{
+		Viewer: handler,
+		Args:   writeSource(t, source, person("alice")),
+	}
+
+	if err := reloader.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if report := getProblems(t, handler); report.OrphanedCount != 0 {
+		t.Errorf("got %d orphaned node(s) after first reload, want 0", report.OrphanedCount)
+	}
+	if perf := handler.Perf(); perf.Index.DatumTriples != 1 || len(perf.Stages) == 0 {
+		t.Errorf("got perf %#v after first reload, want one datum triple", perf)
+	}
+
+	// paris has a type, but is not in any bundle
+	writeSource(t, source, person("alice")+person("bob")+"<http://example.com/paris> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://example.com/Place> <http://example.com/graph> .\n")
+	if err := reloader.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if report := getProblems(t, handler); report.OrphanedCount != 1 {
+		t.Errorf("got %d orphaned node(s) after second reload, want 1", report.OrphanedCount)
+	}
+	if perf := handler.Perf(); perf.Index.DatumTriples != 2 || len(perf.Stages) == 0 {
+		t.Errorf("got perf %#v after second reload, want two datum triples", perf)
+	}
+}
+
+func TestReloader_Watch(t *testing.T) {
+	t.Parallel()
+
+	handler := new(viewer.Viewer)
+	defer func() {
+		if err := handler.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	source := t.TempDir()
+	reloader := &glass.Reloader{
+		Viewer: handler,
+		Args:   writeSource(t, source, person("alice")),
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		reloader.Watch(ctx, 10*time.Millisecond)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// waitGeneration waits for the reloader to reach the given generation
+	waitGeneration := func(want int) {
+		t.Helper()
+
+		deadline := time.Now().Add(10 * time.Second)
+		for reloader.Status().Generation < want {
+			if time.Now().After(deadline) {
+				t.Fatalf("reloader did not reach generation %d", want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// unchanged files are not reloaded
+	time.Sleep(100 * time.Millisecond)
+	if got := reloader.Status().Generation; got != 0 {
+		t.Fatalf("got generation %d for unchanged files, want 0", got)
+	}
+
+	// changed files are
+	writeSource(t, source, person("alice")+person("bob"))
+	waitGeneration(1)
+	if got := countPersons(handler); got != 2 {
+		t.Errorf("got %d person(s) after change, want 2", got)
+	}
+
+	// but only once
+	time.Sleep(100 * time.Millisecond)
+	if got := reloader.Status().Generation; got != 1 {
+		t.Errorf("got generation %d after a single change, want 1", got)
+	}
+}
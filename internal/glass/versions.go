@@ -0,0 +1,77 @@
+//spellchecker:words glass
+package glass
+
+//spellchecker:words errors path filepath slices github hangover internal stats viewer
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/FAU-CDI/hangover"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/viewer"
+)
+
+//spellchecker:words nquads pathbuilder
+
+// LoadVersions loads older versions of a dataset from dir.
+//
+// Each sub-directory of dir holds one version, and must contain exactly one pathbuilder and nquads file, see [hangover.FindSource].
+// Versions are named after their directory, and ordered by name.
+// Naming directories by export date, e.g. "2023-01-31", thus orders them from oldest to newest.
+//
+// When cacheDir is not empty, each version caches data in a sub-directory of it, see [Create].
+func LoadVersions(dir string, cacheDir string, flags viewer.RenderFlags, st *stats.Stats) (versions []viewer.Version, e error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read versions directory: %w", err)
+	}
+
+	// close all loaded versions on error
+	defer func() {
+		if e == nil {
+			return
+		}
+		for _, version := range versions {
+			if e2 := version.Cache.Close(); e2 != nil {
+				e = errors.Join(e, fmt.Errorf("failed to close version %q: %w", version.Name, e2))
+			}
+		}
+		versions = nil
+	}()
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		nq, pb, err := hangover.FindSource(filepath.Join(dir, name))
+		if err != nil {
+			return versions, fmt.Errorf("failed to find source of version %q: %w", name, err)
+		}
+
+		var versionCache string
+		if cacheDir != "" {
+			versionCache = filepath.Join(cacheDir, "versions", name)
+		}
+
+		st.Log("loading version", "name", name, "pathbuilder", pb, "nquads", nq)
+		drincw, err := Create(pb, nq, versionCache, flags, st)
+		if err != nil {
+			return versions, fmt.Errorf("failed to load version %q: %w", name, err)
+		}
+
+		versions = append(versions, viewer.Version{
+			Name:        name,
+			Cache:       drincw.Cache,
+			Pathbuilder: &drincw.Pathbuilder,
+		})
+	}
+	return versions, nil
+}
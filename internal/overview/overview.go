@@ -0,0 +1,273 @@
+// Package overview computes summary statistics and an alphabetical index of a dataset.
+//
+//spellchecker:words overview
+package overview
+
+//spellchecker:words cmp iter maps slices strings unicode github drincw pathbuilder hangover internal triplestore igraph impl wisski
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+// DefaultTop is the default number of classes and predicates listed in an overview.
+const DefaultTop = 20
+
+// Other is the letter entries whose title does not start with a letter are listed under.
+const Other = "#"
+
+// Overview summarizes a dataset.
+type Overview struct {
+	Entities int      // total number of entities in top-level bundles
+	Bundles  []Bundle // top-level bundles, in pathbuilder order
+
+	Index   igraph.Stats // statistics of the index the entities were extracted from
+	Triples int          // number of non-inferred triples in the index
+
+	Languages  []Count // number of literals per language tag, most frequent first
+	Classes    []Count // most frequently used classes, most frequent first
+	Predicates []Count // most frequently used predicates, most frequent first
+
+	Letters []Letter // letters of the A–Z index, in order
+	Entries []Entry  // entries of the A–Z index, ordered by title
+
+	Restricted bool // global statistics were removed by [Overview.Filter]
+}
+
+// Bundle summarizes a top-level bundle.
+type Bundle struct {
+	MachineName string
+	Name        string
+	Entities    int
+	Fields      []Field
+}
+
+// Field summarizes a field of a top-level bundle.
+type Field struct {
+	MachineName string
+	Name        string
+	Present     int     // number of entities with at least one value
+	FillRate    float64 // fraction of entities with at least one value
+}
+
+// Percent returns the fill rate in percent.
+func (field Field) Percent() float64 {
+	return 100 * field.FillRate
+}
+
+// Count is the number of occurrences of a label.
+type Count struct {
+	Label impl.Label
+	Count int
+}
+
+// Letter is a letter of the A–Z index.
+type Letter struct {
+	Letter string
+	Count  int // number of entries starting with this letter
+}
+
+// Entry is an entry of the A–Z index.
+type Entry struct {
+	Title  string
+	URI    impl.Label
+	Bundle string // machine name of the top-level bundle
+}
+
+// New computes an overview of the entities of the given pathbuilder.
+//
+// entities returns the entities of the top-level bundle with the given machine name.
+// stats are the statistics of the index the entities were extracted from.
+// triples returns the triples of that index; if it is nil, no triple statistics are computed.
+// At most top classes and predicates are listed; top <= 0 lists all of them.
+//
+// The title of an entity is the first value of its fields, in pathbuilder order.
+// Entities without any values use their uri as a title.
+func New(pb *pathbuilder.Pathbuilder, entities func(bundle string) []wisski.Entity, stats igraph.Stats, triples func() iter.Seq2[igraph.Triple, error], top int) (*Overview, error) {
+	overview := &Overview{Index: stats}
+
+	for _, bundle := range pb.Bundles() {
+		es := entities(bundle.MachineName())
+		overview.Entities += len(es)
+
+		summary := Bundle{
+			MachineName: bundle.MachineName(),
+			Name:        bundle.Name,
+			Entities:    len(es),
+		}
+		fields := bundle.Fields()
+		for _, field := range fields {
+			f := Field{MachineName: field.MachineName(), Name: field.Name}
+			for _, entity := range es {
+				if len(entity.Fields[f.MachineName]) > 0 {
+					f.Present++
+				}
+			}
+			if len(es) > 0 {
+				f.FillRate = float64(f.Present) / float64(len(es))
+			}
+			summary.Fields = append(summary.Fields, f)
+		}
+		overview.Bundles = append(overview.Bundles, summary)
+
+		for i := range es {
+			overview.Entries = append(overview.Entries, Entry{
+				Title:  Title(fields, &es[i]),
+				URI:    es[i].URI,
+				Bundle: summary.MachineName,
+			})
+		}
+	}
+
+	slices.SortFunc(overview.Entries, func(a, b Entry) int {
+		return cmp.Or(
+			cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)),
+			cmp.Compare(a.Title, b.Title),
+			cmp.Compare(a.URI, b.URI),
+			cmp.Compare(a.Bundle, b.Bundle),
+		)
+	})
+	overview.Letters = letters(overview.Entries)
+
+	if triples == nil {
+		return overview, nil
+	}
+
+	languages := make(map[impl.Label]int)
+	classes := make(map[impl.Label]int)
+	predicates := make(map[impl.Label]int)
+	for triple, err := range triples() {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read triple: %w", err)
+		}
+
+		switch triple.Role {
+		case igraph.Regular:
+			if triple.SPredicate == wisski.Type {
+				classes[triple.SObject]++
+			}
+		case igraph.Data:
+			languages[impl.Label(triple.Datum.Language)]++
+		default:
+			continue
+		}
+
+		overview.Triples++
+		predicates[triple.SPredicate]++
+	}
+
+	overview.Languages = counts(languages, 0)
+	overview.Classes = counts(classes, top)
+	overview.Predicates = counts(predicates, top)
+
+	return overview, nil
+}
+
+// Title returns the title of an entity with the given fields.
+// This is the first value of its fields, or its uri if it has no values.
+func Title(fields []pathbuilder.Field, entity *wisski.Entity) string {
+	for _, field := range fields {
+		for _, value := range entity.Fields[field.MachineName()] {
+			if title := strings.TrimSpace(value.Datum.Value); title != "" {
+				return title
+			}
+		}
+	}
+	return string(entity.URI)
+}
+
+// LetterOf returns the letter of the A–Z index the given title is listed under.
+func LetterOf(title string) string {
+	r, _ := utf8.DecodeRuneInString(title)
+	if !unicode.IsLetter(r) {
+		return Other
+	}
+	return string(unicode.ToUpper(r))
+}
+
+// letters groups sorted entries by their letter.
+func letters(entries []Entry) []Letter {
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[LetterOf(entry.Title)]++
+	}
+
+	letters := make([]Letter, 0, len(counts))
+	for _, letter := range slices.Sorted(maps.Keys(counts)) {
+		letters = append(letters, Letter{Letter: letter, Count: counts[letter]})
+	}
+	return letters
+}
+
+// counts turns a map of counts into a slice, ordered by descending count.
+func counts(m map[impl.Label]int, top int) []Count {
+	result := make([]Count, 0, len(m))
+	for label, count := range m {
+		result = append(result, Count{Label: label, Count: count})
+	}
+	slices.SortFunc(result, func(a, b Count) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Label, b.Label))
+	})
+
+	if top > 0 && len(result) > top {
+		result = result[:top:top]
+	}
+	return result
+}
+
+// Filter returns a copy of overview that only includes the top-level bundles for which visible returns true.
+//
+// Statistics of the index and the counts of languages, classes and predicates are computed over all data.
+// They can not be filtered by bundle, and are removed instead.
+func (overview *Overview) Filter(visible func(bundle string) bool) *Overview {
+	filtered := *overview
+	filtered.Entities = 0
+	filtered.Bundles = nil
+	filtered.Entries = nil
+
+	filtered.Index = igraph.Stats{}
+	filtered.Triples = 0
+	filtered.Languages = nil
+	filtered.Classes = nil
+	filtered.Predicates = nil
+	filtered.Restricted = true
+
+	for _, bundle := range overview.Bundles {
+		if !visible(bundle.MachineName) {
+			continue
+		}
+		filtered.Entities += bundle.Entities
+		filtered.Bundles = append(filtered.Bundles, bundle)
+	}
+	for _, entry := range overview.Entries {
+		if visible(entry.Bundle) {
+			filtered.Entries = append(filtered.Entries, entry)
+		}
+	}
+	filtered.Letters = letters(filtered.Entries)
+
+	return &filtered
+}
+
+// Letter returns the entries of the A–Z index listed under the given letter.
+func (overview *Overview) Letter(letter string) []Entry {
+	entries := []Entry{}
+	for _, entry := range overview.Entries {
+		if LetterOf(entry.Title) == letter {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,137 @@
+//spellchecker:words overview
+package overview_test
+
+//spellchecker:words reflect testing github drincw pathbuilder hangover internal overview triplestore igraph impl wisski
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/overview"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	var index igraph.Index
+	if err := index.Reset(&igraph.MemoryEngine{}); err != nil {
+		t.Fatalf("Reset() returned error %v", err)
+	}
+	defer func() {
+		if err := index.Close(); err != nil {
+			t.Errorf("Close() returned error %v", err)
+		}
+	}()
+
+	triples := [][3]impl.Label{
+		{"alice", wisski.Type, "Person"},
+		{"bob", wisski.Type, "Person"},
+		{"berlin", wisski.Type, "Place"},
+		{"alice", "knows", "bob"},
+	}
+	for _, triple := range triples {
+		if err := index.AddTriple(triple[0], triple[1], triple[2], impl.Source{}); err != nil {
+			t.Fatalf("AddTriple() returned error %v", err)
+		}
+	}
+	data := []struct {
+		subject impl.Label
+		datum   impl.Datum
+	}{
+		{"alice", impl.Datum{Value: "Alice", Language: "en"}},
+		{"bob", impl.Datum{Value: "bob"}},
+		{"berlin", impl.Datum{Value: "Berlin", Language: "de"}},
+	}
+	for _, d := range data {
+		if err := index.AddData(d.subject, "name", d.datum, impl.Source{}); err != nil {
+			t.Fatalf("AddData() returned error %v", err)
+		}
+	}
+	if err := index.Finalize(); err != nil {
+		t.Fatalf("Finalize() returned error %v", err)
+	}
+
+	pb := pathbuilder.NewPathbuilder()
+	person := pb.GetOrCreate("person")
+	person.Path = pathbuilder.Path{ID: "person", Name: "Person", IsGroup: true, Weight: 0}
+	person.ChildFields = []pathbuilder.Field{
+		{Path: pathbuilder.Path{ID: "name", Name: "Name"}},
+		{Path: pathbuilder.Path{ID: "born", Name: "Born"}},
+	}
+	place := pb.GetOrCreate("place")
+	place.Path = pathbuilder.Path{ID: "place", Name: "Place", IsGroup: true, Weight: 1}
+	place.ChildFields = []pathbuilder.Field{
+		{Path: pathbuilder.Path{ID: "label", Name: "Label"}},
+	}
+
+	value := func(v string) []wisski.FieldValue { return []wisski.FieldValue{{Datum: impl.Datum{Value: v}}} }
+	entities := map[string][]wisski.Entity{
+		"person": {
+			{URI: "alice", Fields: map[string][]wisski.FieldValue{"name": value("Alice"), "born": value("1900")}},
+			{URI: "bob", Fields: map[string][]wisski.FieldValue{"name": value("bob")}},
+			{URI: "42"},
+		},
+		"place": {
+			{URI: "berlin", Fields: map[string][]wisski.FieldValue{"label": value("Berlin")}},
+		},
+	}
+	lookup := func(bundle string) []wisski.Entity { return entities[bundle] }
+
+	got, err := overview.New(&pb, lookup, igraph.Stats{DirectTriples: 4, DatumTriples: 3}, index.Triples, 2)
+	if err != nil {
+		t.Fatalf("New() returned error %v", err)
+	}
+
+	want := &overview.Overview{
+		Entities: 4,
+		Bundles: []overview.Bundle{
+			{
+				MachineName: "person", Name: "Person", Entities: 3,
+				Fields: []overview.Field{
+					{MachineName: "name", Name: "Name", Present: 2, FillRate: 2.0 / 3},
+					{MachineName: "born", Name: "Born", Present: 1, FillRate: 1.0 / 3},
+				},
+			},
+			{
+				MachineName: "place", Name: "Place", Entities: 1,
+				Fields: []overview.Field{
+					{MachineName: "label", Name: "Label", Present: 1, FillRate: 1},
+				},
+			},
+		},
+
+		Index:   igraph.Stats{DirectTriples: 4, DatumTriples: 3},
+		Triples: 7,
+
+		Languages:  []overview.Count{{Label: "", Count: 1}, {Label: "de", Count: 1}, {Label: "en", Count: 1}},
+		Classes:    []overview.Count{{Label: "Person", Count: 2}, {Label: "Place", Count: 1}},
+		Predicates: []overview.Count{{Label: wisski.Type, Count: 3}, {Label: "name", Count: 3}},
+
+		Letters: []overview.Letter{{Letter: "#", Count: 1}, {Letter: "A", Count: 1}, {Letter: "B", Count: 2}},
+		Entries: []overview.Entry{
+			{Title: "42", URI: "42", Bundle: "person"},
+			{Title: "Alice", URI: "alice", Bundle: "person"},
+			{Title: "Berlin", URI: "berlin", Bundle: "place"},
+			{Title: "bob", URI: "bob", Bundle: "person"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("New() = %#v, want %#v", got, want)
+	}
+
+	filtered := got.Filter(func(bundle string) bool { return bundle == "place" })
+	if filtered.Entities != 1 || len(filtered.Bundles) != 1 || len(filtered.Entries) != 1 {
+		t.Errorf("Filter() = %#v", filtered)
+	}
+	if !filtered.Restricted || filtered.Triples != 0 || filtered.Index != (igraph.Stats{}) || filtered.Languages != nil || filtered.Classes != nil || filtered.Predicates != nil {
+		t.Errorf("Filter() kept global statistics: %#v", filtered)
+	}
+	if entries := got.Letter("B"); len(entries) != 2 || entries[0].URI != "berlin" {
+		t.Errorf("Letter() = %#v", entries)
+	}
+}
@@ -0,0 +1,59 @@
+//spellchecker:words problems
+package problems
+
+//spellchecker:words container heap slices
+import (
+	"container/heap"
+	"slices"
+)
+
+// bounded keeps the smallest values added to it, up to a limit.
+// Memory use only depends on the limit, not on the number of values added.
+type bounded[T any] struct {
+	limit   int // maximal number of values to keep; <= 0 keeps all values
+	compare func(a, b T) int
+
+	values []T // a max-heap of the kept values, unless all values are kept
+}
+
+// add adds value, and returns the value that is no longer kept because of it, if any.
+// The returned value may be value itself.
+func (b *bounded[T]) add(value T) (evicted T, ok bool) {
+	if b.limit <= 0 {
+		b.values = append(b.values, value)
+		return evicted, false
+	}
+	if len(b.values) < b.limit {
+		heap.Push((*boundedHeap[T])(b), value)
+		return evicted, false
+	}
+
+	// replace the largest kept value, if value is smaller
+	if b.compare(value, b.values[0]) >= 0 {
+		return value, true
+	}
+	evicted = b.values[0]
+	b.values[0] = value
+	heap.Fix((*boundedHeap[T])(b), 0)
+	return evicted, true
+}
+
+// sorted returns the kept values in ascending order.
+func (b *bounded[T]) sorted() []T {
+	values := slices.Clone(b.values)
+	slices.SortFunc(values, b.compare)
+	return values
+}
+
+// boundedHeap implements [heap.Interface] for the values of a bounded.
+type boundedHeap[T any] bounded[T]
+
+func (h *boundedHeap[T]) Len() int           { return len(h.values) }
+func (h *boundedHeap[T]) Less(i, j int) bool { return h.compare(h.values[i], h.values[j]) > 0 }
+func (h *boundedHeap[T]) Swap(i, j int)      { h.values[i], h.values[j] = h.values[j], h.values[i] }
+func (h *boundedHeap[T]) Push(x any)         { h.values = append(h.values, x.(T)) }
+func (h *boundedHeap[T]) Pop() any {
+	last := h.values[len(h.values)-1]
+	h.values = h.values[:len(h.values)-1]
+	return last
+}
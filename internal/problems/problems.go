@@ -0,0 +1,175 @@
+// Package problems detects structural problems in the data a pathbuilder is applied to.
+//
+//spellchecker:words problems
+package problems
+
+//spellchecker:words cmp iter slices github hangover internal triplestore igraph impl wisski
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"slices"
+
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+// DefaultLimit is the default maximal number of problems listed per kind.
+const DefaultLimit = 1000
+
+// Report lists problems found in a dataset.
+//
+// Each kind of problem is counted in full, but only the first problems (in order of their uris) are listed.
+type Report struct {
+	Dangling      []Reference // object properties pointing to uris that have no type
+	DanglingCount int         // number of dangling triples; triples that are equal after normalization are counted separately
+
+	Orphaned      []Node // typed nodes that are not an entity of any bundle
+	OrphanedCount int
+
+	Duplicates     []Duplicate // entities that occur in more than one top-level bundle
+	DuplicateCount int
+}
+
+// Empty checks if the report does not contain any problems.
+func (report *Report) Empty() bool {
+	return report.DanglingCount == 0 && report.OrphanedCount == 0 && report.DuplicateCount == 0
+}
+
+// Reference is a triple whose object has no type.
+type Reference struct {
+	Subject   impl.Label
+	Predicate impl.Label
+	Object    impl.Label
+}
+
+// Node is a typed node.
+type Node struct {
+	URI   impl.Label
+	Types []impl.Label
+}
+
+// Duplicate is an entity that occurs in several bundles.
+type Duplicate struct {
+	URI     impl.Label
+	Bundles []string // machine names of the top-level bundles
+}
+
+// Find searches the given triples for problems.
+//
+// triples returns the triples of the index; it is iterated over twice.
+// bundles holds the entities extracted from the triples, indexed by top-level bundle.
+// At most limit problems of each kind are listed; limit <= 0 lists all problems.
+// Apart from the typed nodes and entities, memory use only depends on limit, not on the number of problems found.
+//
+// Only the canonical form of each triple is considered, inferred triples are ignored.
+func Find(triples func() iter.Seq2[igraph.Triple, error], bundles map[string][]wisski.Entity, limit int) (*Report, error) {
+	// find all the typed nodes
+	types := make(map[impl.Label][]impl.Label)
+	for triple, err := range triples() {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read triple: %w", err)
+		}
+		if triple.Role != igraph.Regular || triple.SPredicate != wisski.Type {
+			continue
+		}
+		types[triple.SSubject] = append(types[triple.SSubject], triple.SObject)
+	}
+
+	var report Report
+
+	// find references to untyped nodes
+	dangling := bounded[Reference]{limit: limit, compare: compareReferences}
+	listed := make(map[Reference]struct{}) // the references kept by dangling
+	for triple, err := range triples() {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read triple: %w", err)
+		}
+		if triple.Role != igraph.Regular || triple.SPredicate == wisski.Type {
+			continue
+		}
+		if _, ok := types[triple.SObject]; ok {
+			continue
+		}
+		report.DanglingCount++
+
+		reference := Reference{
+			Subject:   triple.SSubject,
+			Predicate: triple.SPredicate,
+			Object:    triple.SObject,
+		}
+		if _, ok := listed[reference]; ok {
+			continue
+		}
+		listed[reference] = struct{}{}
+		if evicted, ok := dangling.add(reference); ok {
+			delete(listed, evicted)
+		}
+	}
+	report.Dangling = dangling.sorted()
+
+	// find the bundles of every entity
+	entities := make(map[impl.Label][]string)
+	var walk func(bundle string, entity *wisski.Entity)
+	walk = func(bundle string, entity *wisski.Entity) {
+		if bundle != "" && !slices.Contains(entities[entity.URI], bundle) {
+			entities[entity.URI] = append(entities[entity.URI], bundle)
+		} else if _, ok := entities[entity.URI]; !ok {
+			entities[entity.URI] = nil
+		}
+		for _, children := range entity.Children {
+			for i := range children {
+				walk("", &children[i])
+			}
+		}
+	}
+	for bundle, es := range bundles {
+		for i := range es {
+			walk(bundle, &es[i])
+		}
+	}
+
+	// find typed nodes that no bundle picked up
+	orphaned := bounded[Node]{limit: limit, compare: func(a, b Node) int { return cmp.Compare(a.URI, b.URI) }}
+	for uri, classes := range types {
+		if _, ok := entities[uri]; ok {
+			continue
+		}
+		report.OrphanedCount++
+		orphaned.add(Node{URI: uri, Types: classes})
+	}
+	report.Orphaned = orphaned.sorted()
+	for i, node := range report.Orphaned {
+		classes := slices.Clone(node.Types)
+		slices.Sort(classes)
+		report.Orphaned[i].Types = slices.Compact(classes)
+	}
+
+	// find entities in several bundles
+	duplicates := bounded[Duplicate]{limit: limit, compare: func(a, b Duplicate) int { return cmp.Compare(a.URI, b.URI) }}
+	for uri, bundles := range entities {
+		if len(bundles) < 2 {
+			continue
+		}
+		report.DuplicateCount++
+		duplicates.add(Duplicate{URI: uri, Bundles: bundles})
+	}
+	report.Duplicates = duplicates.sorted()
+	for i, duplicate := range report.Duplicates {
+		report.Duplicates[i].Bundles = slices.Sorted(slices.Values(duplicate.Bundles))
+	}
+
+	return &report, nil
+}
+
+// compareReferences orders references by object, subject and predicate.
+func compareReferences(a, b Reference) int {
+	return cmp.Or(
+		cmp.Compare(a.Object, b.Object),
+		cmp.Compare(a.Subject, b.Subject),
+		cmp.Compare(a.Predicate, b.Predicate),
+	)
+}
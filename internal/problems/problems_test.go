@@ -0,0 +1,156 @@
+//spellchecker:words problems
+package problems_test
+
+//spellchecker:words reflect testing github hangover internal problems triplestore igraph impl wisski
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FAU-CDI/hangover/internal/problems"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	var index igraph.Index
+	if err := index.Reset(&igraph.MemoryEngine{}); err != nil {
+		t.Fatalf("Reset() returned error %v", err)
+	}
+	defer func() {
+		if err := index.Close(); err != nil {
+			t.Errorf("Close() returned error %v", err)
+		}
+	}()
+
+	triples := [][3]impl.Label{
+		{"alice", wisski.Type, "Person"},
+		{"bob", wisski.Type, "Person"},
+		{"bob", wisski.Type, "Author"},
+		{"carol", wisski.Type, "Person"},
+		{"dave", wisski.Type, "Place"},
+		{"alice", "knows", "bob"},
+		{"alice", "knows", "eve"},
+		{"carol", "livesIn", "nowhere"},
+	}
+	for _, triple := range triples {
+		if err := index.AddTriple(triple[0], triple[1], triple[2], impl.Source{}); err != nil {
+			t.Fatalf("AddTriple() returned error %v", err)
+		}
+	}
+	if err := index.AddData("alice", "name", impl.Datum{Value: "Alice"}, impl.Source{}); err != nil {
+		t.Fatalf("AddData() returned error %v", err)
+	}
+	if err := index.Finalize(); err != nil {
+		t.Fatalf("Finalize() returned error %v", err)
+	}
+
+	bundles := map[string][]wisski.Entity{
+		"person": {
+			{URI: "alice", Children: map[string][]wisski.Entity{"residence": {{URI: "dave"}}}},
+			{URI: "bob"},
+		},
+		"author": {
+			{URI: "bob"},
+		},
+	}
+
+	got, err := problems.Find(index.Triples, bundles, 1)
+	if err != nil {
+		t.Fatalf("Find() returned error %v", err)
+	}
+
+	want := &problems.Report{
+		Dangling:      []problems.Reference{{Subject: "alice", Predicate: "knows", Object: "eve"}},
+		DanglingCount: 2,
+
+		Orphaned:      []problems.Node{{URI: "carol", Types: []impl.Label{"Person"}}},
+		OrphanedCount: 1,
+
+		Duplicates:     []problems.Duplicate{{URI: "bob", Bundles: []string{"author", "person"}}},
+		DuplicateCount: 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %#v, want %#v", got, want)
+	}
+}
+
+func TestFind_Limit(t *testing.T) {
+	t.Parallel()
+
+	var index igraph.Index
+	if err := index.Reset(&igraph.MemoryEngine{}); err != nil {
+		t.Fatalf("Reset() returned error %v", err)
+	}
+	defer func() {
+		if err := index.Close(); err != nil {
+			t.Errorf("Close() returned error %v", err)
+		}
+	}()
+
+	// "a" and "alias" are the same node, so both of their dangling references are listed once
+	if err := index.MarkIdentical("a", "alias"); err != nil {
+		t.Fatalf("MarkIdentical() returned error %v", err)
+	}
+	triples := [][3]impl.Label{
+		{"a", "knows", "x"},
+		{"alias", "knows", "x"},
+		{"a", "knows", "z"},
+		{"b", "knows", "y"},
+		{"c", "knows", "w"},
+		{"d", "knows", "v"},
+	}
+	for _, triple := range triples {
+		if err := index.AddTriple(triple[0], triple[1], triple[2], impl.Source{}); err != nil {
+			t.Fatalf("AddTriple() returned error %v", err)
+		}
+	}
+	if err := index.Finalize(); err != nil {
+		t.Fatalf("Finalize() returned error %v", err)
+	}
+
+	for _, tt := range []struct {
+		limit int
+		want  []problems.Reference
+	}{
+		{
+			limit: 2,
+			want: []problems.Reference{
+				{Subject: "d", Predicate: "knows", Object: "v"},
+				{Subject: "c", Predicate: "knows", Object: "w"},
+			},
+		},
+		{
+			limit: 4,
+			want: []problems.Reference{
+				{Subject: "d", Predicate: "knows", Object: "v"},
+				{Subject: "c", Predicate: "knows", Object: "w"},
+				{Subject: "a", Predicate: "knows", Object: "x"},
+				{Subject: "b", Predicate: "knows", Object: "y"},
+			},
+		},
+		{
+			limit: 0,
+			want: []problems.Reference{
+				{Subject: "d", Predicate: "knows", Object: "v"},
+				{Subject: "c", Predicate: "knows", Object: "w"},
+				{Subject: "a", Predicate: "knows", Object: "x"},
+				{Subject: "b", Predicate: "knows", Object: "y"},
+				{Subject: "a", Predicate: "knows", Object: "z"},
+			},
+		},
+	} {
+		got, err := problems.Find(index.Triples, nil, tt.limit)
+		if err != nil {
+			t.Fatalf("Find() returned error %v", err)
+		}
+		if !reflect.DeepEqual(got.Dangling, tt.want) {
+			t.Errorf("Find(limit %d).Dangling = %v, want %v", tt.limit, got.Dangling, tt.want)
+		}
+		if got.DanglingCount != len(triples) {
+			t.Errorf("Find(limit %d).DanglingCount = %d, want %d", tt.limit, got.DanglingCount, len(triples))
+		}
+	}
+}
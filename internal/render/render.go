@@ -0,0 +1,190 @@
+// Package render determines how the values of fields are rendered.
+//
+//spellchecker:words render
+package render
+
+//spellchecker:words bufio errors math strconv strings github drincw pathbuilder hangover internal timeline
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/timeline"
+)
+
+//spellchecker:words pathbuilder datetime daterange
+
+// Renderer determines how the values of a field are rendered.
+type Renderer string
+
+// Renderers understood by the viewer.
+const (
+	Text      Renderer = "text"      // plain text
+	HTML      Renderer = "html"      // sanitized html, if html rendering is enabled
+	Image     Renderer = "image"     // an image, if image rendering is enabled
+	Link      Renderer = "link"      // a link to an external url
+	File      Renderer = "file"      // a link to a downloadable file
+	Date      Renderer = "date"      // a date, see [DateValue]
+	Number    Renderer = "number"    // a number, see [NumberValue]
+	Reference Renderer = "reference" // a reference to another entity
+)
+
+// Renderers holds all known renderers.
+var Renderers = []Renderer{Text, HTML, Image, Link, File, Date, Number, Reference}
+
+// Valid checks if renderer is a known renderer.
+func (renderer Renderer) Valid() bool {
+	for _, r := range Renderers {
+		if r == renderer {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultTypes maps WissKI field types to the renderer used for them.
+// Field types not in this map are rendered as [Text].
+var DefaultTypes = map[string]Renderer{
+	"entity_reference":  Reference,
+	"link":              Link,
+	"image":             Image,
+	"file":              File,
+	"text_long":         HTML,
+	"text_with_summary": HTML,
+	"integer":           Number,
+	"decimal":           Number,
+	"float":             Number,
+	"list_integer":      Number,
+	"list_float":        Number,
+	"datetime":          Date,
+	"daterange":         Date,
+	"timestamp":         Date,
+}
+
+// Registry determines the renderer of each field.
+// The zero value uses [DefaultTypes] and no overrides.
+type Registry struct {
+	Types     map[string]Renderer // renderers by field type; nil uses [DefaultTypes]
+	Overrides Overrides           // renderers by field machine name, taking precedence over types
+}
+
+// For returns the renderer to use for the given field.
+func (registry Registry) For(field pathbuilder.Field) Renderer {
+	if renderer, ok := registry.Overrides[field.MachineName()]; ok {
+		return renderer
+	}
+
+	types := registry.Types
+	if types == nil {
+		types = DefaultTypes
+	}
+	if renderer, ok := types[field.FieldType]; ok {
+		return renderer
+	}
+	return Text
+}
+
+// Overrides holds renderers by field machine name.
+type Overrides map[string]Renderer
+
+var (
+	errInvalidOverride   = errors.New("override must be of the form 'field renderer'")
+	errDuplicateOverride = errors.New("duplicate override")
+	errUnknownRenderer   = errors.New("unknown renderer")
+)
+
+// ParseOverrides parses an overrides file.
+//
+// Each line of the file is of the form "field renderer", where field is the machine name of a field,
+// and renderer is one of [Renderers].
+// Empty lines and lines starting with '#' are ignored.
+func ParseOverrides(reader io.Reader) (Overrides, error) {
+	overrides := make(Overrides)
+
+	scanner := bufio.NewScanner(reader)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var err error
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) != 2:
+			err = errInvalidOverride
+		case !Renderer(fields[1]).Valid():
+			err = fmt.Errorf("%w %q", errUnknownRenderer, fields[1])
+		default:
+			if _, ok := overrides[fields[0]]; ok {
+				err = fmt.Errorf("%w for field %q", errDuplicateOverride, fields[0])
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		overrides[fields[0]] = Renderer(fields[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return overrides, nil
+}
+
+// LoadOverrides loads overrides from the file at path.
+func LoadOverrides(path string) (overrides Overrides, e error) {
+	file, err := os.Open(path) // #nosec G304 -- explicit parameter
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		if e2 := file.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close file: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	return ParseOverrides(file)
+}
+
+// DateValue returns the machine-readable form of a date-like value, as used in the datetime attribute of html time elements.
+// Returns an empty string unless value is an exact day, month or year, see [timeline.Parse].
+func DateValue(value string) string {
+	rng, ok := timeline.Parse(value)
+	if !ok || rng.Approximate {
+		return ""
+	}
+
+	// find the most precise form that denotes exactly the same range
+	start := rng.Start
+	for _, candidate := range []string{
+		start.String(),
+		fmt.Sprintf("%04d-%02d", start.Year, start.Month),
+		fmt.Sprintf("%04d", start.Year),
+	} {
+		if exact, ok := timeline.Parse(candidate); ok && exact == rng {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// NumberValue returns the machine-readable form of a numeric value.
+// Returns an empty string if value is not a number.
+func NumberValue(value string) string {
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return ""
+	}
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
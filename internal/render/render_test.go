@@ -0,0 +1,94 @@
+//spellchecker:words render
+package render_test
+
+//spellchecker:words strings testing github drincw pathbuilder hangover internal render
+import (
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/render"
+)
+
+//spellchecker:words pathbuilder
+
+func TestRegistry_For(t *testing.T) {
+	t.Parallel()
+
+	field := func(id, typ string) pathbuilder.Field {
+		return pathbuilder.Field{Path: pathbuilder.Path{ID: id, FieldType: typ}}
+	}
+
+	overrides, err := render.ParseOverrides(strings.NewReader("# urls that are not images\nf_scan link\n\nf_year number\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		registry render.Registry
+		field    pathbuilder.Field
+		want     render.Renderer
+	}{
+		{render.Registry{}, field("f_scan", "image"), render.Image},
+		{render.Registry{}, field("f_name", "string"), render.Text},
+		{render.Registry{}, field("f_ref", "entity_reference"), render.Reference},
+		{render.Registry{}, field("f_count", "integer"), render.Number},
+		{render.Registry{Overrides: overrides}, field("f_scan", "image"), render.Link},
+		{render.Registry{Overrides: overrides}, field("f_year", "string"), render.Number},
+		{render.Registry{Types: map[string]render.Renderer{"string": render.HTML}}, field("f_name", "string"), render.HTML},
+		{render.Registry{Types: map[string]render.Renderer{"string": render.HTML}}, field("f_ref", "entity_reference"), render.Text},
+	}
+
+	for _, tt := range tests {
+		if got := tt.registry.For(tt.field); got != tt.want {
+			t.Errorf("Registry.For(%q) = %q, want %q", tt.field.ID, got, tt.want)
+		}
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	t.Parallel()
+
+	for _, invalid := range []string{"f_scan", "f_scan image extra", "f_scan picture", "f_scan image\nf_scan link"} {
+		if _, err := render.ParseOverrides(strings.NewReader(invalid)); err == nil {
+			t.Errorf("ParseOverrides(%q) did not return an error", invalid)
+		}
+	}
+}
+
+func TestDateValue(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"1850-05-02":            "1850-05-02",
+		"02.05.1850":            "1850-05-02",
+		"1850-05":               "1850-05",
+		"1850":                  "1850",
+		"1850-05-01/1850-05-15": "",
+		"1850-1860":             "",
+		"ca. 1850":              "",
+		"unknown":               "",
+	}
+	for value, want := range tests {
+		if got := render.DateValue(value); got != want {
+			t.Errorf("DateValue(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"42":     "42",
+		" 1.50 ": "1.5",
+		"1e3":    "1000",
+		"NaN":    "",
+		"twelve": "",
+	}
+	for value, want := range tests {
+		if got := render.NumberValue(value); got != want {
+			t.Errorf("NumberValue(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+//spellchecker:words shacl
+package shacl_test
+
+//spellchecker:words bytes reflect strings testing github drincw pathbuilder hangover internal shacl triplestore igraph impl wisski
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/shacl"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+func newPathbuilder() *pathbuilder.Pathbuilder {
+	pb := pathbuilder.NewPathbuilder()
+
+	person := pb.GetOrCreate("person")
+	person.Path = pathbuilder.Path{ID: "person", Name: "Person", IsGroup: true, PathArray: []string{"Person"}}
+	person.ChildFields = []pathbuilder.Field{
+		{Path: pathbuilder.Path{ID: "name", Name: "Name", Cardinality: 1, PathArray: []string{"Person"}, DatatypeProperty: "name"}},
+		{Path: pathbuilder.Path{ID: "knows", Name: "Knows", Cardinality: -1, PathArray: []string{"Person", "knows", "Person"}, DatatypeProperty: pathbuilder.DatatypeEmpty}},
+	}
+
+	birth := pb.GetOrCreate("birth")
+	birth.Path = pathbuilder.Path{ID: "birth", Name: "Birth", IsGroup: true, Cardinality: 1, PathArray: []string{"Person", "born", "Birth"}}
+	birth.ChildFields = []pathbuilder.Field{
+		{Path: pathbuilder.Path{ID: "date", Name: "Date", Cardinality: 1, PathArray: []string{"Person", "born", "Birth", "happened", "TimeSpan"}, DatatypeProperty: "date"}},
+	}
+	birth.Parent = person
+	person.ChildBundles = []*pathbuilder.Bundle{birth}
+
+	return &pb
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	got := shacl.New(newPathbuilder(), "urn:shape:")
+	want := &shacl.Shapes{
+		Shapes: []shacl.NodeShape{
+			{
+				ID: "urn:shape:person", Bundle: "person", Name: "Person",
+				TargetClass: "Person", Root: "Person",
+				Properties: []shacl.PropertyShape{
+					{ID: "urn:shape:person/name", Name: "Name", Path: []impl.Label{"name"}, MaxCount: 1, Literal: true},
+					{ID: "urn:shape:person/knows", Name: "Knows", Path: []impl.Label{"knows"}, Class: "Person"},
+					{ID: "urn:shape:person/birth/property", Name: "Birth", Path: []impl.Label{"born"}, MaxCount: 1, Node: "urn:shape:person/birth"},
+				},
+			},
+			{
+				ID: "urn:shape:person/birth", Bundle: "birth", Name: "Birth",
+				Class: "Birth", Root: "Person", Focus: []impl.Label{"born"},
+				Properties: []shacl.PropertyShape{
+					{ID: "urn:shape:person/birth/date", Name: "Date", Path: []impl.Label{"happened", "date"}, MaxCount: 1, Literal: true},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("New() = %#v, want %#v", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	var index igraph.Index
+	if err := index.Reset(&igraph.MemoryEngine{}); err != nil {
+		t.Fatalf("Reset() returned error %v", err)
+	}
+	defer func() {
+		if err := index.Close(); err != nil {
+			t.Errorf("Close() returned error %v", err)
+		}
+	}()
+
+	triples := [][3]impl.Label{
+		{"alice", wisski.Type, "Person"},
+		{"bob", wisski.Type, "Person"},
+		{"alice", "knows", "bob"},
+		{"alice", "knows", "eve"},
+		{"alice", "born", "b1"},
+		{"b1", wisski.Type, "Birth"},
+		{"bob", "born", "b2"},
+	}
+	for _, triple := range triples {
+		if err := index.AddTriple(triple[0], triple[1], triple[2], impl.Source{}); err != nil {
+			t.Fatalf("AddTriple() returned error %v", err)
+		}
+	}
+	for _, value := range []string{"Alice", "Alicia"} {
+		if err := index.AddData("alice", "name", impl.Datum{Value: value}, impl.Source{}); err != nil {
+			t.Fatalf("AddData() returned error %v", err)
+		}
+	}
+	if err := index.Finalize(); err != nil {
+		t.Fatalf("Finalize() returned error %v", err)
+	}
+
+	shapes := shacl.New(newPathbuilder(), "urn:shape:")
+	report, err := shapes.Validate(&index)
+	if err != nil {
+		t.Fatalf("Validate() returned error %v", err)
+	}
+
+	want := []shacl.Result{
+		{Focus: "alice", Path: []impl.Label{"name"}, Shape: "urn:shape:person/name", Component: shacl.MaxCountConstraintComponent, Message: "More than 1 values"},
+		{Focus: "alice", Path: []impl.Label{"knows"}, Value: "eve", Shape: "urn:shape:person/knows", Component: shacl.ClassConstraintComponent, Message: "Value is not an instance of Person"},
+		{Focus: "b2", Value: "b2", Shape: "urn:shape:person/birth", Component: shacl.ClassConstraintComponent, Message: "Value is not an instance of Birth"},
+	}
+	if !reflect.DeepEqual(report.Results, want) {
+		t.Errorf("Validate() = %#v, want %#v", report.Results, want)
+	}
+
+	var turtle bytes.Buffer
+	if err := report.WriteTurtle(&turtle); err != nil {
+		t.Fatalf("WriteTurtle() returned error %v", err)
+	}
+	for _, part := range []string{"sh:conforms\tfalse", "sh:sourceConstraintComponent\tsh:MaxCountConstraintComponent", "sh:focusNode\t<alice>"} {
+		if !strings.Contains(turtle.String(), part) {
+			t.Errorf("WriteTurtle() does not contain %q:\n%s", part, turtle.String())
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Package shacl generates SHACL shapes from a pathbuilder and validates data against them.
+//
+//spellchecker:words shacl
+package shacl
+
+//spellchecker:words github drincw pathbuilder hangover internal triplestore impl
+import (
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+)
+
+//spellchecker:words pathbuilder
+
+// DefaultBase is the default prefix for the iris of generated shapes.
+const DefaultBase = "urn:hangover:shape:"
+
+// Shapes holds the shapes generated from a pathbuilder.
+type Shapes struct {
+	Shapes []NodeShape // one shape per bundle, parents before their children
+}
+
+// NodeShape describes the entities of a single bundle.
+//
+// Top-level bundles target all instances of their class.
+// Child bundles have no target, instead they are referenced by a [PropertyShape] of their parent.
+type NodeShape struct {
+	ID     impl.Label // iri of this shape
+	Bundle string     // machine name of the bundle
+	Name   string     // human-readable name of the bundle
+
+	TargetClass impl.Label // class targeted by this shape; empty for child bundles
+	Class       impl.Label // class every focus node must be an instance of; empty for top-level bundles
+
+	// Root is the class of the top-level bundle this bundle belongs to.
+	// Focus is the sequence of predicates leading from an instance of Root to the focus nodes of this shape.
+	Root  impl.Label
+	Focus []impl.Label
+
+	Properties []PropertyShape
+}
+
+// PropertyShape describes a single field or child bundle.
+type PropertyShape struct {
+	ID   impl.Label   // iri of this shape
+	Name string       // human-readable name of the field or bundle
+	Path []impl.Label // sequence of predicates leading from the focus node to the values
+
+	MaxCount int        // maximal number of values; zero means unlimited
+	Literal  bool       // values must be literals
+	Class    impl.Label // values must be instances of this class; may be empty
+	Node     impl.Label // values must conform to this shape; may be empty
+}
+
+// New generates shapes for all bundles in the given pathbuilder.
+// base is used as a prefix for the iris of the generated shapes.
+func New(pb *pathbuilder.Pathbuilder, base string) *Shapes {
+	var shapes Shapes
+	for _, bundle := range pb.Bundles() {
+		shapes.add(bundle, base, "", nil)
+	}
+	return &shapes
+}
+
+// add adds shapes for the given bundle and its children.
+func (shapes *Shapes) add(bundle *pathbuilder.Bundle, base string, root impl.Label, focus []impl.Label) impl.Label {
+	class := lastClass(bundle.PathArray)
+
+	shape := NodeShape{
+		ID:     impl.Label(base + bundle.MachineName()),
+		Bundle: bundle.MachineName(),
+		Name:   bundle.Name,
+		Root:   root,
+		Focus:  focus,
+	}
+	if bundle.Parent == nil {
+		shape.TargetClass = class
+		shape.Root = class
+	} else {
+		shape.Class = class
+	}
+
+	// the entity of a bundle is the last class of its path
+	entity := len(bundle.PathArray) / 2
+
+	for _, field := range bundle.Fields() {
+		path := predicates(field.PathArray, entity)
+		if datatype := field.Datatype(); datatype != "" {
+			path = append(path, impl.Label(datatype))
+		}
+		if len(path) == 0 {
+			continue
+		}
+
+		property := PropertyShape{
+			ID:       shape.ID + "/" + impl.Label(field.MachineName()),
+			Name:     field.Name,
+			Path:     path,
+			MaxCount: max(field.Cardinality, 0),
+			Literal:  field.Datatype() != "",
+		}
+		if !property.Literal {
+			property.Class = lastClass(field.PathArray)
+		}
+		shape.Properties = append(shape.Properties, property)
+	}
+
+	// add the shape before the shapes of its children
+	index := len(shapes.Shapes)
+	shapes.Shapes = append(shapes.Shapes, shape)
+
+	for _, child := range bundle.Bundles() {
+		path := predicates(child.PathArray, entity)
+		if len(path) == 0 {
+			continue
+		}
+
+		childFocus := append(focus[:len(focus):len(focus)], path...)
+		node := shapes.add(child, string(shape.ID)+"/", shape.Root, childFocus)
+
+		shapes.Shapes[index].Properties = append(shapes.Shapes[index].Properties, PropertyShape{
+			ID:       node + "/property",
+			Name:     child.Name,
+			Path:     path,
+			MaxCount: max(child.Cardinality, 0),
+			Node:     node,
+		})
+	}
+
+	return shape.ID
+}
+
+// predicates returns the predicates in a path array that follow the node with the given index.
+func predicates(ary []string, node int) (path []impl.Label) {
+	for i := 2*node + 1; i < len(ary); i += 2 {
+		path = append(path, impl.Label(ary[i]))
+	}
+	return path
+}
+
+// lastClass returns the last class in a path array, or the empty label if there is none.
+func lastClass(ary []string) impl.Label {
+	if len(ary) == 0 || len(ary)%2 == 0 {
+		return ""
+	}
+	return impl.Label(ary[len(ary)-1])
+}
@@ -0,0 +1,270 @@
+//spellchecker:words shacl
+package shacl
+
+//spellchecker:words errors strconv github anglo korean hangover internal triplestore impl wisski
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	"github.com/anglo-korean/rdf"
+)
+
+//spellchecker:words nodeKind maxCount targetClass focusNode resultPath resultSeverity sourceShape resultMessage sourceConstraintComponent
+
+// Namespaces used in generated turtle.
+const (
+	NamespaceSHACL = "http://www.w3.org/ns/shacl#"
+	NamespaceRDF   = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+)
+
+// Constraint components reported in validation results.
+const (
+	ClassConstraintComponent    impl.Label = NamespaceSHACL + "ClassConstraintComponent"
+	MaxCountConstraintComponent impl.Label = NamespaceSHACL + "MaxCountConstraintComponent"
+	NodeKindConstraintComponent impl.Label = NamespaceSHACL + "NodeKindConstraintComponent"
+)
+
+// WriteTurtle writes the shapes as turtle to w.
+func (shapes *Shapes) WriteTurtle(w io.Writer) (e error) {
+	g := newGraph(w)
+	defer func() {
+		if e2 := g.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close encoder: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	for _, shape := range shapes.Shapes {
+		id := g.iri(shape.ID)
+
+		g.add(id, wisski.Type, g.iri(NamespaceSHACL+"NodeShape"))
+		g.add(id, NamespaceSHACL+"name", g.literal(shape.Name))
+		if shape.TargetClass != "" {
+			g.add(id, NamespaceSHACL+"targetClass", g.iri(shape.TargetClass))
+		}
+		if shape.Class != "" {
+			g.add(id, NamespaceSHACL+"class", g.iri(shape.Class))
+		}
+		for _, property := range shape.Properties {
+			g.add(id, NamespaceSHACL+"property", g.iri(property.ID))
+		}
+
+		for _, property := range shape.Properties {
+			id := g.iri(property.ID)
+			path := g.path(property.Path)
+
+			g.add(id, wisski.Type, g.iri(NamespaceSHACL+"PropertyShape"))
+			g.add(id, NamespaceSHACL+"name", g.literal(property.Name))
+			g.add(id, NamespaceSHACL+"path", path.node)
+			if property.MaxCount > 0 {
+				g.add(id, NamespaceSHACL+"maxCount", g.integer(property.MaxCount))
+			}
+			if property.Literal {
+				g.add(id, NamespaceSHACL+"nodeKind", g.iri(NamespaceSHACL+"Literal"))
+			}
+			if property.Class != "" {
+				g.add(id, NamespaceSHACL+"class", g.iri(property.Class))
+			}
+			if property.Node != "" {
+				g.add(id, NamespaceSHACL+"node", g.iri(property.Node))
+			}
+			path.write()
+		}
+	}
+
+	return g.err
+}
+
+// WriteTurtle writes the report as a SHACL validation report in turtle to w.
+func (report *Report) WriteTurtle(w io.Writer) (e error) {
+	g := newGraph(w)
+	defer func() {
+		if e2 := g.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close encoder: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	id := g.blank()
+	g.add(id, wisski.Type, g.iri(NamespaceSHACL+"ValidationReport"))
+	g.add(id, NamespaceSHACL+"conforms", g.boolean(report.Conforms()))
+
+	results := make([]rdf.Blank, len(report.Results))
+	for i := range results {
+		results[i] = g.blank()
+		g.add(id, NamespaceSHACL+"result", results[i])
+	}
+
+	for i, result := range report.Results {
+		id := results[i]
+		path := g.path(result.Path)
+
+		g.add(id, wisski.Type, g.iri(NamespaceSHACL+"ValidationResult"))
+		g.add(id, NamespaceSHACL+"resultSeverity", g.iri(NamespaceSHACL+"Violation"))
+		g.add(id, NamespaceSHACL+"focusNode", g.iri(result.Focus))
+		if path.node != nil {
+			g.add(id, NamespaceSHACL+"resultPath", path.node)
+		}
+		if result.Value != "" {
+			g.add(id, NamespaceSHACL+"value", g.iri(result.Value))
+		}
+		g.add(id, NamespaceSHACL+"sourceShape", g.iri(result.Shape))
+		g.add(id, NamespaceSHACL+"sourceConstraintComponent", g.iri(result.Component))
+		g.add(id, NamespaceSHACL+"resultMessage", g.literal(result.Message))
+		path.write()
+	}
+
+	return g.err
+}
+
+// graph writes triples to an encoder, remembering the first error.
+type graph struct {
+	encoder *rdf.TripleEncoder
+	blanks  int
+	err     error
+}
+
+func newGraph(w io.Writer) *graph {
+	encoder := rdf.NewTripleEncoder(w, rdf.Turtle)
+
+	// only abbreviate well-known namespaces.
+	// iris from the data may not form valid prefixed names.
+	encoder.GenerateNamespaces = false
+	encoder.Namespaces[NamespaceSHACL] = "sh"
+	encoder.Namespaces[NamespaceRDF] = "rdf"
+
+	return &graph{encoder: encoder}
+}
+
+func (g *graph) Close() error {
+	return g.encoder.Close()
+}
+
+// record records err unless an error has already been recorded.
+func (g *graph) record(err error) {
+	if g.err == nil && err != nil {
+		g.err = err
+	}
+}
+
+func (g *graph) iri(label impl.Label) rdf.Term {
+	iri, err := rdf.NewIRI(string(label))
+	if err != nil {
+		g.record(fmt.Errorf("failed to create IRI %q: %w", label, err))
+		return nil
+	}
+	return iri
+}
+
+func (g *graph) blank() rdf.Blank {
+	g.blanks++
+	blank, err := rdf.NewBlank("b" + strconv.Itoa(g.blanks))
+	g.record(err)
+	return blank
+}
+
+func (g *graph) literal(value string) rdf.Term {
+	literal, err := rdf.NewLiteral(value)
+	if err != nil {
+		g.record(fmt.Errorf("failed to create literal: %w", err))
+		return nil
+	}
+	return literal
+}
+
+func (g *graph) integer(value int) rdf.Term {
+	literal, err := rdf.NewLiteral(value)
+	if err != nil {
+		g.record(fmt.Errorf("failed to create literal: %w", err))
+		return nil
+	}
+	return literal
+}
+
+func (g *graph) boolean(value bool) rdf.Term {
+	literal, err := rdf.NewLiteral(value)
+	if err != nil {
+		g.record(fmt.Errorf("failed to create literal: %w", err))
+		return nil
+	}
+	return literal
+}
+
+// add writes a single triple.
+// Triples with the same subject should be added consecutively.
+func (g *graph) add(subject rdf.Term, predicate impl.Label, object rdf.Term) {
+	if g.err != nil {
+		return
+	}
+
+	s, ok := subject.(rdf.Subject)
+	if !ok {
+		g.record(fmt.Errorf("invalid subject %v", subject))
+		return
+	}
+	p, err := rdf.NewIRI(string(predicate))
+	if err != nil {
+		g.record(fmt.Errorf("failed to create IRI %q: %w", predicate, err))
+		return
+	}
+	o, ok := object.(rdf.Object)
+	if !ok {
+		g.record(fmt.Errorf("invalid object %v", object))
+		return
+	}
+
+	g.record(g.encoder.Encode(rdf.Triple{Subj: s, Pred: p, Obj: o}))
+}
+
+// path is a SHACL property path.
+type path struct {
+	node rdf.Term // node representing the path; nil for the empty path
+
+	g     *graph
+	items []impl.Label
+	cells []rdf.Blank // cells of the rdf list for a sequence path
+}
+
+// path creates a path for the given sequence of predicates.
+// A single predicate is represented by itself, longer sequences by an rdf list.
+//
+// The triples describing the list are not written until write is called.
+func (g *graph) path(predicates []impl.Label) path {
+	p := path{g: g, items: predicates}
+	switch len(predicates) {
+	case 0:
+	case 1:
+		p.node = g.iri(predicates[0])
+	default:
+		p.cells = make([]rdf.Blank, len(predicates))
+		for i := range p.cells {
+			p.cells[i] = g.blank()
+		}
+		p.node = p.cells[0]
+	}
+	return p
+}
+
+// write writes the rdf list representing this path, if any.
+func (p path) write() {
+	for i, cell := range p.cells {
+		p.g.add(cell, NamespaceRDF+"first", p.g.iri(p.items[i]))
+		if i+1 < len(p.cells) {
+			p.g.add(cell, NamespaceRDF+"rest", p.cells[i+1])
+		} else {
+			p.g.add(cell, NamespaceRDF+"rest", p.g.iri(NamespaceRDF+"nil"))
+		}
+	}
+}
@@ -0,0 +1,203 @@
+//spellchecker:words shacl
+package shacl
+
+//spellchecker:words maps slices strconv github hangover internal triplestore igraph impl wisski
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+// Report is the result of validating data against shapes.
+type Report struct {
+	Results []Result
+}
+
+// Conforms checks if the data conforms to the shapes.
+func (report *Report) Conforms() bool {
+	return len(report.Results) == 0
+}
+
+// Result describes a single violation of a constraint.
+type Result struct {
+	Focus     impl.Label   // focus node the violation was found at
+	Path      []impl.Label // path of the property shape; empty for constraints of node shapes
+	Value     impl.Label   // value that caused the violation; empty if not applicable
+	Shape     impl.Label   // shape that holds the violated constraint
+	Component impl.Label   // constraint component that was violated
+	Message   string
+}
+
+// Validate validates the data in index against the shapes.
+//
+// Only the canonical form of the data is validated.
+// Paths are followed regardless of the classes of intermediate nodes, as mandated by SHACL.
+func (shapes *Shapes) Validate(index *igraph.Index) (*Report, error) {
+	v := validator{
+		index:     index,
+		instances: make(map[impl.Label]map[impl.Label]struct{}),
+	}
+
+	var report Report
+	for _, shape := range shapes.Shapes {
+		results, err := v.validate(shape)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate shape %q: %w", shape.ID, err)
+		}
+		report.Results = append(report.Results, results...)
+	}
+	return &report, nil
+}
+
+type validator struct {
+	index     *igraph.Index
+	instances map[impl.Label]map[impl.Label]struct{} // instances of each class
+}
+
+// validate validates a single node shape.
+func (v *validator) validate(shape NodeShape) (results []Result, err error) {
+	if shape.Root == "" {
+		return nil, nil
+	}
+
+	// check that all focus nodes have the right class
+	if shape.Class != "" {
+		focus, err := v.values(shape.Root, shape.Focus, len(shape.Focus))
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range slices.Sorted(maps.Keys(focus)) {
+			ok, err := v.instanceOf(node, shape.Class)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				continue
+			}
+			results = append(results, Result{
+				Focus:     node,
+				Value:     node,
+				Shape:     shape.ID,
+				Component: ClassConstraintComponent,
+				Message:   "Value is not an instance of " + string(shape.Class),
+			})
+		}
+	}
+
+	for _, property := range shape.Properties {
+		values, err := v.values(shape.Root, append(shape.Focus[:len(shape.Focus):len(shape.Focus)], property.Path...), len(shape.Focus))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, focus := range slices.Sorted(maps.Keys(values)) {
+			result := Result{Focus: focus, Path: property.Path, Shape: property.ID}
+
+			if property.MaxCount > 0 && len(values[focus]) > property.MaxCount {
+				result.Component = MaxCountConstraintComponent
+				result.Message = "More than " + strconv.Itoa(property.MaxCount) + " values"
+				results = append(results, result)
+			}
+
+			for _, key := range slices.Sorted(maps.Keys(values[focus])) {
+				value := values[focus][key]
+				switch {
+				case property.Literal && !value.literal:
+					result.Value = value.node
+					result.Component = NodeKindConstraintComponent
+					result.Message = "Value is not a literal"
+					results = append(results, result)
+				case property.Class != "" && !value.literal:
+					ok, err := v.instanceOf(value.node, property.Class)
+					if err != nil {
+						return nil, err
+					}
+					if ok {
+						continue
+					}
+					result.Value = value.node
+					result.Component = ClassConstraintComponent
+					result.Message = "Value is not an instance of " + string(property.Class)
+					results = append(results, result)
+				}
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// value is a value reached by a path.
+type value struct {
+	node    impl.Label
+	literal bool
+}
+
+// values returns the values reached by following path from every instance of root.
+// The values are indexed by the node that is reached after the first focus predicates.
+// If focus is len(path), the nodes reached by path are returned without any values.
+func (v *validator) values(root impl.Label, path []impl.Label, focus int) (map[impl.Label]map[string]value, error) {
+	paths, err := v.index.PathsStarting(wisski.Type, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query instances: %w", err)
+	}
+	for _, predicate := range path {
+		if err := paths.Connected(predicate); err != nil {
+			return nil, fmt.Errorf("failed to follow predicate %q: %w", predicate, err)
+		}
+	}
+
+	values := make(map[impl.Label]map[string]value)
+	for path, err := range paths.Paths() {
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate paths: %w", err)
+		}
+		if len(path.Nodes) <= focus {
+			continue
+		}
+
+		node := path.Nodes[focus]
+		if _, ok := values[node]; !ok {
+			values[node] = make(map[string]value)
+		}
+
+		switch {
+		case len(path.Nodes) == focus+1 && !path.HasDatum:
+			// the focus node is the end of the path
+		case path.HasDatum:
+			values[node]["\""+path.Datum.Value+"\"@"+path.Datum.Language] = value{literal: true}
+		default:
+			last := path.Nodes[len(path.Nodes)-1]
+			values[node]["<"+string(last)+">"] = value{node: last}
+		}
+	}
+	return values, nil
+}
+
+// instanceOf checks if node is an instance of class.
+func (v *validator) instanceOf(node, class impl.Label) (bool, error) {
+	instances, ok := v.instances[class]
+	if !ok {
+		paths, err := v.index.PathsStarting(wisski.Type, class)
+		if err != nil {
+			return false, fmt.Errorf("failed to query instances: %w", err)
+		}
+
+		instances = make(map[impl.Label]struct{})
+		for path, err := range paths.Paths() {
+			if err != nil {
+				return false, fmt.Errorf("failed to iterate paths: %w", err)
+			}
+			instances[path.Nodes[0]] = struct{}{}
+		}
+		v.instances[class] = instances
+	}
+
+	_, ok = instances[node]
+	return ok, nil
+}
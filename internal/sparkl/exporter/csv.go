@@ -0,0 +1,291 @@
+//spellchecker:words exporter
+package exporter
+
+//spellchecker:words bufio errors strings sync unicode github drincw pathbuilder hangover internal wisski
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+// CSV implements an exporter that streams entities into csv files.
+//
+// Every bundle, including child bundles, is written to a file named like the table of the [SQL] exporter.
+// It has a "uri" column, a "parent" column for child bundles, and a "field__name" column for every field.
+// Multiple values of a field are joined using Separator.
+//
+// When FieldFiles is set, field values are instead written to separate files with "uri", "value" and "lang" columns.
+type CSV struct {
+	// Create is called to create the file with the given name, excluding any extension.
+	// Files are closed once all entities of their top-level bundle have been written.
+	Create func(name string) (io.WriteCloser, error)
+
+	Comma     rune   // field delimiter, defaults to ','
+	Quote     rune   // quote character, defaults to '"'
+	QuoteAll  bool   // quote every field, not only those that need it
+	Separator string // separator for multiple values of a field
+	BOM       bool   // start every file with a UTF-8 byte order mark, for Excel
+
+	FieldFiles bool // write field values into separate files
+
+	l     sync.Mutex
+	files map[string]map[string]*csvFile // open files by top-level bundle and name
+}
+
+// csvFile is a single open csv file.
+type csvFile struct {
+	closer io.Closer
+	buffer *bufio.Writer
+}
+
+var (
+	errCSVNoCreate  = errors.New("Create not set")
+	errCSVDelimiter = errors.New("invalid delimiter")
+	errCSVQuote     = errors.New("invalid quote")
+)
+
+// byteOrderMark is the UTF-8 encoded byte order mark.
+const byteOrderMark = "\uFEFF"
+
+// BundleFile returns the name of the file holding entities of the given bundle.
+func (*CSV) BundleFile(bundle *pathbuilder.Bundle) string {
+	return bundleTablePrefix + bundle.MachineName()
+}
+
+// FieldFile returns the name of the file holding values of the given field.
+func (*CSV) FieldFile(bundle *pathbuilder.Bundle, field pathbuilder.Field) string {
+	return fieldTablePrefix + bundle.MachineName() + fieldTableInfix + field.MachineName()
+}
+
+// Begin signals that count entities will be transmitted for the given bundle.
+func (cv *CSV) Begin(bundle *pathbuilder.Bundle, count int64) (e error) {
+	if cv.Create == nil {
+		return errCSVNoCreate
+	}
+	if _, _, err := cv.delimiters(); err != nil {
+		return err
+	}
+
+	files := make(map[string]*csvFile)
+	defer func() {
+		if e != nil {
+			e = errors.Join(e, cv.closeFiles(files))
+		}
+	}()
+	if err := cv.createFiles(files, bundle); err != nil {
+		return err
+	}
+
+	cv.l.Lock()
+	defer cv.l.Unlock()
+
+	if cv.files == nil {
+		cv.files = make(map[string]map[string]*csvFile)
+	}
+	cv.files[bundle.MachineName()] = files
+	return nil
+}
+
+// createFiles creates the files for the given bundle and its children, and writes their headers.
+func (cv *CSV) createFiles(files map[string]*csvFile, bundle *pathbuilder.Bundle) error {
+	header := []string{uriColumn}
+	if !bundle.IsToplevel() {
+		header = append(header, parentColumn)
+	}
+	for _, field := range bundle.ChildFields {
+		if cv.FieldFiles {
+			if err := cv.createFile(files, cv.FieldFile(bundle, field), []string{uriColumn, valueColumn, langColumn}); err != nil {
+				return err
+			}
+			continue
+		}
+		header = append(header, fieldColumnPrefix+field.MachineName())
+	}
+	if err := cv.createFile(files, cv.BundleFile(bundle), header); err != nil {
+		return err
+	}
+
+	for _, child := range bundle.ChildBundles {
+		if err := cv.createFiles(files, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createFile creates a single file and writes the given header.
+func (cv *CSV) createFile(files map[string]*csvFile, name string, header []string) error {
+	writer, err := cv.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create %q: %w", name, err)
+	}
+
+	file := &csvFile{closer: writer, buffer: bufio.NewWriter(writer)}
+	files[name] = file
+
+	if cv.BOM {
+		if _, err := file.buffer.WriteString(byteOrderMark); err != nil {
+			return fmt.Errorf("failed to write byte order mark: %w", err)
+		}
+	}
+	return cv.write(file, header)
+}
+
+// Add adds entities for the given bundle.
+func (cv *CSV) Add(bundle *pathbuilder.Bundle, entity *wisski.Entity) error {
+	cv.l.Lock()
+	files := cv.files[bundle.MachineName()]
+	cv.l.Unlock()
+
+	return cv.add(files, bundle, "", entity)
+}
+
+// add writes an entity of the given bundle, and then its children.
+func (cv *CSV) add(files map[string]*csvFile, bundle *pathbuilder.Bundle, parent string, entity *wisski.Entity) error {
+	record := []string{string(entity.URI)}
+	if !bundle.IsToplevel() {
+		record = append(record, parent)
+	}
+
+	var builder strings.Builder
+	for _, field := range bundle.ChildFields {
+		values := entity.Fields[field.MachineName()]
+
+		if cv.FieldFiles {
+			file := files[cv.FieldFile(bundle, field)]
+			for _, value := range values {
+				if err := cv.write(file, []string{string(entity.URI), value.Datum.Value, value.Datum.Language}); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for i, value := range values {
+			if i > 0 {
+				builder.WriteString(cv.Separator)
+			}
+			builder.WriteString(value.Datum.Value)
+		}
+		record = append(record, builder.String())
+		builder.Reset()
+	}
+	if err := cv.write(files[cv.BundleFile(bundle)], record); err != nil {
+		return err
+	}
+
+	for _, child := range bundle.ChildBundles {
+		children := entity.Children[child.MachineName()]
+		for i := range children {
+			if err := cv.add(files, child, string(entity.URI), &children[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// End signals that no more entities will be submitted for the given bundle.
+func (cv *CSV) End(bundle *pathbuilder.Bundle) error {
+	cv.l.Lock()
+	files := cv.files[bundle.MachineName()]
+	delete(cv.files, bundle.MachineName())
+	cv.l.Unlock()
+
+	return cv.closeFiles(files)
+}
+
+// closeFiles flushes and closes the given files.
+func (cv *CSV) closeFiles(files map[string]*csvFile) error {
+	var errs []error
+	for name, file := range files {
+		if err := file.buffer.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush %q: %w", name, err))
+		}
+		if err := file.closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes this exporter.
+// Files are already closed when their bundles end, so this does nothing.
+func (cv *CSV) Close() error {
+	return nil
+}
+
+// delimiters returns the delimiter and quote to use, applying defaults.
+// Neither may be a line break, and both must differ.
+func (cv *CSV) delimiters() (comma, quote rune, err error) {
+	comma = cv.Comma
+	if comma == 0 {
+		comma = ','
+	}
+	quote = cv.Quote
+	if quote == 0 {
+		quote = '"'
+	}
+
+	switch {
+	case comma == '\n' || comma == '\r' || comma == utf8.RuneError:
+		return 0, 0, fmt.Errorf("%w: %q", errCSVDelimiter, comma)
+	case quote == '\n' || quote == '\r' || quote == utf8.RuneError:
+		return 0, 0, fmt.Errorf("%w: %q", errCSVQuote, quote)
+	case comma == quote:
+		return 0, 0, fmt.Errorf("%w: %q is also the delimiter", errCSVQuote, quote)
+	}
+	return comma, quote, nil
+}
+
+// write writes a single record into file.
+func (cv *CSV) write(file *csvFile, record []string) error {
+	comma, quote, err := cv.delimiters()
+	if err != nil {
+		return err
+	}
+
+	for i, field := range record {
+		if i > 0 {
+			file.buffer.WriteRune(comma)
+		}
+
+		if !cv.QuoteAll && !csvNeedsQuotes(field, comma, quote) {
+			file.buffer.WriteString(field)
+			continue
+		}
+
+		// double any quote inside the field
+		file.buffer.WriteRune(quote)
+		for _, r := range field {
+			if r == quote {
+				file.buffer.WriteRune(quote)
+			}
+			file.buffer.WriteRune(r)
+		}
+		file.buffer.WriteRune(quote)
+	}
+
+	if _, err := file.buffer.WriteString("\n"); err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+	return nil
+}
+
+// csvNeedsQuotes checks if field must be quoted.
+func csvNeedsQuotes(field string, comma, quote rune) bool {
+	if field == "" {
+		return false
+	}
+	if field[0] == ' ' || field[0] == '\t' {
+		return true
+	}
+	return strings.ContainsRune(field, comma) || strings.ContainsRune(field, quote) || strings.ContainsAny(field, "\r\n")
+}
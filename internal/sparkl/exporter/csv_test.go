@@ -0,0 +1,156 @@
+//spellchecker:words exporter
+package exporter_test
+
+//spellchecker:words bytes testing github drincw pathbuilder hangover internal sparkl exporter wisski
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+// nopCloser is a buffer that can be closed.
+type nopCloser struct{ *bytes.Buffer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestCSV(t *testing.T) {
+	t.Parallel()
+
+	_, book, _ := newBook(
+		[]pathbuilder.Path{{ID: "title"}, {ID: "keyword"}},
+		[]pathbuilder.Path{{ID: "heading"}},
+	)
+	value := func(values ...string) []wisski.FieldValue { return fieldValues("de", values...) }
+	entities := []wisski.Entity{
+		{
+			URI:    "b1",
+			Fields: map[string][]wisski.FieldValue{"title": value(`Faust; "Der Tragödie"`), "keyword": value("drama", "tragedy")},
+			Children: map[string][]wisski.Entity{"chapter": {
+				{URI: "c1", Fields: map[string][]wisski.FieldValue{"heading": value(" Zueignung")}},
+			}},
+		},
+		{URI: "b2"},
+	}
+
+	tests := []struct {
+		name  string
+		csv   *exporter.CSV
+		files map[string]string
+	}{
+		{
+			name: "default",
+			csv:  &exporter.CSV{Separator: "|"},
+			files: map[string]string{
+				"bundle__book":    "uri,field__title,field__keyword\nb1,\"Faust; \"\"Der Tragödie\"\"\",drama|tragedy\nb2,,\n",
+				"bundle__chapter": "uri,parent,field__heading\nc1,b1,\" Zueignung\"\n",
+			},
+		},
+		{
+			name: "delimiter, quote and bom",
+			csv:  &exporter.CSV{Comma: ';', Quote: '\'', QuoteAll: true, Separator: ",", BOM: true},
+			files: map[string]string{
+				"bundle__book":    "\uFEFF'uri';'field__title';'field__keyword'\n'b1';'Faust; \"Der Tragödie\"';'drama,tragedy'\n'b2';'';''\n",
+				"bundle__chapter": "\uFEFF'uri';'parent';'field__heading'\n'c1';'b1';' Zueignung'\n",
+			},
+		},
+		{
+			name: "field files",
+			csv:  &exporter.CSV{FieldFiles: true},
+			files: map[string]string{
+				"bundle__book":            "uri\nb1\nb2\n",
+				"field__book__title":      "uri,value,lang\nb1,\"Faust; \"\"Der Tragödie\"\"\",de\n",
+				"field__book__keyword":    "uri,value,lang\nb1,drama,de\nb1,tragedy,de\n",
+				"bundle__chapter":         "uri,parent\nc1,b1\n",
+				"field__chapter__heading": "uri,value,lang\nc1,\" Zueignung\",de\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			files := make(map[string]*bytes.Buffer)
+			cv := tt.csv
+			cv.Create = func(name string) (io.WriteCloser, error) {
+				files[name] = new(bytes.Buffer)
+				return nopCloser{files[name]}, nil
+			}
+
+			if err := cv.Begin(book, int64(len(entities))); err != nil {
+				t.Fatal(err)
+			}
+			for i := range entities {
+				if err := cv.Add(book, &entities[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := cv.End(book); err != nil {
+				t.Fatal(err)
+			}
+			if err := cv.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(files) != len(tt.files) {
+				t.Errorf("got %d files, want %d", len(files), len(tt.files))
+			}
+			for name, want := range tt.files {
+				if got := files[name]; got == nil || got.String() != want {
+					t.Errorf("file %q = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCSV_Begin(t *testing.T) {
+	t.Parallel()
+
+	_, book, _ := newBook([]pathbuilder.Path{{ID: "title"}}, nil)
+
+	for _, tt := range []struct {
+		name    string
+		csv     *exporter.CSV
+		wantErr bool
+	}{
+		{"defaults", &exporter.CSV{}, false},
+		{"custom", &exporter.CSV{Comma: '\t', Quote: '\''}, false},
+		{"quote equals default delimiter", &exporter.CSV{Quote: ','}, true},
+		{"delimiter equals default quote", &exporter.CSV{Comma: '"'}, true},
+		{"newline delimiter", &exporter.CSV{Comma: '\n'}, true},
+		{"carriage return delimiter", &exporter.CSV{Comma: '\r'}, true},
+		{"newline quote", &exporter.CSV{Quote: '\n'}, true},
+		{"carriage return quote", &exporter.CSV{Quote: '\r'}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			created := 0
+			cv := tt.csv
+			cv.Create = func(name string) (io.WriteCloser, error) {
+				created++
+				return nopCloser{new(bytes.Buffer)}, nil
+			}
+
+			err := cv.Begin(book, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Begin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && created != 0 {
+				t.Errorf("created %d file(s) for invalid configuration, want none", created)
+			}
+			if err == nil {
+				if err := cv.End(book); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+}
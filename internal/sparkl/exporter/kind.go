@@ -0,0 +1,54 @@
+//spellchecker:words exporter
+package exporter
+
+//spellchecker:words strconv strings github drincw pathbuilder
+import (
+	"strconv"
+	"strings"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+)
+
+// Kind is the kind of values a field holds, as determined by its field type.
+type Kind int
+
+// Kinds of values.
+const (
+	KindString Kind = iota
+	KindInteger
+	KindFloat
+	KindBoolean
+)
+
+// KindOf returns the kind of values held by the given field.
+// Fields of unknown types hold strings.
+func KindOf(field pathbuilder.Field) Kind {
+	switch field.FieldType {
+	case "integer", "list_integer":
+		return KindInteger
+	case "decimal", "float", "list_float":
+		return KindFloat
+	case "boolean":
+		return KindBoolean
+	default:
+		return KindString
+	}
+}
+
+// Parse parses a value of this kind into a string, int64, float64 or bool.
+// Returns false if value is not of this kind.
+func (kind Kind) Parse(value string) (any, bool) {
+	var parsed any
+	var err error
+	switch kind {
+	case KindInteger:
+		parsed, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	case KindFloat:
+		parsed, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+	case KindBoolean:
+		parsed, err = strconv.ParseBool(strings.TrimSpace(value))
+	default:
+		parsed = value
+	}
+	return parsed, err == nil
+}
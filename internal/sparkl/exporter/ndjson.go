@@ -0,0 +1,147 @@
+//spellchecker:words exporter
+package exporter
+
+//spellchecker:words bufio encoding json errors sync github drincw pathbuilder hangover internal wisski
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words ndjson
+
+// NDJSON implements an exporter that writes newline-delimited json.
+// Each line holds a single [NDJSONEntity].
+//
+// Entities are written as soon as they are added, and are never kept in memory.
+// Bundles may be exported concurrently, but lines are never interleaved.
+type NDJSON struct {
+	Writer io.Writer // receives the entities of all bundles, unless Create is set
+
+	// Create, when non-nil, is called to create a separate writer for each bundle.
+	// The writer is closed once all entities of the bundle have been written.
+	Create func(bundle *pathbuilder.Bundle) (io.WriteCloser, error)
+
+	l       sync.Mutex
+	shared  *ndjsonWriter
+	writers map[string]*ndjsonWriter
+}
+
+// NDJSONEntity is a single line written by the [NDJSON] exporter.
+type NDJSONEntity struct {
+	Bundle string // machine name of the bundle of the entity
+	*wisski.Entity
+}
+
+var errNDJSONNoWriter = errors.New("neither Writer nor Create set")
+
+// ndjsonWriter writes lines into an underlying writer.
+type ndjsonWriter struct {
+	l       sync.Mutex
+	closer  io.Closer // nil for the shared writer
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newNDJSONWriter(writer io.Writer, closer io.Closer) *ndjsonWriter {
+	buffer := bufio.NewWriter(writer)
+	return &ndjsonWriter{
+		closer:  closer,
+		buffer:  buffer,
+		encoder: json.NewEncoder(buffer),
+	}
+}
+
+// Begin signals that count entities will be transmitted for the given bundle.
+func (nd *NDJSON) Begin(bundle *pathbuilder.Bundle, count int64) error {
+	nd.l.Lock()
+	defer nd.l.Unlock()
+
+	if nd.writers == nil {
+		nd.writers = make(map[string]*ndjsonWriter)
+	}
+
+	var writer *ndjsonWriter
+	switch {
+	case nd.Create != nil:
+		file, err := nd.Create(bundle)
+		if err != nil {
+			return fmt.Errorf("failed to create writer for bundle %q: %w", bundle.MachineName(), err)
+		}
+		writer = newNDJSONWriter(file, file)
+	case nd.Writer != nil:
+		if nd.shared == nil {
+			nd.shared = newNDJSONWriter(nd.Writer, nil)
+		}
+		writer = nd.shared
+	default:
+		return errNDJSONNoWriter
+	}
+
+	nd.writers[bundle.MachineName()] = writer
+	return nil
+}
+
+// Add adds entities for the given bundle.
+func (nd *NDJSON) Add(bundle *pathbuilder.Bundle, entity *wisski.Entity) error {
+	nd.l.Lock()
+	writer := nd.writers[bundle.MachineName()]
+	nd.l.Unlock()
+
+	writer.l.Lock()
+	defer writer.l.Unlock()
+
+	if err := writer.encoder.Encode(NDJSONEntity{Bundle: bundle.MachineName(), Entity: entity}); err != nil {
+		return fmt.Errorf("failed to encode entity: %w", err)
+	}
+	return nil
+}
+
+// End signals that no more entities will be submitted for the given bundle.
+func (nd *NDJSON) End(bundle *pathbuilder.Bundle) (e error) {
+	nd.l.Lock()
+	writer := nd.writers[bundle.MachineName()]
+	delete(nd.writers, bundle.MachineName())
+	nd.l.Unlock()
+
+	// the shared writer is only flushed once everything is done
+	if writer == nil || writer.closer == nil {
+		return nil
+	}
+
+	defer func() {
+		if e2 := writer.closer.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close writer: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	if err := writer.buffer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	return nil
+}
+
+// Close flushes all entities written to Writer.
+func (nd *NDJSON) Close() error {
+	nd.l.Lock()
+	defer nd.l.Unlock()
+
+	if nd.shared == nil {
+		return nil
+	}
+	if err := nd.shared.buffer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+//spellchecker:words exporter
+package exporter_test
+
+//spellchecker:words bytes encoding json strings testing github drincw pathbuilder hangover internal sparkl exporter triplestore impl wisski
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words ndjson pathbuilder
+
+// decodeNDJSON decodes every line of data into an entity.
+func decodeNDJSON(t *testing.T, data string) []exporter.NDJSONEntity {
+	t.Helper()
+
+	if !strings.HasSuffix(data, "\n") {
+		t.Fatalf("output %q does not end with a newline", data)
+	}
+
+	var entities []exporter.NDJSONEntity
+	for line := range strings.Lines(data) {
+		var entity exporter.NDJSONEntity
+		if err := json.Unmarshal([]byte(line), &entity); err != nil {
+			t.Fatalf("failed to decode line %q: %v", line, err)
+		}
+		entities = append(entities, entity)
+	}
+	return entities
+}
+
+func TestNDJSON(t *testing.T) {
+	t.Parallel()
+
+	pb, book, _ := newBook([]pathbuilder.Path{{ID: "title"}}, []pathbuilder.Path{{ID: "heading"}})
+	author := pb.GetOrCreate("author")
+	author.Path = pathbuilder.Path{ID: "author"}
+
+	books := []wisski.Entity{
+		{
+			URI:    "b1",
+			Fields: map[string][]wisski.FieldValue{"title": fieldValues("de", "Faust")},
+			Children: map[string][]wisski.Entity{"chapter": {
+				{URI: "c1", Fields: map[string][]wisski.FieldValue{"heading": fieldValues("de", "Zueignung")}},
+			}},
+		},
+		{URI: "b2"},
+	}
+	authors := []wisski.Entity{{URI: "a1"}}
+
+	// export writes books and authors into nd
+	export := func(t *testing.T, nd *exporter.NDJSON) {
+		t.Helper()
+
+		for _, bundle := range []struct {
+			bundle   *pathbuilder.Bundle
+			entities []wisski.Entity
+		}{
+			{book, books},
+			{author, authors},
+		} {
+			if err := nd.Begin(bundle.bundle, int64(len(bundle.entities))); err != nil {
+				t.Fatal(err)
+			}
+			for i := range bundle.entities {
+				if err := nd.Add(bundle.bundle, &bundle.entities[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := nd.End(bundle.bundle); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := nd.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// check checks that got holds entities with the given bundle and uris
+	check := func(t *testing.T, got []exporter.NDJSONEntity, bundle string, uris ...impl.Label) {
+		t.Helper()
+
+		if len(got) != len(uris) {
+			t.Fatalf("got %d entities, want %d", len(got), len(uris))
+		}
+		for i, entity := range got {
+			if entity.Bundle != bundle || entity.Entity == nil || entity.URI != uris[i] {
+				t.Errorf("entity %d = %q in bundle %q, want %q in bundle %q", i, entity.URI, entity.Bundle, uris[i], bundle)
+			}
+		}
+	}
+
+	// checkChapters checks that the chapter of b1 is nested within it
+	checkChapters := func(t *testing.T, b1 exporter.NDJSONEntity) {
+		t.Helper()
+
+		chapters := b1.Children["chapter"]
+		if len(chapters) != 1 || chapters[0].URI != "c1" {
+			t.Fatalf("got chapters %v, want only c1", chapters)
+		}
+		if heading := chapters[0].Fields["heading"]; len(heading) != 1 || heading[0].Datum.Value != "Zueignung" {
+			t.Errorf("got heading %v, want Zueignung", heading)
+		}
+	}
+
+	t.Run("shared", func(t *testing.T) {
+		t.Parallel()
+
+		var buffer bytes.Buffer
+		export(t, &exporter.NDJSON{Writer: &buffer})
+
+		got := decodeNDJSON(t, buffer.String())
+		if len(got) != 3 {
+			t.Fatalf("got %d lines, want 3", len(got))
+		}
+		check(t, got[:2], "book", "b1", "b2")
+		check(t, got[2:], "author", "a1")
+		checkChapters(t, got[0])
+	})
+
+	t.Run("split", func(t *testing.T) {
+		t.Parallel()
+
+		files := make(map[string]*bytes.Buffer)
+		export(t, &exporter.NDJSON{
+			Create: func(bundle *pathbuilder.Bundle) (io.WriteCloser, error) {
+				files[bundle.MachineName()] = new(bytes.Buffer)
+				return nopCloser{files[bundle.MachineName()]}, nil
+			},
+		})
+
+		if len(files) != 2 {
+			t.Fatalf("got %d files, want one per bundle", len(files))
+		}
+		lines := decodeNDJSON(t, files["book"].String())
+		check(t, lines, "book", "b1", "b2")
+		checkChapters(t, lines[0])
+		check(t, decodeNDJSON(t, files["author"].String()), "author", "a1")
+	})
+}
@@ -0,0 +1,247 @@
+//spellchecker:words exporter
+package exporter
+
+//spellchecker:words errors slices sync atomic github drincw pathbuilder hangover internal triplestore impl wisski parquet
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	"github.com/parquet-go/parquet-go"
+)
+
+//spellchecker:words snappy
+
+// Parquet implements an exporter that writes one parquet file for every bundle.
+//
+// Like [SQL], each file has a "uri" column, a "parent" column holding the uri of the parent entity for child bundles,
+// and a "field__" column for every field.
+// Field columns are repeated, and typed according to the field type, see [ParquetType].
+// Values that do not fit the type of their column are skipped, see [Parquet.Skipped].
+//
+// Entities are written as soon as they are added.
+type Parquet struct {
+	// Create creates the file for the given bundle.
+	// It is called for every bundle, including child bundles, and the file is closed once the bundle is done.
+	Create func(bundle *pathbuilder.Bundle) (io.WriteCloser, error)
+
+	l       sync.Mutex
+	bundles map[string]*parquetBundle // by top-level bundle
+	skipped atomic.Int64
+}
+
+// ParquetType returns the parquet type used for values of the given field, and a function to convert values to it.
+func ParquetType(field pathbuilder.Field) (parquet.Node, func(value string) (parquet.Value, bool)) {
+	kind := KindOf(field)
+
+	var node parquet.Node
+	switch kind {
+	case KindInteger:
+		node = parquet.Int(64)
+	case KindFloat:
+		node = parquet.Leaf(parquet.DoubleType)
+	case KindBoolean:
+		node = parquet.Leaf(parquet.BooleanType)
+	default:
+		node = parquet.String()
+	}
+
+	return node, func(value string) (parquet.Value, bool) {
+		parsed, ok := kind.Parse(value)
+		if !ok {
+			return parquet.Value{}, false
+		}
+		return parquet.ValueOf(parsed), true
+	}
+}
+
+// parquetBundle writes the file of a single bundle.
+type parquetBundle struct {
+	bundle *pathbuilder.Bundle
+	file   io.WriteCloser
+	writer *parquet.Writer
+
+	columns  []parquetColumn // ordered by column index
+	children []*parquetBundle
+	row      parquet.Row
+}
+
+// parquetColumn is a column of a bundle file.
+type parquetColumn struct {
+	index   int
+	field   string                                   // machine name of the field, empty for the uri and parent columns
+	parent  bool                                     // column holds the parent uri
+	convert func(value string) (parquet.Value, bool) // converts field values
+}
+
+// newParquetBundle creates files for bundle and all of its children.
+// If an error occurs, files created so far are closed.
+func (pq *Parquet) newParquetBundle(bundle *pathbuilder.Bundle) (pb *parquetBundle, e error) {
+	// build the schema
+	group := parquet.Group{uriColumn: parquet.String()}
+	if !bundle.IsToplevel() {
+		group[parentColumn] = parquet.String()
+	}
+	converters := make(map[string]func(string) (parquet.Value, bool), len(bundle.ChildFields))
+	for _, field := range bundle.ChildFields {
+		node, convert := ParquetType(field)
+		group[fieldColumnPrefix+field.MachineName()] = parquet.Repeated(node)
+		converters[field.MachineName()] = convert
+	}
+	schema := parquet.NewSchema(bundle.MachineName(), group)
+
+	pb = &parquetBundle{bundle: bundle}
+	for _, name := range []string{uriColumn, parentColumn} {
+		if leaf, ok := schema.Lookup(name); ok {
+			pb.columns = append(pb.columns, parquetColumn{index: leaf.ColumnIndex, parent: name == parentColumn})
+		}
+	}
+	for _, field := range bundle.ChildFields {
+		leaf, _ := schema.Lookup(fieldColumnPrefix + field.MachineName())
+		pb.columns = append(pb.columns, parquetColumn{index: leaf.ColumnIndex, field: field.MachineName(), convert: converters[field.MachineName()]})
+	}
+	slices.SortFunc(pb.columns, func(a, b parquetColumn) int { return a.index - b.index })
+
+	// create the children first
+	defer func() {
+		if e != nil {
+			e = errors.Join(e, pb.close())
+		}
+	}()
+	for _, child := range bundle.ChildBundles {
+		cb, err := pq.newParquetBundle(child)
+		if err != nil {
+			return pb, err
+		}
+		pb.children = append(pb.children, cb)
+	}
+
+	file, err := pq.Create(bundle)
+	if err != nil {
+		return pb, fmt.Errorf("failed to create file for bundle %q: %w", bundle.MachineName(), err)
+	}
+	pb.file = file
+	pb.writer = parquet.NewWriter(file, schema, parquet.Compression(&parquet.Snappy))
+	return pb, nil
+}
+
+// write writes entity and its children.
+func (pb *parquetBundle) write(entity *wisski.Entity, parent impl.Label, skipped *atomic.Int64) error {
+	row := pb.row[:0]
+	for _, column := range pb.columns {
+		switch {
+		case column.field == "" && column.parent:
+			row = append(row, parquet.ByteArrayValue([]byte(parent)).Level(0, 0, column.index))
+		case column.field == "":
+			row = append(row, parquet.ByteArrayValue([]byte(entity.URI)).Level(0, 0, column.index))
+		default:
+			var count int
+			for _, value := range entity.Fields[column.field] {
+				v, ok := column.convert(value.Datum.Value)
+				if !ok {
+					skipped.Add(1)
+					continue
+				}
+
+				repetition := 1
+				if count == 0 {
+					repetition = 0
+				}
+				row = append(row, v.Level(repetition, 1, column.index))
+				count++
+			}
+			if count == 0 {
+				row = append(row, parquet.NullValue().Level(0, 0, column.index))
+			}
+		}
+	}
+	pb.row = row
+
+	if _, err := pb.writer.WriteRows([]parquet.Row{row}); err != nil {
+		return fmt.Errorf("failed to write row for bundle %q: %w", pb.bundle.MachineName(), err)
+	}
+
+	for _, child := range pb.children {
+		children := entity.Children[child.bundle.MachineName()]
+		for i := range children {
+			if err := child.write(&children[i], entity.URI, skipped); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// close finishes the files of the bundle and its children.
+func (pb *parquetBundle) close() error {
+	var errs []error
+	for _, child := range pb.children {
+		errs = append(errs, child.close())
+	}
+	if pb.writer != nil {
+		if err := pb.writer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to finish file for bundle %q: %w", pb.bundle.MachineName(), err))
+		}
+	}
+	if pb.file != nil {
+		if err := pb.file.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close file for bundle %q: %w", pb.bundle.MachineName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Begin signals that count entities will be transmitted for the given bundle.
+func (pq *Parquet) Begin(bundle *pathbuilder.Bundle, count int64) error {
+	pb, err := pq.newParquetBundle(bundle)
+	if err != nil {
+		return err
+	}
+
+	pq.l.Lock()
+	defer pq.l.Unlock()
+
+	if pq.bundles == nil {
+		pq.bundles = make(map[string]*parquetBundle)
+	}
+	pq.bundles[bundle.MachineName()] = pb
+	return nil
+}
+
+// Add adds entities for the given bundle.
+func (pq *Parquet) Add(bundle *pathbuilder.Bundle, entity *wisski.Entity) error {
+	pq.l.Lock()
+	pb := pq.bundles[bundle.MachineName()]
+	pq.l.Unlock()
+
+	return pb.write(entity, "", &pq.skipped)
+}
+
+// End signals that no more entities will be submitted for the given bundle.
+func (pq *Parquet) End(bundle *pathbuilder.Bundle) error {
+	pq.l.Lock()
+	pb := pq.bundles[bundle.MachineName()]
+	delete(pq.bundles, bundle.MachineName())
+	pq.l.Unlock()
+
+	if pb == nil {
+		return nil
+	}
+	return pb.close()
+}
+
+// Close closes the exporter.
+func (pq *Parquet) Close() error {
+	return nil // no-op
+}
+
+// Skipped returns the number of values that were skipped because they did not fit the type of their column.
+func (pq *Parquet) Skipped() int64 {
+	return pq.skipped.Load()
+}
@@ -0,0 +1,123 @@
+//spellchecker:words exporter
+package exporter_test
+
+//spellchecker:words bytes errors reflect testing github drincw pathbuilder hangover internal sparkl exporter wisski parquet
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	"github.com/parquet-go/parquet-go"
+)
+
+//spellchecker:words pathbuilder urfaust
+
+// parquetBook is a row of the book file.
+type parquetBook struct {
+	URI   string   `parquet:"uri"`
+	Title []string `parquet:"field__title"`
+	Pages []int64  `parquet:"field__pages"`
+}
+
+// parquetChapter is a row of the chapter file.
+type parquetChapter struct {
+	URI     string   `parquet:"uri"`
+	Parent  string   `parquet:"parent"`
+	Heading []string `parquet:"field__heading"`
+}
+
+// readParquet reads all rows of a parquet file.
+func readParquet[T any](t *testing.T, data []byte) []T {
+	t.Helper()
+
+	reader := parquet.NewGenericReader[T](bytes.NewReader(data))
+	defer func() {
+		if err := reader.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	rows := make([]T, reader.NumRows())
+	n, err := reader.Read(rows)
+	if err != nil && !errors.Is(err, io.EOF) {
+		t.Fatal(err)
+	}
+	return rows[:n]
+}
+
+func TestParquet(t *testing.T) {
+	t.Parallel()
+
+	_, book, _ := newBook(
+		[]pathbuilder.Path{{ID: "title"}, {ID: "pages", FieldType: "integer"}},
+		[]pathbuilder.Path{{ID: "heading"}},
+	)
+	value := func(values ...string) []wisski.FieldValue { return fieldValues("", values...) }
+	entities := []wisski.Entity{
+		{
+			URI:    "b1",
+			Fields: map[string][]wisski.FieldValue{"title": value("Faust", "Urfaust"), "pages": value("123")},
+			Children: map[string][]wisski.Entity{"chapter": {
+				{URI: "c1", Fields: map[string][]wisski.FieldValue{"heading": value("Zueignung")}},
+				{URI: "c2"},
+			}},
+		},
+		{URI: "b2", Fields: map[string][]wisski.FieldValue{"pages": value("many", " 42")}},
+		{URI: "b3", Fields: map[string][]wisski.FieldValue{"pages": value("?")}},
+	}
+
+	files := make(map[string]*bytes.Buffer)
+	pq := &exporter.Parquet{
+		Create: func(bundle *pathbuilder.Bundle) (io.WriteCloser, error) {
+			files[bundle.MachineName()] = new(bytes.Buffer)
+			return nopCloser{files[bundle.MachineName()]}, nil
+		},
+	}
+
+	if err := pq.Begin(book, int64(len(entities))); err != nil {
+		t.Fatal(err)
+	}
+	for i := range entities {
+		if err := pq.Add(book, &entities[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pq.End(book); err != nil {
+		t.Fatal(err)
+	}
+	if err := pq.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2", len(files))
+	}
+
+	// empty columns are read back as empty slices
+	wantBooks := []parquetBook{
+		{URI: "b1", Title: []string{"Faust", "Urfaust"}, Pages: []int64{123}},
+		{URI: "b2", Title: []string{}, Pages: []int64{42}},
+		{URI: "b3", Title: []string{}, Pages: []int64{}},
+	}
+	if got := readParquet[parquetBook](t, files["book"].Bytes()); !reflect.DeepEqual(got, wantBooks) {
+		t.Errorf("got books %#v, want %#v", got, wantBooks)
+	}
+
+	wantChapters := []parquetChapter{
+		{URI: "c1", Parent: "b1", Heading: []string{"Zueignung"}},
+		{URI: "c2", Parent: "b1", Heading: []string{}},
+	}
+	if got := readParquet[parquetChapter](t, files["chapter"].Bytes()); !reflect.DeepEqual(got, wantChapters) {
+		t.Errorf("got chapters %#v, want %#v", got, wantChapters)
+	}
+
+	// "many" and "?" are not integers
+	if got := pq.Skipped(); got != 2 {
+		t.Errorf("got %d skipped values, want 2", got)
+	}
+}
@@ -0,0 +1,407 @@
+//spellchecker:words exporter
+package exporter
+
+//spellchecker:words database strings sync atomic github drincw pathbuilder hangover internal triplestore impl wisski huandu sqlbuilder
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	"github.com/huandu/go-sqlbuilder"
+)
+
+//spellchecker:words BIGINT
+
+// Postgres implements an exporter for storing data inside a PostgreSQL database using a typed schema.
+//
+// Each bundle is stored in a table "bundle__<bundle>" with the "uri" of each entity as primary key.
+// Entities of child bundles may belong to several parents, so they are linked to them by a separate table "parent__<bundle>".
+// It has a "uri" column referencing the child table, and an indexed "parent" column referencing the table of the parent bundle.
+//
+// Fields with a cardinality of 1 are stored in a column "field__<field>" of the table of their bundle.
+// Other fields are stored in a separate table "field__<bundle>__<field>" with an indexed "uri" column referencing the bundle table,
+// and "value" and "lang" columns.
+// Column types are determined by the field type, see [PostgresType].
+//
+// Values that do not fit the type of their column, and further values of fields with a cardinality of 1, are skipped.
+// See [Postgres.Skipped].
+//
+// The generated sql is also understood by sqlite.
+type Postgres struct {
+	SkipClose   bool
+	DB          *sql.DB
+	BatchSize   int // number of top-level entities to insert at once
+	MaxQueryVar int // maximum number of query variables per statement
+
+	l       sync.Mutex
+	bundles map[string]*postgresBundle // state by top-level bundle
+	skipped atomic.Int64
+}
+
+// postgresBundle holds the state of a top-level bundle being exported.
+type postgresBundle struct {
+	batch []postgresRow
+	seen  map[string]map[impl.Label]struct{} // uris of inserted entities of child bundles
+}
+
+const parentTablePrefix = "parent__"
+
+// postgresRow is an entity to insert.
+type postgresRow struct {
+	parent impl.Label // uri of the parent entity, if any
+	entity *wisski.Entity
+}
+
+// PostgresType returns the column type used for values of the given field.
+func PostgresType(field pathbuilder.Field) string {
+	switch KindOf(field) {
+	case KindInteger:
+		return "BIGINT"
+	case KindFloat:
+		return "DOUBLE PRECISION"
+	case KindBoolean:
+		return "BOOLEAN"
+	default:
+		return "TEXT"
+	}
+}
+
+func (pg *Postgres) quote(name string) string {
+	return sqlbuilder.PostgreSQL.Quote(name)
+}
+
+func (pg *Postgres) exec(query string, args ...any) error {
+	if _, err := pg.DB.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	return nil
+}
+
+// BundleTable returns the name of the table holding entities of the given bundle.
+func (*Postgres) BundleTable(bundle *pathbuilder.Bundle) string {
+	return bundleTablePrefix + bundle.MachineName()
+}
+
+// ParentTable returns the name of the table linking entities of the given child bundle to their parents.
+func (*Postgres) ParentTable(bundle *pathbuilder.Bundle) string {
+	return parentTablePrefix + bundle.MachineName()
+}
+
+// FieldTable returns the name of the table holding values of the given field of bundle, like [SQL.FieldTable].
+func (*Postgres) FieldTable(bundle *pathbuilder.Bundle, field pathbuilder.Field) string {
+	return fieldTablePrefix + bundle.MachineName() + fieldTableInfix + field.MachineName()
+}
+
+// FieldColumn returns the name of the column holding values of the given field.
+func (*Postgres) FieldColumn(field pathbuilder.Field) string {
+	return fieldColumnPrefix + field.MachineName()
+}
+
+// singleValued checks if the given field is stored in a column of the bundle table.
+func singleValued(field pathbuilder.Field) bool {
+	return field.Cardinality == 1
+}
+
+// dropTables drops the tables of the given bundle, its fields and its children.
+// Tables are dropped before the tables they reference.
+func (pg *Postgres) dropTables(bundle *pathbuilder.Bundle) error {
+	for _, child := range bundle.ChildBundles {
+		if err := pg.dropTables(child); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range bundle.ChildFields {
+		if singleValued(field) {
+			continue
+		}
+		if err := pg.exec("DROP TABLE IF EXISTS " + pg.quote(pg.FieldTable(bundle, field))); err != nil {
+			return err
+		}
+	}
+	if !bundle.IsToplevel() {
+		if err := pg.exec("DROP TABLE IF EXISTS " + pg.quote(pg.ParentTable(bundle))); err != nil {
+			return err
+		}
+	}
+	return pg.exec("DROP TABLE IF EXISTS " + pg.quote(pg.BundleTable(bundle)))
+}
+
+// createTables creates the tables of the given bundle, its fields and its children.
+func (pg *Postgres) createTables(bundle *pathbuilder.Bundle) error {
+	table := pg.BundleTable(bundle)
+
+	create := sqlbuilder.CreateTable(pg.quote(table))
+	create.Define(pg.quote(uriColumn), "TEXT", "PRIMARY KEY")
+	for _, field := range bundle.ChildFields {
+		if singleValued(field) {
+			create.Define(pg.quote(pg.FieldColumn(field)), PostgresType(field))
+		}
+	}
+	if err := pg.exec(create.String()); err != nil {
+		return err
+	}
+	if !bundle.IsToplevel() {
+		parentTable := pg.ParentTable(bundle)
+		create := sqlbuilder.CreateTable(pg.quote(parentTable))
+		create.Define(pg.quote(uriColumn), "TEXT", "NOT NULL", "REFERENCES", pg.quote(table), "("+pg.quote(uriColumn)+")")
+		create.Define(pg.quote(parentColumn), "TEXT", "NOT NULL", "REFERENCES", pg.quote(pg.BundleTable(bundle.Parent)), "("+pg.quote(uriColumn)+")")
+		create.Define("PRIMARY KEY", "("+pg.quote(uriColumn)+", "+pg.quote(parentColumn)+")")
+		if err := pg.exec(create.String()); err != nil {
+			return err
+		}
+		if err := pg.createIndex(parentTable, parentColumn); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range bundle.ChildFields {
+		if singleValued(field) {
+			continue
+		}
+
+		fieldTable := pg.FieldTable(bundle, field)
+		create := sqlbuilder.CreateTable(pg.quote(fieldTable))
+		create.Define(pg.quote(uriColumn), "TEXT", "NOT NULL", "REFERENCES", pg.quote(table), "("+pg.quote(uriColumn)+")")
+		create.Define(pg.quote(valueColumn), PostgresType(field))
+		create.Define(pg.quote(langColumn), "TEXT")
+		if err := pg.exec(create.String()); err != nil {
+			return err
+		}
+		if err := pg.createIndex(fieldTable, uriColumn); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range bundle.ChildBundles {
+		if err := pg.createTables(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createIndex creates an index on the given column of table.
+func (pg *Postgres) createIndex(table, column string) error {
+	return pg.exec("CREATE INDEX " + pg.quote(table+"__"+column) + " ON " + pg.quote(table) + " (" + pg.quote(column) + ")")
+}
+
+// Begin signals that count entities will be transmitted for the given bundle.
+func (pg *Postgres) Begin(bundle *pathbuilder.Bundle, count int64) error {
+	if err := pg.dropTables(bundle); err != nil {
+		return fmt.Errorf("failed to drop tables: %w", err)
+	}
+	if err := pg.createTables(bundle); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	pg.l.Lock()
+	defer pg.l.Unlock()
+
+	if pg.bundles == nil {
+		pg.bundles = make(map[string]*postgresBundle)
+	}
+	pg.bundles[bundle.MachineName()] = &postgresBundle{seen: make(map[string]map[impl.Label]struct{})}
+	return nil
+}
+
+func (pg *Postgres) state(bundle *pathbuilder.Bundle) *postgresBundle {
+	pg.l.Lock()
+	defer pg.l.Unlock()
+
+	return pg.bundles[bundle.MachineName()]
+}
+
+// Add adds entities for the given bundle.
+func (pg *Postgres) Add(bundle *pathbuilder.Bundle, entity *wisski.Entity) error {
+	state := pg.state(bundle)
+
+	state.batch = append(state.batch, postgresRow{entity: entity})
+	if len(state.batch) < pg.BatchSize {
+		return nil
+	}
+
+	batch := state.batch
+	state.batch = nil
+	return pg.insert(state, bundle, batch)
+}
+
+// End signals that no more entities will be submitted for the given bundle.
+func (pg *Postgres) End(bundle *pathbuilder.Bundle) error {
+	state := pg.state(bundle)
+
+	pg.l.Lock()
+	delete(pg.bundles, bundle.MachineName())
+	pg.l.Unlock()
+
+	if state == nil || len(state.batch) == 0 {
+		return nil
+	}
+	return pg.insert(state, bundle, state.batch)
+}
+
+// Close closes the underlying database, unless SkipClose is set.
+func (pg *Postgres) Close() error {
+	if pg.SkipClose {
+		return nil
+	}
+	if err := pg.DB.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+	return nil
+}
+
+// Skipped returns the number of values that were skipped.
+func (pg *Postgres) Skipped() int64 {
+	return pg.skipped.Load()
+}
+
+// insert inserts the given rows into the tables of the given bundle, followed by their children.
+func (pg *Postgres) insert(state *postgresBundle, bundle *pathbuilder.Bundle, rows []postgresRow) error {
+	// entities of child bundles may be reachable from several parents:
+	// every parent is linked, but each entity is only inserted once.
+	var links [][]any
+	if !bundle.IsToplevel() {
+		links = make([][]any, len(rows))
+		for i, row := range rows {
+			links[i] = []any{string(row.entity.URI), string(row.parent)}
+		}
+
+		seen := state.seen[bundle.MachineName()]
+		if seen == nil {
+			seen = make(map[impl.Label]struct{})
+			state.seen[bundle.MachineName()] = seen
+		}
+
+		unique := rows[:0:0]
+		for _, row := range rows {
+			if _, ok := seen[row.entity.URI]; ok {
+				continue
+			}
+			seen[row.entity.URI] = struct{}{}
+			unique = append(unique, row)
+		}
+		rows = unique
+	}
+
+	// values of the bundle table
+	columns := []string{uriColumn}
+	for _, field := range bundle.ChildFields {
+		if singleValued(field) {
+			columns = append(columns, pg.FieldColumn(field))
+		}
+	}
+
+	values := make([][]any, len(rows))
+	for i, row := range rows {
+		values[i] = make([]any, 0, len(columns))
+		values[i] = append(values[i], string(row.entity.URI))
+		for _, field := range bundle.ChildFields {
+			if !singleValued(field) {
+				continue
+			}
+
+			var value any
+			for j, fv := range row.entity.Fields[field.MachineName()] {
+				parsed, ok := KindOf(field).Parse(fv.Datum.Value)
+				if j > 0 || !ok {
+					pg.skipped.Add(1)
+					continue
+				}
+				value = parsed
+			}
+			values[i] = append(values[i], value)
+		}
+	}
+	if err := pg.execInsert(pg.BundleTable(bundle), columns, values); err != nil {
+		return err
+	}
+	if err := pg.execInsert(pg.ParentTable(bundle), []string{uriColumn, parentColumn}, links); err != nil {
+		return err
+	}
+
+	// values of the field tables
+	for _, field := range bundle.ChildFields {
+		if singleValued(field) {
+			continue
+		}
+
+		var values [][]any
+		for _, row := range rows {
+			for _, fv := range row.entity.Fields[field.MachineName()] {
+				parsed, ok := KindOf(field).Parse(fv.Datum.Value)
+				if !ok {
+					pg.skipped.Add(1)
+					continue
+				}
+
+				var lang any
+				if fv.Datum.Language != "" {
+					lang = fv.Datum.Language
+				}
+				values = append(values, []any{string(row.entity.URI), parsed, lang})
+			}
+		}
+		if err := pg.execInsert(pg.FieldTable(bundle, field), []string{uriColumn, valueColumn, langColumn}, values); err != nil {
+			return err
+		}
+	}
+
+	// and finally the children
+	for _, child := range bundle.ChildBundles {
+		var children []postgresRow
+		for _, row := range rows {
+			entities := row.entity.Children[child.MachineName()]
+			for i := range entities {
+				children = append(children, postgresRow{parent: row.entity.URI, entity: &entities[i]})
+			}
+		}
+		if err := pg.insert(state, child, children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execInsert inserts values into the given columns of table.
+// Statements are split to not exceed MaxQueryVar variables.
+func (pg *Postgres) execInsert(table string, columns []string, values [][]any) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	chunkSize := pg.MaxQueryVar / len(columns)
+	if chunkSize == 0 {
+		return errInsufficientQueryVars
+	}
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = pg.quote(column)
+	}
+
+	for len(values) > 0 {
+		chunk := values[:min(chunkSize, len(values))]
+		values = values[len(chunk):]
+
+		insert := sqlbuilder.InsertInto(pg.quote(table))
+		insert.Cols(quoted...)
+		for _, v := range chunk {
+			insert.Values(v...)
+		}
+
+		query, args := insert.BuildWithFlavor(sqlbuilder.PostgreSQL)
+		var builder strings.Builder
+		builder.WriteString(query)
+		builder.WriteString(" ON CONFLICT DO NOTHING")
+		if err := pg.exec(builder.String(), args...); err != nil {
+			return fmt.Errorf("failed to insert into %q: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+//spellchecker:words exporter
+package exporter_test
+
+//spellchecker:words database reflect testing github drincw pathbuilder hangover internal sparkl exporter wisski glebarez sqlite
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	_ "github.com/glebarez/go-sqlite"
+)
+
+//spellchecker:words pathbuilder BIGINT
+
+func TestPostgresType(t *testing.T) {
+	t.Parallel()
+
+	for typ, want := range map[string]string{
+		"integer":      "BIGINT",
+		"list_integer": "BIGINT",
+		"decimal":      "DOUBLE PRECISION",
+		"boolean":      "BOOLEAN",
+		"string":       "TEXT",
+		"":             "TEXT",
+	} {
+		field := pathbuilder.Field{Path: pathbuilder.Path{FieldType: typ}}
+		if got := exporter.PostgresType(field); got != want {
+			t.Errorf("PostgresType(%q) = %q, want %q", typ, got, want)
+		}
+	}
+}
+
+// newPostgresDB opens an in-memory sqlite database standing in for PostgreSQL.
+// It is closed when the test ends.
+func newPostgresDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	db.SetMaxOpenConns(1) // every connection has its own in-memory database
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestPostgres(t *testing.T) {
+	t.Parallel()
+
+	db := newPostgresDB(t)
+
+	_, book, _ := newBook(
+		[]pathbuilder.Path{
+			{ID: "title", FieldType: "string", Cardinality: 1},
+			{ID: "pages", FieldType: "integer", Cardinality: 1},
+			{ID: "keyword", FieldType: "string", Cardinality: -1},
+		},
+		[]pathbuilder.Path{
+			{ID: "number", FieldType: "integer", Cardinality: -1},
+		},
+	)
+	value := func(values ...string) []wisski.FieldValue { return fieldValues("", values...) }
+	shared := wisski.Entity{URI: "c1", Fields: map[string][]wisski.FieldValue{"number": value("1", "one")}}
+	entities := []wisski.Entity{
+		{
+			URI:      "b1",
+			Fields:   map[string][]wisski.FieldValue{"title": value("Faust", "Faust I"), "pages": value("320"), "keyword": value("drama", "tragedy")},
+			Children: map[string][]wisski.Entity{"chapter": {shared, {URI: "c2"}}},
+		},
+		{
+			URI:      "b2",
+			Fields:   map[string][]wisski.FieldValue{"title": value("Werther"), "pages": value("many")},
+			Children: map[string][]wisski.Entity{"chapter": {shared}},
+		},
+	}
+
+	pg := &exporter.Postgres{DB: db, SkipClose: true, BatchSize: 1, MaxQueryVar: 4}
+	for range 2 { // exporting twice replaces the tables
+		if err := pg.Begin(book, int64(len(entities))); err != nil {
+			t.Fatal(err)
+		}
+		for i := range entities {
+			if err := pg.Add(book, &entities[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := pg.End(book); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pg.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// "Faust I", "many" and "one" are skipped in each export
+	if got := pg.Skipped(); got != 6 {
+		t.Errorf("Skipped() = %d, want 6", got)
+	}
+
+	for _, tt := range []struct {
+		query string
+		want  [][]any
+	}{
+		{
+			`SELECT "uri", "field__title", "field__pages" FROM "bundle__book" ORDER BY "uri"`,
+			[][]any{{"b1", "Faust", int64(320)}, {"b2", "Werther", nil}},
+		},
+		{
+			`SELECT "uri", "value", "lang" FROM "field__book__keyword" ORDER BY "value"`,
+			[][]any{{"b1", "drama", nil}, {"b1", "tragedy", nil}},
+		},
+		{
+			`SELECT "uri" FROM "bundle__chapter" ORDER BY "uri"`,
+			[][]any{{"c1"}, {"c2"}},
+		},
+		{
+			`SELECT "uri", "parent" FROM "parent__chapter" ORDER BY "uri", "parent"`,
+			[][]any{{"c1", "b1"}, {"c1", "b2"}, {"c2", "b1"}},
+		},
+		{
+			`SELECT "uri", "value" FROM "field__chapter__number"`,
+			[][]any{{"c1", int64(1)}},
+		},
+		{
+			`SELECT "name" FROM sqlite_master WHERE "type" = 'index' AND "sql" IS NOT NULL ORDER BY "name"`,
+			[][]any{{"field__book__keyword__uri"}, {"field__chapter__number__uri"}, {"parent__chapter__parent"}},
+		},
+		{
+			`SELECT "table", "from", "to" FROM pragma_foreign_key_list('parent__chapter') ORDER BY "from"`,
+			[][]any{{"bundle__book", "parent", "uri"}, {"bundle__chapter", "uri", "uri"}},
+		},
+		{
+			`SELECT "name", "type", "pk" FROM pragma_table_info('bundle__book') ORDER BY "cid"`,
+			[][]any{{"uri", "TEXT", int64(1)}, {"field__title", "TEXT", int64(0)}, {"field__pages", "BIGINT", int64(0)}},
+		},
+	} {
+		if got := queryAll(t, db, tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// foreign keys are enforced
+	if _, err := db.Exec(`INSERT INTO "parent__chapter" ("uri", "parent") VALUES ('c1', 'b3')`); err == nil {
+		t.Error("inserting a chapter of a missing book succeeded")
+	}
+}
+
+func TestPostgres_FieldTables(t *testing.T) {
+	t.Parallel()
+
+	db := newPostgresDB(t)
+
+	// every bundle has a field named "name"
+	name := []pathbuilder.Path{{ID: "name", Cardinality: -1}}
+	pb, book, _ := newBook(name, name)
+	author := pb.GetOrCreate("author")
+	author.Path = pathbuilder.Path{ID: "author"}
+	author.ChildFields = []pathbuilder.Field{{Path: name[0]}}
+
+	value := func(values ...string) []wisski.FieldValue { return fieldValues("", values...) }
+	pg := &exporter.Postgres{DB: db, SkipClose: true, BatchSize: 1, MaxQueryVar: 100}
+	for _, bundle := range []struct {
+		bundle *pathbuilder.Bundle
+		entity wisski.Entity
+	}{
+		{book, wisski.Entity{
+			URI:      "b1",
+			Fields:   map[string][]wisski.FieldValue{"name": value("Faust")},
+			Children: map[string][]wisski.Entity{"chapter": {{URI: "c1", Fields: map[string][]wisski.FieldValue{"name": value("Zueignung")}}}},
+		}},
+		{author, wisski.Entity{URI: "a1", Fields: map[string][]wisski.FieldValue{"name": value("Goethe")}}},
+	} {
+		if err := pg.Begin(bundle.bundle, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := pg.Add(bundle.bundle, &bundle.entity); err != nil {
+			t.Fatal(err)
+		}
+		if err := pg.End(bundle.bundle); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for table, want := range map[string][][]any{
+		"field__book__name":    {{"b1", "Faust"}},
+		"field__chapter__name": {{"c1", "Zueignung"}},
+		"field__author__name":  {{"a1", "Goethe"}},
+	} {
+		query := `SELECT "uri", "value" FROM "` + table + `"`
+		if got := queryAll(t, db, query); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %v, want %v", query, got, want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+//spellchecker:words exporter
+package exporter
+
+//spellchecker:words bufio crypto sha256 encoding binary errors hash sync github drincw pathbuilder hangover internal triplestore igraph impl wisski anglo korean
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	"github.com/anglo-korean/rdf"
+)
+
+//spellchecker:words nquads ntriples sameas
+
+// RDFFormat is a serialization format written by the [RDF] exporter.
+type RDFFormat string
+
+// Supported rdf formats.
+const (
+	// RDFNQuads writes N-Quads, keeping the graph of every triple.
+	RDFNQuads RDFFormat = "nquads"
+
+	// RDFNTriples writes N-Triples, dropping the graph of every triple.
+	RDFNTriples RDFFormat = "ntriples"
+
+	// RDFTurtle writes turtle, dropping the graph of every triple.
+	RDFTurtle RDFFormat = "turtle"
+)
+
+// RDFFormats are all supported rdf formats.
+var RDFFormats = []RDFFormat{RDFNQuads, RDFNTriples, RDFTurtle}
+
+// Valid checks if this is a supported rdf format.
+func (format RDFFormat) Valid() bool {
+	return format == RDFNQuads || format == RDFNTriples || format == RDFTurtle
+}
+
+// RDF implements an exporter that writes a dump of all triples that make up the exported entities.
+// These are the triples returned by [wisski.Entity.AllTriples], that is all triples reachable via pathbuilder paths.
+//
+// Each triple is written only once, even if it belongs to several entities.
+// Inferred inverse triples are written as the triple they were inferred from.
+type RDF struct {
+	Writer io.Writer
+	Format RDFFormat
+
+	// Canonical writes subjects and objects normalized via sameAs.
+	// Predicates of inferred inverse triples are not normalized.
+	Canonical bool
+
+	l       sync.Mutex
+	buffer  *bufio.Writer                  // used for nquads
+	encoder *rdf.TripleEncoder             // used for all other formats
+	seen    map[[sha256.Size]byte]struct{} // hashes of the keys of triples already written
+	hash    hash.Hash                      // used to compute the hashes in seen
+	written int64
+}
+
+// rdfKey identifies a triple in the output.
+type rdfKey struct {
+	subject, predicate, object impl.Label
+	datum                      impl.Datum
+	graph                      impl.Label
+}
+
+// sum returns the SHA-256 hash of key, using digest.
+// Only the hash is kept for every written triple, so that memory use does not depend on the size of the triples.
+func (key rdfKey) sum(digest hash.Hash) (sum [sha256.Size]byte) {
+	digest.Reset()
+	for _, part := range []string{string(key.subject), string(key.predicate), string(key.object), key.datum.Value, key.datum.Language, string(key.graph)} {
+		// prefix every part with its length, so that different keys never produce the same input
+		_, _ = digest.Write(binary.AppendUvarint(nil, uint64(len(part)))) // never fails
+		_, _ = digest.Write([]byte(part))                                 // never fails
+	}
+	digest.Sum(sum[:0])
+	return sum
+}
+
+var (
+	errRDFNoWriter = errors.New("Writer not set")
+	errRDFFormat   = errors.New("unsupported rdf format")
+)
+
+// Begin signals that count entities will be transmitted for the given bundle.
+func (dump *RDF) Begin(bundle *pathbuilder.Bundle, count int64) error {
+	switch {
+	case dump.Writer == nil:
+		return errRDFNoWriter
+	case !dump.Format.Valid():
+		return fmt.Errorf("%w: %q", errRDFFormat, dump.Format)
+	}
+
+	dump.l.Lock()
+	defer dump.l.Unlock()
+
+	if dump.seen != nil {
+		return nil
+	}
+	dump.seen = make(map[[sha256.Size]byte]struct{})
+	dump.hash = sha256.New()
+
+	switch dump.Format {
+	case RDFNQuads:
+		dump.buffer = bufio.NewWriter(dump.Writer)
+	case RDFNTriples:
+		dump.encoder = rdf.NewTripleEncoder(dump.Writer, rdf.NTriples)
+	case RDFTurtle:
+		dump.encoder = rdf.NewTripleEncoder(dump.Writer, rdf.Turtle)
+	}
+	return nil
+}
+
+// Add adds entities for the given bundle.
+func (dump *RDF) Add(bundle *pathbuilder.Bundle, entity *wisski.Entity) error {
+	triples := entity.AllTriples()
+
+	dump.l.Lock()
+	defer dump.l.Unlock()
+
+	for _, triple := range triples {
+		if triple.Role == igraph.Inverse {
+			// the literal triple is the one found in the data, but the canonical one is inferred.
+			triple.SSubject, triple.SPredicate, triple.SObject = triple.SObject, triple.Predicate, triple.SSubject
+		}
+
+		key := rdfKey{subject: triple.Subject, predicate: triple.Predicate, object: triple.Object, datum: triple.Datum, graph: triple.Source.Graph}
+		if dump.Canonical {
+			key.subject, key.predicate, key.object = triple.SSubject, triple.SPredicate, triple.SObject
+		}
+		if triple.Role == igraph.Data {
+			key.object = ""
+		} else {
+			key.datum = impl.Datum{}
+		}
+
+		sum := key.sum(dump.hash)
+		if _, ok := dump.seen[sum]; ok {
+			continue
+		}
+		dump.seen[sum] = struct{}{}
+
+		if err := dump.write(triple, key.graph); err != nil {
+			return fmt.Errorf("failed to write triple %q %q %q: %w", key.subject, key.predicate, key.object, err)
+		}
+		dump.written++
+	}
+	return nil
+}
+
+// write writes a single triple in the given graph.
+func (dump *RDF) write(triple igraph.Triple, graph impl.Label) error {
+	spo, err := triple.Triple(dump.Canonical)
+	if err != nil {
+		return err
+	}
+
+	if dump.encoder != nil {
+		return dump.encoder.Encode(spo)
+	}
+
+	// triples in the default graph have no context
+	line := spo.Serialize(rdf.NQuads)
+	if graph != "" {
+		context, err := rdf.NewIRI(string(graph))
+		if err != nil {
+			return fmt.Errorf("failed to create IRI for graph: %w", err)
+		}
+		line = rdf.Quad{Triple: spo, Ctx: context}.Serialize(rdf.NQuads)
+	}
+
+	if _, err := dump.buffer.WriteString(line); err != nil {
+		return fmt.Errorf("failed to write quad: %w", err)
+	}
+	return nil
+}
+
+// End signals that no more entities will be submitted for the given bundle.
+func (dump *RDF) End(bundle *pathbuilder.Bundle) error {
+	return nil
+}
+
+// Close finishes and flushes the dump.
+func (dump *RDF) Close() error {
+	dump.l.Lock()
+	defer dump.l.Unlock()
+
+	switch {
+	case dump.encoder != nil:
+		if err := dump.encoder.Close(); err != nil {
+			return fmt.Errorf("failed to close triple encoder: %w", err)
+		}
+		dump.encoder = nil
+	case dump.buffer != nil:
+		if err := dump.buffer.Flush(); err != nil {
+			return fmt.Errorf("failed to flush writer: %w", err)
+		}
+	}
+	return nil
+}
+
+// Written returns the number of triples that were written.
+func (dump *RDF) Written() int64 {
+	dump.l.Lock()
+	defer dump.l.Unlock()
+
+	return dump.written
+}
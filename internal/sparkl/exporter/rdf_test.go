@@ -0,0 +1,137 @@
+//spellchecker:words exporter
+package exporter_test
+
+//spellchecker:words bytes math testing github drincw pathbuilder hangover internal sparkl exporter triplestore igraph impl wisski
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder nquads ntriples
+
+func TestRDF(t *testing.T) {
+	t.Parallel()
+
+	_, book, _ := newBook([]pathbuilder.Path{{ID: "title"}}, nil)
+
+	// triple creates a triple with the given literal and canonical labels
+	triple := func(id int64, literal, canonical [3]impl.Label, datum impl.Datum, graph impl.Label, role igraph.Role) igraph.Triple {
+		var tid impl.ID
+		tid.LoadInt(big.NewInt(id))
+		return igraph.Triple{
+			ID:         tid,
+			Subject:    literal[0],
+			Predicate:  literal[1],
+			Object:     literal[2],
+			SSubject:   canonical[0],
+			SPredicate: canonical[1],
+			SObject:    canonical[2],
+			Datum:      datum,
+			Source:     impl.Source{Graph: graph},
+			Role:       role,
+		}
+	}
+
+	const graph = "http://example.com/graph"
+	typ := [3]impl.Label{"http://example.com/b1", wisski.Type, "http://example.com/Book"}
+	title := [3]impl.Label{"http://example.com/b1", "http://example.com/hasTitle", ""}
+
+	bookType := triple(1, typ, typ, impl.Datum{}, graph, igraph.Regular)
+	bookTitle := triple(2, title, title, impl.Datum{Value: "Faust", Language: "de"}, graph, igraph.Data)
+	wrote := triple(3,
+		[3]impl.Label{"http://example.com/a1", "http://example.com/wrote", "http://example.com/b1"},
+		[3]impl.Label{"http://example.com/b1", "http://example.com/hasAuthor", "http://example.com/goethe"},
+		impl.Datum{}, graph, igraph.Inverse,
+	)
+	alias := triple(4,
+		[3]impl.Label{"http://example.com/b1-alias", wisski.Type, "http://example.com/Book"},
+		typ,
+		impl.Datum{}, graph, igraph.Regular,
+	)
+	unnamed := triple(5,
+		[3]impl.Label{"http://example.com/b2", "http://example.com/hasTitle", ""},
+		[3]impl.Label{"http://example.com/b2", "http://example.com/hasTitle", ""},
+		impl.Datum{Value: "Prolog", Language: "en"}, "", igraph.Data,
+	)
+
+	entities := []wisski.Entity{
+		{
+			URI:     "http://example.com/b1",
+			Triples: []igraph.Triple{bookType},
+			Fields: map[string][]wisski.FieldValue{
+				"title": {{Datum: bookTitle.Datum, Triples: []igraph.Triple{wrote, bookTitle}}},
+			},
+		},
+		{
+			URI:     "http://example.com/b2",
+			Triples: []igraph.Triple{bookType, alias, unnamed}, // shared triples are only written once
+		},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		format    exporter.RDFFormat
+		canonical bool
+		want      string
+	}{
+		{
+			name:   "nquads",
+			format: exporter.RDFNQuads,
+			want: "<http://example.com/b1> <" + string(wisski.Type) + "> <http://example.com/Book> <" + graph + "> .\n" +
+				"<http://example.com/b1> <http://example.com/hasTitle> \"Faust\"@de <" + graph + "> .\n" +
+				"<http://example.com/a1> <http://example.com/wrote> <http://example.com/b1> <" + graph + "> .\n" +
+				"<http://example.com/b1-alias> <" + string(wisski.Type) + "> <http://example.com/Book> <" + graph + "> .\n" +
+				"<http://example.com/b2> <http://example.com/hasTitle> \"Prolog\"@en .\n",
+		},
+		{
+			name:      "canonical nquads",
+			format:    exporter.RDFNQuads,
+			canonical: true,
+			want: "<http://example.com/b1> <" + string(wisski.Type) + "> <http://example.com/Book> <" + graph + "> .\n" +
+				"<http://example.com/b1> <http://example.com/hasTitle> \"Faust\"@de <" + graph + "> .\n" +
+				"<http://example.com/goethe> <http://example.com/wrote> <http://example.com/b1> <" + graph + "> .\n" +
+				"<http://example.com/b2> <http://example.com/hasTitle> \"Prolog\"@en .\n",
+		},
+		{
+			name:   "ntriples",
+			format: exporter.RDFNTriples,
+			want: "<http://example.com/b1> <" + string(wisski.Type) + "> <http://example.com/Book> .\n" +
+				"<http://example.com/b1> <http://example.com/hasTitle> \"Faust\"@de .\n" +
+				"<http://example.com/a1> <http://example.com/wrote> <http://example.com/b1> .\n" +
+				"<http://example.com/b1-alias> <" + string(wisski.Type) + "> <http://example.com/Book> .\n" +
+				"<http://example.com/b2> <http://example.com/hasTitle> \"Prolog\"@en .\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buffer bytes.Buffer
+			dump := &exporter.RDF{Writer: &buffer, Format: tt.format, Canonical: tt.canonical}
+			if err := dump.Begin(book, int64(len(entities))); err != nil {
+				t.Fatal(err)
+			}
+			for i := range entities {
+				if err := dump.Add(book, &entities[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := dump.End(book); err != nil {
+				t.Fatal(err)
+			}
+			if err := dump.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := buffer.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+//spellchecker:words exporter
+package exporter
+
+//spellchecker:words bufio crypto sha256 encoding json errors sync atomic github drincw pathbuilder hangover internal overview wisski
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/overview"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words opensearch meilisearch ndjson
+
+// SearchFormat is a bulk file format understood by a search engine.
+type SearchFormat string
+
+// Supported search formats.
+const (
+	// SearchOpenSearch is the newline-delimited json accepted by the OpenSearch (and Elasticsearch) "_bulk" endpoint.
+	SearchOpenSearch SearchFormat = "opensearch"
+
+	// SearchMeilisearch is a json array of documents accepted by the Meilisearch "documents" endpoint.
+	SearchMeilisearch SearchFormat = "meilisearch"
+)
+
+// SearchFormats are all supported search formats.
+var SearchFormats = []SearchFormat{SearchOpenSearch, SearchMeilisearch}
+
+// Valid checks if this is a supported search format.
+func (format SearchFormat) Valid() bool {
+	return format == SearchOpenSearch || format == SearchMeilisearch
+}
+
+// Attributes of every search document.
+const (
+	SearchID     = "id"     // hash of the uri, see [SearchDocumentID]
+	SearchURI    = "uri"    // uri of the entity
+	SearchBundle = "bundle" // machine name of the bundle of the entity
+	SearchTitle  = "title"  // title of the entity, as shown by the viewer
+)
+
+// Search implements an exporter that writes search engine bulk files.
+//
+// Every top-level entity becomes a single document with the attributes above.
+// Values of each field, including fields of child bundles, are flattened into an array under "field__name".
+// Integer, decimal and boolean fields hold typed values, values that do not fit the type of their field are skipped.
+// See [SearchMapping] for a matching index definition.
+type Search struct {
+	Writer io.Writer
+	Format SearchFormat
+	Index  string // name of the OpenSearch index to write into, ignored for Meilisearch
+
+	l       sync.Mutex
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+	written int64 // number of documents written
+	skipped atomic.Int64
+}
+
+var (
+	errSearchNoWriter = errors.New("Writer not set")
+	errSearchFormat   = errors.New("unsupported search format")
+	errSearchNoIndex  = errors.New("Index not set")
+)
+
+// SearchDocumentID returns the id of the search document for the given uri.
+// Meilisearch only allows alphanumeric ids, so it is the hex-encoded SHA-256 hash of the uri.
+func SearchDocumentID(uri string) string {
+	sum := sha256.Sum256([]byte(uri))
+	return hex.EncodeToString(sum[:])
+}
+
+// SearchAttribute returns the name of the attribute holding values of the given field.
+func SearchAttribute(field pathbuilder.Field) string {
+	return fieldColumnPrefix + field.MachineName()
+}
+
+// Begin signals that count entities will be transmitted for the given bundle.
+func (se *Search) Begin(bundle *pathbuilder.Bundle, count int64) error {
+	switch {
+	case se.Writer == nil:
+		return errSearchNoWriter
+	case !se.Format.Valid():
+		return fmt.Errorf("%w: %q", errSearchFormat, se.Format)
+	case se.Format == SearchOpenSearch && se.Index == "":
+		return errSearchNoIndex
+	}
+
+	se.l.Lock()
+	defer se.l.Unlock()
+
+	if se.buffer == nil {
+		se.buffer = bufio.NewWriter(se.Writer)
+		se.encoder = json.NewEncoder(se.buffer)
+	}
+	return nil
+}
+
+// Add adds entities for the given bundle.
+func (se *Search) Add(bundle *pathbuilder.Bundle, entity *wisski.Entity) error {
+	document := map[string]any{
+		SearchID:     SearchDocumentID(string(entity.URI)),
+		SearchURI:    string(entity.URI),
+		SearchBundle: bundle.MachineName(),
+		SearchTitle:  overview.Title(bundle.Fields(), entity),
+	}
+	se.flatten(document, bundle, entity)
+
+	se.l.Lock()
+	defer se.l.Unlock()
+
+	switch se.Format {
+	case SearchOpenSearch:
+		// an action line, followed by the document
+		if err := se.encoder.Encode(map[string]any{
+			"index": map[string]string{"_index": se.Index, "_id": document[SearchID].(string)},
+		}); err != nil {
+			return fmt.Errorf("failed to encode action: %w", err)
+		}
+		if err := se.encoder.Encode(document); err != nil {
+			return fmt.Errorf("failed to encode document: %w", err)
+		}
+	case SearchMeilisearch:
+		// one element of an array per line
+		encoded, err := json.Marshal(document)
+		if err != nil {
+			return fmt.Errorf("failed to encode document: %w", err)
+		}
+		separator := ",\n"
+		if se.written == 0 {
+			separator = "[\n"
+		}
+		if _, err := se.buffer.WriteString(separator); err != nil {
+			return fmt.Errorf("failed to write separator: %w", err)
+		}
+		if _, err := se.buffer.Write(encoded); err != nil {
+			return fmt.Errorf("failed to write document: %w", err)
+		}
+	}
+	se.written++
+	return nil
+}
+
+// flatten adds the values of all fields of entity and its children to document.
+func (se *Search) flatten(document map[string]any, bundle *pathbuilder.Bundle, entity *wisski.Entity) {
+	for _, field := range bundle.ChildFields {
+		kind := KindOf(field)
+		attribute := SearchAttribute(field)
+
+		for _, value := range entity.Fields[field.MachineName()] {
+			parsed, ok := kind.Parse(value.Datum.Value)
+			if !ok {
+				se.skipped.Add(1)
+				continue
+			}
+
+			values, _ := document[attribute].([]any)
+			document[attribute] = append(values, parsed)
+		}
+	}
+
+	for _, child := range bundle.ChildBundles {
+		children := entity.Children[child.MachineName()]
+		for i := range children {
+			se.flatten(document, child, &children[i])
+		}
+	}
+}
+
+// End signals that no more entities will be submitted for the given bundle.
+func (se *Search) End(bundle *pathbuilder.Bundle) error {
+	return nil
+}
+
+// Close finishes and flushes the bulk file.
+func (se *Search) Close() error {
+	se.l.Lock()
+	defer se.l.Unlock()
+
+	if se.buffer == nil {
+		return nil
+	}
+
+	if se.Format == SearchMeilisearch {
+		closing := "\n]\n"
+		if se.written == 0 {
+			closing = "[]\n"
+		}
+		if _, err := se.buffer.WriteString(closing); err != nil {
+			return fmt.Errorf("failed to write end of documents: %w", err)
+		}
+	}
+	if err := se.buffer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	return nil
+}
+
+// Skipped returns the number of values that were skipped.
+func (se *Search) Skipped() int64 {
+	return se.skipped.Load()
+}
+
+// SearchMapping returns the index definition matching the documents written for the given pathbuilder.
+//
+// For OpenSearch, this is the body of a request creating the index, holding the type of every attribute.
+// For Meilisearch, this is the body of a request updating the index settings,
+// making text attributes searchable, typed attributes filterable, and numeric attributes sortable.
+func SearchMapping(pb *pathbuilder.Pathbuilder, format SearchFormat) (any, error) {
+	// find all fields that may occur in documents
+	var fields []pathbuilder.Field
+	seen := make(map[string]struct{})
+	for _, bundle := range pb.Bundles() {
+		for _, field := range bundle.AllFields() {
+			if _, ok := seen[field.MachineName()]; ok {
+				continue
+			}
+			seen[field.MachineName()] = struct{}{}
+			fields = append(fields, field)
+		}
+	}
+
+	switch format {
+	case SearchOpenSearch:
+		return openSearchMapping(fields), nil
+	case SearchMeilisearch:
+		return meilisearchSettings(fields), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errSearchFormat, format)
+	}
+}
+
+func openSearchMapping(fields []pathbuilder.Field) any {
+	type property map[string]any
+
+	keyword := property{"type": "keyword"}
+	text := property{
+		"type":   "text",
+		"fields": map[string]property{"keyword": {"type": "keyword", "ignore_above": 256}},
+	}
+
+	properties := map[string]property{
+		SearchID:     keyword,
+		SearchURI:    keyword,
+		SearchBundle: keyword,
+		SearchTitle:  text,
+	}
+	for _, field := range fields {
+		switch KindOf(field) {
+		case KindInteger:
+			properties[SearchAttribute(field)] = property{"type": "long"}
+		case KindFloat:
+			properties[SearchAttribute(field)] = property{"type": "double"}
+		case KindBoolean:
+			properties[SearchAttribute(field)] = property{"type": "boolean"}
+		default:
+			properties[SearchAttribute(field)] = text
+		}
+	}
+
+	return map[string]any{
+		"mappings": map[string]any{
+			"dynamic":    false,
+			"properties": properties,
+		},
+	}
+}
+
+// meilisearchSettingsBody holds the Meilisearch index settings.
+type meilisearchSettingsBody struct {
+	SearchableAttributes []string `json:"searchableAttributes"`
+	FilterableAttributes []string `json:"filterableAttributes"`
+	SortableAttributes   []string `json:"sortableAttributes"`
+}
+
+func meilisearchSettings(fields []pathbuilder.Field) any {
+	settings := meilisearchSettingsBody{
+		SearchableAttributes: []string{SearchTitle},
+		FilterableAttributes: []string{SearchBundle, SearchURI},
+		SortableAttributes:   []string{SearchTitle},
+	}
+	for _, field := range fields {
+		attribute := SearchAttribute(field)
+		switch KindOf(field) {
+		case KindString:
+			settings.SearchableAttributes = append(settings.SearchableAttributes, attribute)
+		case KindBoolean:
+			settings.FilterableAttributes = append(settings.FilterableAttributes, attribute)
+		default:
+			settings.FilterableAttributes = append(settings.FilterableAttributes, attribute)
+			settings.SortableAttributes = append(settings.SortableAttributes, attribute)
+		}
+	}
+	settings.SearchableAttributes = append(settings.SearchableAttributes, SearchURI)
+	return settings
+}
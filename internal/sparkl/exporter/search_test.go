@@ -0,0 +1,152 @@
+//spellchecker:words exporter
+package exporter_test
+
+//spellchecker:words bytes encoding json reflect strings testing github drincw pathbuilder hangover internal sparkl exporter wisski
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder opensearch meilisearch
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	pb, book, _ := newBook(
+		[]pathbuilder.Path{{ID: "title", FieldType: "string"}, {ID: "pages", FieldType: "integer"}},
+		[]pathbuilder.Path{{ID: "heading", FieldType: "string"}},
+	)
+	value := func(values ...string) []wisski.FieldValue { return fieldValues("", values...) }
+	entities := []wisski.Entity{
+		{
+			URI:    "http://example.com/b1",
+			Fields: map[string][]wisski.FieldValue{"title": value("Faust"), "pages": value("320", "many")},
+			Children: map[string][]wisski.Entity{"chapter": {
+				{URI: "http://example.com/c1", Fields: map[string][]wisski.FieldValue{"heading": value("Zueignung")}},
+				{URI: "http://example.com/c2", Fields: map[string][]wisski.FieldValue{"heading": value("Prolog")}},
+			}},
+		},
+		{URI: "http://example.com/b2"},
+	}
+
+	b1 := map[string]any{
+		"id":             exporter.SearchDocumentID("http://example.com/b1"),
+		"uri":            "http://example.com/b1",
+		"bundle":         "book",
+		"title":          "Faust",
+		"field__title":   []any{"Faust"},
+		"field__pages":   []any{float64(320)},
+		"field__heading": []any{"Zueignung", "Prolog"},
+	}
+	b2 := map[string]any{
+		"id":     exporter.SearchDocumentID("http://example.com/b2"),
+		"uri":    "http://example.com/b2",
+		"bundle": "book",
+		"title":  "http://example.com/b2",
+	}
+	action := func(document map[string]any) map[string]any {
+		return map[string]any{"index": map[string]any{"_index": "archive", "_id": document["id"]}}
+	}
+
+	export := func(format exporter.SearchFormat) (string, int64) {
+		t.Helper()
+
+		var buffer bytes.Buffer
+		se := &exporter.Search{Writer: &buffer, Format: format, Index: "archive"}
+		if err := se.Begin(book, int64(len(entities))); err != nil {
+			t.Fatal(err)
+		}
+		for i := range entities {
+			if err := se.Add(book, &entities[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := se.End(book); err != nil {
+			t.Fatal(err)
+		}
+		if err := se.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buffer.String(), se.Skipped()
+	}
+
+	t.Run("opensearch", func(t *testing.T) {
+		t.Parallel()
+
+		got, skipped := export(exporter.SearchOpenSearch)
+		if skipped != 1 {
+			t.Errorf("Skipped() = %d, want 1", skipped)
+		}
+		if !strings.HasSuffix(got, "\n") {
+			t.Error("bulk file does not end with a newline")
+		}
+
+		var lines []map[string]any
+		for _, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n") {
+			var decoded map[string]any
+			if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+				t.Fatalf("invalid line %q: %v", line, err)
+			}
+			lines = append(lines, decoded)
+		}
+		if want := []map[string]any{action(b1), b1, action(b2), b2}; !reflect.DeepEqual(lines, want) {
+			t.Errorf("lines = %v, want %v", lines, want)
+		}
+	})
+
+	t.Run("meilisearch", func(t *testing.T) {
+		t.Parallel()
+
+		got, _ := export(exporter.SearchMeilisearch)
+
+		var documents []map[string]any
+		if err := json.Unmarshal([]byte(got), &documents); err != nil {
+			t.Fatalf("invalid documents %q: %v", got, err)
+		}
+		if want := []map[string]any{b1, b2}; !reflect.DeepEqual(documents, want) {
+			t.Errorf("documents = %v, want %v", documents, want)
+		}
+	})
+
+	t.Run("mapping", func(t *testing.T) {
+		t.Parallel()
+
+		settings, err := exporter.SearchMapping(&pb, exporter.SearchMeilisearch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := json.Marshal(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"searchableAttributes":["title","field__title","field__heading","uri"],"filterableAttributes":["bundle","uri","field__pages"],"sortableAttributes":["title","field__pages"]}`
+		if string(encoded) != want {
+			t.Errorf("SearchMapping(meilisearch) = %s, want %s", encoded, want)
+		}
+
+		mapping, err := exporter.SearchMapping(&pb, exporter.SearchOpenSearch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err = json.Marshal(mapping)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, property := range []string{`"field__pages":{"type":"long"}`, `"field__heading":{"fields":{"keyword":`, `"uri":{"type":"keyword"}`} {
+			if !strings.Contains(string(encoded), property) {
+				t.Errorf("SearchMapping(opensearch) = %s, want it to contain %s", encoded, property)
+			}
+		}
+
+		if _, err := exporter.SearchMapping(&pb, "solr"); err == nil {
+			t.Error("SearchMapping(solr) did not fail")
+		}
+	})
+}
@@ -18,6 +18,7 @@ import (
 //spellchecker:words twiesing
 
 // SQL implements an exporter for storing data inside an sql database.
+// When ExportTriples is set, it also stores provenance in [TriplesTable] and [FieldValueTriplesTable].
 // TODO(twiesing): For now this only supports string-like fields.
 type SQL struct {
 	SkipClose       bool
@@ -29,6 +30,10 @@ type SQL struct {
 	dbLock          sync.Mutex
 	batchLock       sync.Mutex
 	MakeFieldTables bool // create tables for field values (if false, they get joined with "separator")
+
+	ExportTriples bool // create tables linking entities and field values to their source triples
+	tripleLock    sync.Mutex
+	triples       map[impl.ID]struct{} // triples already inserted, nil until the triple tables are created
 }
 
 // exec executes an sql query.
@@ -95,6 +100,13 @@ func (sql *SQL) Begin(bundle *pathbuilder.Bundle, count int64) error {
 		}
 	}()
 
+	// create the triple tables (once)
+	if sql.ExportTriples {
+		if err := sql.createTripleTables(); err != nil {
+			return err
+		}
+	}
+
 	// create a table for the given bundle
 	return sql.createBundleTable(bundle)
 }
@@ -261,7 +273,14 @@ func (sql *SQL) insert(bundle *pathbuilder.Bundle, parent impl.Label, entities [
 		}
 	}
 
-	// 3. insert any children into table(s)
+	// 3. insert the provenance of the entities and their values (if requested)
+	if sql.ExportTriples {
+		if err := sql.insertTriples(bundle, entities); err != nil {
+			return err
+		}
+	}
+
+	// 4. insert any children into table(s)
 	bundles := bundle.ChildBundles
 	for _, entity := range entities {
 		for _, bundle := range bundles {
@@ -0,0 +1,174 @@
+//spellchecker:words exporter
+package exporter_test
+
+//spellchecker:words database math reflect testing github drincw pathbuilder hangover internal sparkl exporter triplestore igraph impl wisski glebarez sqlite
+import (
+	"database/sql"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	_ "github.com/glebarez/go-sqlite"
+)
+
+//spellchecker:words pathbuilder
+
+func TestSQL_ExportTriples(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	db.SetMaxOpenConns(1) // every connection has its own in-memory database
+
+	_, book, _ := newBook([]pathbuilder.Path{{ID: "title"}}, nil)
+
+	triple := func(id int64, subject, predicate, object impl.Label, datum impl.Datum, role igraph.Role) igraph.Triple {
+		var tid impl.ID
+		tid.LoadInt(big.NewInt(id))
+		return igraph.Triple{
+			ID:        tid,
+			Subject:   subject,
+			Predicate: predicate,
+			Object:    object,
+			Datum:     datum,
+			Source:    impl.Source{Graph: "http://example.com/graph"},
+			Role:      role,
+		}
+	}
+	typ := triple(1, "b1", wisski.Type, "Book", impl.Datum{}, igraph.Regular)
+	title := triple(2, "b1", "hasTitle", "", impl.Datum{Value: "Faust", Language: "de"}, igraph.Data)
+	inverse := triple(3, "b1", "hasAuthor", "a1", impl.Datum{}, igraph.Inverse)
+
+	entities := []wisski.Entity{
+		{
+			URI:     "b1",
+			Triples: []igraph.Triple{typ},
+			Fields: map[string][]wisski.FieldValue{
+				"title": {{Datum: title.Datum, Triples: []igraph.Triple{inverse, title}}},
+			},
+		},
+		{
+			URI:     "b2",
+			Triples: []igraph.Triple{typ}, // shared triples are only stored once
+		},
+	}
+
+	se := &exporter.SQL{DB: db, SkipClose: true, BatchSize: 1, MaxQueryVar: 1000, Separator: ",", ExportTriples: true}
+	if err := se.Begin(book, int64(len(entities))); err != nil {
+		t.Fatal(err)
+	}
+	for i := range entities {
+		if err := se.Add(book, &entities[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := se.End(book); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		query string
+		want  [][]any
+	}{
+		{
+			`SELECT id, subject, predicate, object, value, lang, graph, role, inferred FROM triples ORDER BY id`,
+			[][]any{
+				{int64(1), "b1", string(wisski.Type), "Book", nil, nil, "http://example.com/graph", "regular", int64(0)},
+				{int64(2), "b1", "hasTitle", nil, "Faust", "de", "http://example.com/graph", "data", int64(0)},
+				{int64(3), "b1", "hasAuthor", "a1", nil, nil, "http://example.com/graph", "inverse", int64(1)},
+			},
+		},
+		{
+			`SELECT bundle, uri, field, value, lang, triple FROM field_value_triples ORDER BY uri, triple`,
+			[][]any{
+				{"book", "b1", nil, nil, nil, int64(1)},
+				{"book", "b1", "title", "Faust", "de", int64(2)},
+				{"book", "b1", "title", "Faust", "de", int64(3)},
+				{"book", "b2", nil, nil, nil, int64(1)},
+			},
+		},
+	} {
+		if got := queryAll(t, db, tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+// queryAll runs query against db and returns all rows.
+func queryAll(t *testing.T, db *sql.DB, query string) (rows [][]any) {
+	t.Helper()
+
+	result, err := db.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := result.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	columns, err := result.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for result.Next() {
+		row := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range row {
+			pointers[i] = &row[i]
+		}
+		if err := result.Scan(pointers...); err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+// newBook returns the bundles shared by exporter tests:
+// a top-level "book" bundle with the given fields, and its "chapter" child bundle with the given fields.
+// Both bundles are part of the returned pathbuilder.
+func newBook(bookFields, chapterFields []pathbuilder.Path) (pb pathbuilder.Pathbuilder, book, chapter *pathbuilder.Bundle) {
+	pb = pathbuilder.NewPathbuilder()
+
+	book = pb.GetOrCreate("book")
+	book.Path = pathbuilder.Path{ID: "book"}
+	for _, path := range bookFields {
+		book.ChildFields = append(book.ChildFields, pathbuilder.Field{Path: path})
+	}
+
+	chapter = pb.GetOrCreate("chapter")
+	chapter.Path = pathbuilder.Path{ID: "chapter"}
+	chapter.Parent = book
+	for _, path := range chapterFields {
+		chapter.ChildFields = append(chapter.ChildFields, pathbuilder.Field{Path: path})
+	}
+
+	book.ChildBundles = []*pathbuilder.Bundle{chapter}
+	return pb, book, chapter
+}
+
+// fieldValues returns field values holding the given values in the given language.
+func fieldValues(language string, values ...string) []wisski.FieldValue {
+	fvs := make([]wisski.FieldValue, len(values))
+	for i, v := range values {
+		fvs[i] = wisski.FieldValue{Datum: impl.Datum{Value: v, Language: language}}
+	}
+	return fvs
+}
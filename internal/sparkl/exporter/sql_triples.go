@@ -0,0 +1,141 @@
+//spellchecker:words exporter
+package exporter
+
+//spellchecker:words math github drincw pathbuilder hangover internal triplestore igraph impl wisski huandu sqlbuilder
+import (
+	"math/big"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	"github.com/huandu/go-sqlbuilder"
+)
+
+const (
+	// TriplesTable holds every triple any exported entity or value was derived from.
+	TriplesTable = "triples"
+
+	// FieldValueTriplesTable links entities and their field values to rows of [TriplesTable].
+	// Triples connecting the entity itself have a NULL field and value.
+	FieldValueTriplesTable = "field_value_triples"
+
+	idColumn        = "id"
+	subjectColumn   = "subject"
+	predicateColumn = "predicate"
+	objectColumn    = "object"
+	graphColumn     = "graph"
+	roleColumn      = "role"
+	inferredColumn  = "inferred"
+
+	bundleColumn = "bundle"
+	fieldColumn  = "field"
+	tripleColumn = "triple"
+)
+
+// createTripleTables (re-)creates the triple tables, unless they have already been created.
+func (sql *SQL) createTripleTables() error {
+	sql.tripleLock.Lock()
+	defer sql.tripleLock.Unlock()
+
+	if sql.triples != nil {
+		return nil
+	}
+
+	for _, name := range []string{TriplesTable, FieldValueTriplesTable} {
+		if err := sql.exec("DROP TABLE IF EXISTS "+name+";", nil); err != nil {
+			return err
+		}
+	}
+
+	triples := sqlbuilder.CreateTable(TriplesTable).IfNotExists()
+	triples.Define(idColumn, "INTEGER", "NOT NULL")
+	triples.Define(subjectColumn, "TEXT")
+	triples.Define(predicateColumn, "TEXT")
+	triples.Define(objectColumn, "TEXT")
+	triples.Define(valueColumn, "TEXT")
+	triples.Define(langColumn, "TEXT")
+	triples.Define(graphColumn, "TEXT")
+	triples.Define(roleColumn, "TEXT")
+	triples.Define(inferredColumn, "BOOLEAN")
+	if err := sql.exec(triples.Build()); err != nil {
+		return err
+	}
+
+	links := sqlbuilder.CreateTable(FieldValueTriplesTable).IfNotExists()
+	links.Define(bundleColumn, "TEXT")
+	links.Define(uriColumn, "TEXT")
+	links.Define(fieldColumn, "TEXT")
+	links.Define(valueColumn, "TEXT")
+	links.Define(langColumn, "TEXT")
+	links.Define(tripleColumn, "INTEGER")
+	if err := sql.exec(links.Build()); err != nil {
+		return err
+	}
+
+	sql.triples = make(map[impl.ID]struct{})
+	return nil
+}
+
+// insertTriples inserts the triples the given entities and their field values were derived from.
+// Each triple is only inserted into [TriplesTable] once.
+func (sql *SQL) insertTriples(bundle *pathbuilder.Bundle, entities []wisski.Entity) error {
+	var triples, links [][]any
+	var id big.Int
+
+	// add adds a single triple, and links it to the given field value.
+	add := func(uri impl.Label, field, value, lang any, triple igraph.Triple) {
+		tid := triple.ID.Int(&id).Int64()
+		links = append(links, []any{bundle.MachineName(), string(uri), field, value, lang, tid})
+
+		sql.tripleLock.Lock()
+		defer sql.tripleLock.Unlock()
+
+		if _, ok := sql.triples[triple.ID]; ok {
+			return
+		}
+		sql.triples[triple.ID] = struct{}{}
+
+		// data triples have a value instead of an object
+		var object, datum, language any = string(triple.Object), nullString, nullString
+		if triple.Role == igraph.Data {
+			object, datum, language = nullString, triple.Datum.Value, nullOrString(triple.Datum.Language)
+		}
+
+		triples = append(triples, []any{
+			tid,
+			string(triple.Subject), string(triple.Predicate), object, datum, language,
+			nullOrString(string(triple.Source.Graph)), triple.Role.String(), triple.Inferred(),
+		})
+	}
+
+	for _, entity := range entities {
+		for _, triple := range entity.Triples {
+			add(entity.URI, nullString, nullString, nullString, triple)
+		}
+		for _, field := range bundle.ChildFields {
+			for _, value := range entity.Fields[field.MachineName()] {
+				for _, triple := range value.Triples {
+					add(entity.URI, field.MachineName(), value.Datum.Value, nullOrString(value.Datum.Language), triple)
+				}
+			}
+		}
+	}
+
+	if err := sql.execInsert(TriplesTable, []string{
+		idColumn,
+		subjectColumn, predicateColumn, objectColumn, valueColumn, langColumn,
+		graphColumn, roleColumn, inferredColumn,
+	}, triples); err != nil {
+		return err
+	}
+	return sql.execInsert(FieldValueTriplesTable, []string{bundleColumn, uriColumn, fieldColumn, valueColumn, langColumn, tripleColumn}, links)
+}
+
+// nullOrString returns value, or NULL if it is empty.
+func nullOrString(value string) any {
+	if value == "" {
+		return nullString
+	}
+	return value
+}
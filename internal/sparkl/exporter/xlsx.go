@@ -0,0 +1,347 @@
+//spellchecker:words exporter
+package exporter
+
+//spellchecker:words errors strconv strings sync unicode github drincw pathbuilder hangover internal wisski xuri excelize
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	"github.com/xuri/excelize/v2"
+)
+
+//spellchecker:words xlsx
+
+// XLSX implements an exporter that writes a single Excel workbook.
+//
+// Every bundle, including child bundles, is written to a separate worksheet named after the bundle.
+// Worksheets have a frozen header row, a hyperlinked "URI" column, a hyperlinked "Parent" column for child bundles,
+// and a column for every field.
+// Multiple values of a field are joined using Separator.
+// Once a worksheet is full, the bundle continues in a new worksheet with the same header, named like the first with a "_2", "_3", ... suffix.
+//
+// Rows are streamed into temporary storage as entities are added.
+// The workbook is written to Writer when the exporter is closed.
+type XLSX struct {
+	Writer    io.Writer
+	Separator string // separator for multiple values of a field
+	MaxRows   int    // maximal number of rows per worksheet, including the header; defaults to the limit of Excel
+
+	l           sync.Mutex // protects file and the maps below
+	file        *excelize.File
+	headerStyle int
+	linkStyle   int
+	names       map[string]struct{}              // lower-cased names of sheets created so far
+	sheets      map[string]map[string]*xlsxSheet // open sheets by top-level bundle and bundle
+}
+
+// xlsxSheet is a single worksheet being written.
+type xlsxSheet struct {
+	writer *excelize.StreamWriter
+	row    int // last row written
+
+	name   string // name of the first worksheet of the bundle
+	header []any
+	part   int // number of worksheets of the bundle so far
+}
+
+// xlsxMaxFormulaString is the maximal length of a string inside a formula.
+// Longer uris are not hyperlinked.
+const xlsxMaxFormulaString = 255
+
+var errXLSXNoWriter = errors.New("Writer not set")
+
+// Begin signals that count entities will be transmitted for the given bundle.
+func (xl *XLSX) Begin(bundle *pathbuilder.Bundle, count int64) error {
+	if xl.Writer == nil {
+		return errXLSXNoWriter
+	}
+
+	xl.l.Lock()
+	defer xl.l.Unlock()
+
+	if xl.file == nil {
+		xl.file = excelize.NewFile()
+		xl.names = make(map[string]struct{})
+		xl.sheets = make(map[string]map[string]*xlsxSheet)
+
+		var err error
+		xl.headerStyle, err = xl.file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+		if err != nil {
+			return fmt.Errorf("failed to create header style: %w", err)
+		}
+		xl.linkStyle, err = xl.file.NewStyle(&excelize.Style{Font: &excelize.Font{Color: "0563C1", Underline: "single"}})
+		if err != nil {
+			return fmt.Errorf("failed to create hyperlink style: %w", err)
+		}
+	}
+
+	sheets := make(map[string]*xlsxSheet)
+	if err := xl.createSheets(sheets, bundle); err != nil {
+		return err
+	}
+	xl.sheets[bundle.MachineName()] = sheets
+	return nil
+}
+
+// createSheets creates the worksheets for the given bundle and its children, and writes their headers.
+// The caller must hold the lock.
+func (xl *XLSX) createSheets(sheets map[string]*xlsxSheet, bundle *pathbuilder.Bundle) error {
+	name := bundle.Name
+	if name == "" {
+		name = bundle.MachineName()
+	}
+
+	header := []any{"URI"}
+	if !bundle.IsToplevel() {
+		header = append(header, "Parent")
+	}
+	for _, field := range bundle.ChildFields {
+		header = append(header, xlsxFieldName(field))
+	}
+
+	sheet := &xlsxSheet{header: header}
+	if err := xl.createSheet(sheet, name); err != nil {
+		return err
+	}
+	sheets[bundle.MachineName()] = sheet
+
+	for _, child := range bundle.ChildBundles {
+		if err := xl.createSheets(sheets, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSheet creates the next worksheet of sheet and writes its header.
+// The first worksheet is named after name, continuations after the first worksheet.
+// The caller must hold the lock.
+func (xl *XLSX) createSheet(sheet *xlsxSheet, name string) error {
+	sheet.part++
+	if sheet.part == 1 {
+		name = xl.sheetName(name, "")
+		sheet.name = name
+	} else {
+		name = xl.sheetName(sheet.name, "_"+strconv.Itoa(sheet.part))
+	}
+
+	// the new file starts with an empty sheet, use it for the first bundle
+	var err error
+	if len(xl.names) == 0 {
+		err = xl.file.SetSheetName(xl.file.GetSheetName(0), name)
+	} else {
+		_, err = xl.file.NewSheet(name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create sheet %q: %w", name, err)
+	}
+	xl.names[strings.ToLower(name)] = struct{}{}
+
+	writer, err := xl.file.NewStreamWriter(name)
+	if err != nil {
+		return fmt.Errorf("failed to create stream writer for sheet %q: %w", name, err)
+	}
+	if err := writer.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return fmt.Errorf("failed to freeze header of sheet %q: %w", name, err)
+	}
+
+	sheet.writer = writer
+	sheet.row = 0
+	if err := sheet.write(sheet.header, excelize.RowOpts{StyleID: xl.headerStyle}); err != nil {
+		return fmt.Errorf("failed to write header of sheet %q: %w", name, err)
+	}
+	return nil
+}
+
+// continueSheet continues sheet in a new worksheet if it is full.
+func (xl *XLSX) continueSheet(sheet *xlsxSheet) error {
+	maxRows := xl.MaxRows
+	if maxRows <= 1 || maxRows > excelize.TotalRows {
+		maxRows = excelize.TotalRows
+	}
+	if sheet.row < maxRows {
+		return nil
+	}
+
+	xl.l.Lock()
+	defer xl.l.Unlock()
+
+	if err := sheet.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush sheet %q: %w", sheet.writer.Sheet, err)
+	}
+	return xl.createSheet(sheet, sheet.name)
+}
+
+// xlsxFieldName returns the column header for the given field.
+func xlsxFieldName(field pathbuilder.Field) string {
+	if field.Name != "" {
+		return field.Name
+	}
+	return field.MachineName()
+}
+
+// sheetName returns a valid and unused sheet name, made of name and suffix.
+// The caller must hold the lock.
+func (xl *XLSX) sheetName(name, suffix string) string {
+	// remove characters not allowed in sheet names
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = "Bundle"
+	}
+
+	// make it unique, and not too long
+	candidate := xlsxTruncate(name, excelize.MaxSheetNameLength-len(suffix)) + suffix
+	for i := 2; ; i++ {
+		if _, ok := xl.names[strings.ToLower(candidate)]; !ok {
+			return candidate
+		}
+		unique := suffix + " (" + strconv.Itoa(i) + ")"
+		candidate = xlsxTruncate(name, excelize.MaxSheetNameLength-len(unique)) + unique
+	}
+}
+
+// xlsxTruncate truncates value to at most limit runes, and removes trailing spaces.
+func xlsxTruncate(value string, limit int) string {
+	if utf8.RuneCountInString(value) > limit {
+		value = string([]rune(value)[:limit])
+	}
+	return strings.TrimRight(value, " ")
+}
+
+// Add adds entities for the given bundle.
+func (xl *XLSX) Add(bundle *pathbuilder.Bundle, entity *wisski.Entity) error {
+	xl.l.Lock()
+	sheets := xl.sheets[bundle.MachineName()]
+	xl.l.Unlock()
+
+	return xl.add(sheets, bundle, "", entity)
+}
+
+// add writes an entity of the given bundle, and then its children.
+func (xl *XLSX) add(sheets map[string]*xlsxSheet, bundle *pathbuilder.Bundle, parent string, entity *wisski.Entity) error {
+	row := []any{xl.link(string(entity.URI))}
+	if !bundle.IsToplevel() {
+		row = append(row, xl.link(parent))
+	}
+
+	var builder strings.Builder
+	for _, field := range bundle.ChildFields {
+		values := entity.Fields[field.MachineName()]
+		if len(values) == 0 {
+			row = append(row, nil)
+			continue
+		}
+
+		for i, value := range values {
+			if i > 0 {
+				builder.WriteString(xl.Separator)
+			}
+			builder.WriteString(value.Datum.Value)
+		}
+		row = append(row, builder.String())
+		builder.Reset()
+	}
+	sheet := sheets[bundle.MachineName()]
+	if err := xl.continueSheet(sheet); err != nil {
+		return err
+	}
+	if err := sheet.write(row, excelize.RowOpts{}); err != nil {
+		return fmt.Errorf("failed to write entity %q: %w", entity.URI, err)
+	}
+
+	for _, child := range bundle.ChildBundles {
+		children := entity.Children[child.MachineName()]
+		for i := range children {
+			if err := xl.add(sheets, child, string(entity.URI), &children[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// link returns a cell hyperlinking the given uri.
+func (xl *XLSX) link(uri string) excelize.Cell {
+	if len(uri) > xlsxMaxFormulaString {
+		return excelize.Cell{Value: uri}
+	}
+
+	quoted := `"` + strings.ReplaceAll(uri, `"`, `""`) + `"`
+	return excelize.Cell{
+		StyleID: xl.linkStyle,
+		Formula: "HYPERLINK(" + quoted + "," + quoted + ")",
+		Value:   uri,
+	}
+}
+
+// write writes the next row of the sheet.
+func (sheet *xlsxSheet) write(values []any, opts excelize.RowOpts) error {
+	sheet.row++
+	cell, err := excelize.CoordinatesToCellName(1, sheet.row)
+	if err != nil {
+		return fmt.Errorf("failed to determine cell: %w", err)
+	}
+	if err := sheet.writer.SetRow(cell, values, opts); err != nil {
+		return fmt.Errorf("failed to write row: %w", err)
+	}
+	return nil
+}
+
+// End signals that no more entities will be submitted for the given bundle.
+func (xl *XLSX) End(bundle *pathbuilder.Bundle) error {
+	xl.l.Lock()
+	defer xl.l.Unlock()
+
+	sheets := xl.sheets[bundle.MachineName()]
+	delete(xl.sheets, bundle.MachineName())
+
+	var errs []error
+	for _, sheet := range sheets {
+		if err := sheet.writer.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush sheet %q: %w", sheet.writer.Sheet, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close writes the workbook to Writer.
+func (xl *XLSX) Close() (e error) {
+	if xl.Writer == nil {
+		return errXLSXNoWriter
+	}
+
+	xl.l.Lock()
+	defer xl.l.Unlock()
+
+	if xl.file == nil {
+		xl.file = excelize.NewFile()
+	}
+	defer func() {
+		if e2 := xl.file.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close workbook: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	if err := xl.file.Write(xl.Writer); err != nil {
+		return fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+//spellchecker:words exporter
+package exporter_test
+
+//spellchecker:words bytes reflect strings testing github drincw pathbuilder hangover internal sparkl exporter triplestore impl wisski xuri excelize
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	"github.com/xuri/excelize/v2"
+)
+
+//spellchecker:words pathbuilder xlsx
+
+func TestXLSX(t *testing.T) {
+	t.Parallel()
+
+	_, book, chapter := newBook([]pathbuilder.Path{{ID: "title", Name: "Title"}, {ID: "keyword"}}, nil)
+	book.Name = "Book"
+	chapter.Name = "Book" // sheet names must be unique
+	long := &pathbuilder.Bundle{Path: pathbuilder.Path{ID: "long", Name: "A bundle: with [a] rather long name"}}
+
+	value := func(values ...string) []wisski.FieldValue { return fieldValues("", values...) }
+	books := []wisski.Entity{
+		{
+			URI:      `http://example.com/"b1"`,
+			Fields:   map[string][]wisski.FieldValue{"title": value("Faust"), "keyword": value("drama", "tragedy")},
+			Children: map[string][]wisski.Entity{"chapter": {{URI: "http://example.com/c1"}}},
+		},
+		{URI: "http://example.com/b2"},
+	}
+	longs := []wisski.Entity{{URI: impl.Label("http://example.com/" + strings.Repeat("x", 300))}}
+
+	var buffer bytes.Buffer
+	xl := &exporter.XLSX{Writer: &buffer, Separator: "; "}
+	for _, export := range []struct {
+		bundle   *pathbuilder.Bundle
+		entities []wisski.Entity
+	}{{book, books}, {long, longs}} {
+		if err := xl.Begin(export.bundle, int64(len(export.entities))); err != nil {
+			t.Fatal(err)
+		}
+		for i := range export.entities {
+			if err := xl.Add(export.bundle, &export.entities[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := xl.End(export.bundle); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := xl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := excelize.OpenReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	wantSheets := []string{"Book", "Book (2)", "A bundle_ with _a_ rather long"}
+	if got := file.GetSheetList(); !reflect.DeepEqual(got, wantSheets) {
+		t.Fatalf("GetSheetList() = %q, want %q", got, wantSheets)
+	}
+
+	for sheet, want := range map[string][][]string{
+		"Book": {
+			{"URI", "Title", "keyword"},
+			{`http://example.com/"b1"`, "Faust", "drama; tragedy"},
+			{"http://example.com/b2"},
+		},
+		"Book (2)": {
+			{"URI", "Parent"},
+			{"http://example.com/c1", `http://example.com/"b1"`},
+		},
+		"A bundle_ with _a_ rather long": {
+			{"URI"},
+			{string(longs[0].URI)},
+		},
+	} {
+		got, err := file.GetRows(sheet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetRows(%q) = %q, want %q", sheet, got, want)
+		}
+
+		panes, err := file.GetPanes(sheet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !panes.Freeze || panes.YSplit != 1 {
+			t.Errorf("GetPanes(%q) = %v, want frozen header", sheet, panes)
+		}
+	}
+
+	for _, tt := range []struct{ sheet, cell, want string }{
+		{"Book", "A2", `HYPERLINK("http://example.com/""b1""","http://example.com/""b1""")`},
+		{"Book (2)", "B2", `HYPERLINK("http://example.com/""b1""","http://example.com/""b1""")`},
+		{"A bundle_ with _a_ rather long", "A2", ""}, // too long for a formula
+	} {
+		if got, err := file.GetCellFormula(tt.sheet, tt.cell); err != nil || got != tt.want {
+			t.Errorf("GetCellFormula(%q, %q) = %q, %v, want %q", tt.sheet, tt.cell, got, err, tt.want)
+		}
+	}
+}
+
+func TestXLSX_MaxRows(t *testing.T) {
+	t.Parallel()
+
+	_, book, chapter := newBook(nil, nil)
+	book.Name = "Book"
+	chapter.Name = "Chapter"
+
+	chapters := []wisski.Entity{{URI: "c1"}, {URI: "c2"}, {URI: "c3"}}
+	books := []wisski.Entity{{URI: "b1", Children: map[string][]wisski.Entity{"chapter": chapters}}, {URI: "b2"}, {URI: "b3"}, {URI: "b4"}, {URI: "b5"}}
+
+	// every sheet holds the header and two entities
+	var buffer bytes.Buffer
+	xl := &exporter.XLSX{Writer: &buffer, MaxRows: 3}
+	if err := xl.Begin(book, int64(len(books))); err != nil {
+		t.Fatal(err)
+	}
+	for i := range books {
+		if err := xl.Add(book, &books[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := xl.End(book); err != nil {
+		t.Fatal(err)
+	}
+	if err := xl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := excelize.OpenReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	wantSheets := []string{"Book", "Chapter", "Chapter_2", "Book_2", "Book_3"}
+	if got := file.GetSheetList(); !reflect.DeepEqual(got, wantSheets) {
+		t.Fatalf("GetSheetList() = %q, want %q", got, wantSheets)
+	}
+
+	for sheet, want := range map[string][][]string{
+		"Book":      {{"URI"}, {"b1"}, {"b2"}},
+		"Book_2":    {{"URI"}, {"b3"}, {"b4"}},
+		"Book_3":    {{"URI"}, {"b5"}},
+		"Chapter":   {{"URI", "Parent"}, {"c1", "b1"}, {"c2", "b1"}},
+		"Chapter_2": {{"URI", "Parent"}, {"c3", "b1"}},
+	} {
+		got, err := file.GetRows(sheet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetRows(%q) = %q, want %q", sheet, got, want)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 //spellchecker:words sparkl
 package sparkl
 
-//spellchecker:words errors sync github drincw pathbuilder hangover internal sparkl exporter storages stats triplestore igraph wisski
+//spellchecker:words errors sync github drincw pathbuilder hangover internal sparkl exporter storages stats triplestore igraph impl wisski
 import (
 	"errors"
 	"fmt"
@@ -12,6 +12,7 @@ import (
 	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
 	"github.com/FAU-CDI/hangover/internal/stats"
 	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
 	"github.com/FAU-CDI/hangover/internal/wisski"
 )
 
@@ -20,8 +21,12 @@ import (
 // Export loads all top-level paths from the given path-builder from the index into the given engine.
 // Afterwards it is exported into the given exporter.
 func Export(pb *pathbuilder.Pathbuilder, index *igraph.Index, engine storages.BundleEngine, exporter exporter.Exporter, stats *stats.Stats) (e error) {
-	bundles := pb.Bundles()
+	return exportBundles(pb.Bundles(), nil, index, engine, exporter, stats)
+}
 
+// exportBundles exports the given top-level bundles into exporter.
+// If uris is non-nil, only entities with uris listed for their bundle are exported.
+func exportBundles(bundles []*pathbuilder.Bundle, uris bundleURIs, index *igraph.Index, engine storages.BundleEngine, exporter exporter.Exporter, stats *stats.Stats) (e error) {
 	storages, closer, err := StoreBundles(bundles, index, engine, stats)
 	if closer != nil {
 		defer func() {
@@ -63,7 +68,8 @@ func Export(pb *pathbuilder.Pathbuilder, index *igraph.Index, engine storages.Bu
 				}()
 
 				// count the number of elements
-				count, err := storage.Count()
+				selected := uris.of(bundle)
+				count, err := countSelected(storage, selected)
 				if err != nil {
 					return fmt.Errorf("failed to count number of entities: %w", err)
 				}
@@ -96,6 +102,9 @@ func Export(pb *pathbuilder.Pathbuilder, index *igraph.Index, engine storages.Bu
 					if err != nil {
 						return fmt.Errorf("failed to get from storage: %w", err)
 					}
+					if _, ok := selected[element.Label]; selected != nil && !ok {
+						continue
+					}
 					entity, err := storage.Load(element.Label)
 					if err != nil {
 						return fmt.Errorf("failed to load element: %w", err)
@@ -126,12 +135,39 @@ func Export(pb *pathbuilder.Pathbuilder, index *igraph.Index, engine storages.Bu
 	return gErr
 }
 
+// countSelected counts the entities in storage, or only those with the given uris if uris is non-nil.
+func countSelected(storage storages.BundleStorage, uris map[impl.Label]struct{}) (int64, error) {
+	if uris == nil {
+		count, err := storage.Count()
+		if err != nil {
+			return 0, fmt.Errorf("failed to count entities: %w", err)
+		}
+		return count, nil
+	}
+
+	var count int64
+	for element, err := range storage.Get(-1) {
+		if err != nil {
+			return 0, fmt.Errorf("failed to get from storage: %w", err)
+		}
+		if _, ok := uris[element.Label]; ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
 // LoadPathbuilder loads all paths in the given pathbuilder.
 func LoadPathbuilder(pb *pathbuilder.Pathbuilder, index *igraph.Index, engine storages.BundleEngine, stats *stats.Stats) (map[string][]wisski.Entity, error) {
+	return LoadSelection(pb, Selection{}, index, engine, stats)
+}
+
+// LoadSelection loads the selected entities of the given pathbuilder.
+func LoadSelection(pb *pathbuilder.Pathbuilder, selection Selection, index *igraph.Index, engine storages.BundleEngine, stats *stats.Stats) (map[string][]wisski.Entity, error) {
 	mp := exporter.Map{
 		Data: make(map[string][]wisski.Entity, len(pb.Bundles())),
 	}
-	err := Export(pb, index, engine, &mp, stats)
+	err := ExportSelection(pb, selection, index, engine, &mp, stats)
 	if err != nil {
 		return nil, err
 	}
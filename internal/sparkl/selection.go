@@ -0,0 +1,254 @@
+//spellchecker:words sparkl
+package sparkl
+
+//spellchecker:words bufio errors strings sync github drincw pathbuilder hangover internal sparkl exporter storages stats triplestore igraph impl wisski
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl/exporter"
+	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+// Selection selects a subset of bundles and entities to export.
+// The zero value selects everything.
+type Selection struct {
+	Bundles []string                // machine names of top-level bundles to export, all bundles if empty
+	URIs    map[impl.Label]struct{} // uris of top-level entities to export, all entities if nil
+
+	// References additionally selects all entities (transitively) referenced by selected entities, regardless of their bundle.
+	// References are values of fields that end in an entity, as opposed to a datatype property.
+	References bool
+}
+
+var errUnknownBundle = errors.New("unknown bundle")
+
+// bundles returns the selected top-level bundles of pb.
+func (selection Selection) bundles(pb *pathbuilder.Pathbuilder) ([]*pathbuilder.Bundle, error) {
+	all := pb.Bundles()
+	if len(selection.Bundles) == 0 {
+		return all, nil
+	}
+
+	selected := make(map[string]struct{}, len(selection.Bundles))
+	for _, machine := range selection.Bundles {
+		bundle := pb.Bundle(machine)
+		if bundle == nil || !bundle.IsToplevel() {
+			return nil, fmt.Errorf("%w: %q", errUnknownBundle, machine)
+		}
+		selected[machine] = struct{}{}
+	}
+
+	bundles := make([]*pathbuilder.Bundle, 0, len(selected))
+	for _, bundle := range all {
+		if _, ok := selected[bundle.MachineName()]; ok {
+			bundles = append(bundles, bundle)
+		}
+	}
+	return bundles, nil
+}
+
+// ReadURIs reads a list of uris, one per line.
+// Empty lines and lines starting with '#' are ignored.
+func ReadURIs(reader io.Reader) (map[impl.Label]struct{}, error) {
+	uris := make(map[impl.Label]struct{})
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		uris[impl.Label(strings.TrimSuffix(strings.TrimPrefix(line, "<"), ">"))] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read uris: %w", err)
+	}
+	return uris, nil
+}
+
+// bundleURIs holds the uris of selected entities by machine name of their top-level bundle.
+// A nil bundleURIs selects all entities.
+type bundleURIs map[string]map[impl.Label]struct{}
+
+// of returns the uris selected in bundle, or nil if all entities are selected.
+func (uris bundleURIs) of(bundle *pathbuilder.Bundle) map[impl.Label]struct{} {
+	if uris == nil {
+		return nil
+	}
+	if selected := uris[bundle.MachineName()]; selected != nil {
+		return selected
+	}
+	return map[impl.Label]struct{}{} // nothing is selected in this bundle
+}
+
+// add selects the entity with the given uri in the given bundle.
+func (uris bundleURIs) add(bundle string, uri impl.Label) {
+	if uris[bundle] == nil {
+		uris[bundle] = make(map[impl.Label]struct{})
+	}
+	uris[bundle][uri] = struct{}{}
+}
+
+// ExportSelection is like [Export], but only exports the selected entities.
+//
+// Selecting references requires extracting all bundles twice:
+// once to find references, and once to export the selected entities.
+func ExportSelection(pb *pathbuilder.Pathbuilder, selection Selection, index *igraph.Index, engine storages.BundleEngine, exporter exporter.Exporter, stats *stats.Stats) error {
+	bundles, err := selection.bundles(pb)
+	if err != nil {
+		return err
+	}
+
+	var uris bundleURIs
+	if selection.URIs != nil {
+		uris = make(bundleURIs, len(bundles))
+		for _, bundle := range bundles {
+			uris[bundle.MachineName()] = selection.URIs
+		}
+	}
+
+	if selection.References {
+		references := &referenceCollector{
+			bundles: make(map[string]struct{}, len(bundles)),
+			uris:    selection.URIs,
+		}
+		for _, bundle := range bundles {
+			references.bundles[bundle.MachineName()] = struct{}{}
+		}
+		if err := Export(pb, index, engine, references, stats); err != nil {
+			return fmt.Errorf("failed to find references: %w", err)
+		}
+
+		// referenced entities may be in any bundle
+		uris = references.Closure()
+		bundles = pb.Bundles()
+	}
+
+	return exportBundles(bundles, uris, index, engine, exporter, stats)
+}
+
+// referenceCollector is an exporter that collects references between top-level entities.
+type referenceCollector struct {
+	bundles map[string]struct{}     // machine names of selected bundles
+	uris    map[impl.Label]struct{} // selected uris, or nil
+
+	l          sync.Mutex
+	seeds      bundleURIs                  // selected top-level entities
+	toplevel   map[impl.Label][]string     // machine names of the top-level bundles of each top-level entity
+	owners     map[impl.Label]impl.Label   // top-level entity owning each entity
+	references map[impl.Label][]impl.Label // uris referenced by each top-level entity (and its children)
+}
+
+func (rc *referenceCollector) Begin(bundle *pathbuilder.Bundle, count int64) error {
+	rc.l.Lock()
+	defer rc.l.Unlock()
+
+	if rc.owners == nil {
+		rc.seeds = make(bundleURIs)
+		rc.toplevel = make(map[impl.Label][]string)
+		rc.owners = make(map[impl.Label]impl.Label)
+		rc.references = make(map[impl.Label][]impl.Label)
+	}
+	return nil
+}
+
+func (rc *referenceCollector) Add(bundle *pathbuilder.Bundle, entity *wisski.Entity) error {
+	rc.l.Lock()
+	defer rc.l.Unlock()
+
+	machine := bundle.MachineName()
+	if _, ok := rc.bundles[machine]; ok {
+		if _, ok := rc.uris[entity.URI]; rc.uris == nil || ok {
+			rc.seeds.add(machine, entity.URI)
+		}
+	}
+
+	// a top-level entity always owns itself, even if it is also a child entity elsewhere
+	rc.owners[entity.URI] = entity.URI
+	rc.toplevel[entity.URI] = append(rc.toplevel[entity.URI], machine)
+
+	rc.collect(entity.URI, bundle, entity)
+	return nil
+}
+
+// collect records the references of entity and its children, which are owned by the top-level entity owner.
+func (rc *referenceCollector) collect(owner impl.Label, bundle *pathbuilder.Bundle, entity *wisski.Entity) {
+	for _, field := range bundle.ChildFields {
+		if field.Datatype() != "" {
+			continue
+		}
+		for _, value := range entity.Fields[field.MachineName()] {
+			rc.references[owner] = append(rc.references[owner], impl.Label(value.Datum.Value))
+		}
+	}
+
+	for _, child := range bundle.ChildBundles {
+		children := entity.Children[child.MachineName()]
+		for i := range children {
+			if _, ok := rc.owners[children[i].URI]; !ok {
+				rc.owners[children[i].URI] = owner
+			}
+			rc.collect(owner, child, &children[i])
+		}
+	}
+}
+
+func (rc *referenceCollector) End(bundle *pathbuilder.Bundle) error {
+	return nil
+}
+
+func (rc *referenceCollector) Close() error {
+	return nil
+}
+
+// Closure returns the seeds in their bundles, and all top-level entities transitively referenced by them.
+// Referenced entities are selected in every bundle they are part of.
+func (rc *referenceCollector) Closure() bundleURIs {
+	rc.l.Lock()
+	defer rc.l.Unlock()
+
+	closure := make(bundleURIs, len(rc.seeds))
+	var queue []impl.Label
+	for machine, seeds := range rc.seeds {
+		for uri := range seeds {
+			closure.add(machine, uri)
+			queue = append(queue, uri)
+		}
+	}
+
+	visited := make(map[impl.Label]struct{})
+	for len(queue) > 0 {
+		uri := queue[0]
+		queue = queue[1:]
+
+		if _, ok := visited[uri]; ok {
+			continue
+		}
+		visited[uri] = struct{}{}
+
+		for _, reference := range rc.references[uri] {
+			// references to child entities select their top-level entity
+			owner, ok := rc.owners[reference]
+			if !ok {
+				continue
+			}
+			for _, machine := range rc.toplevel[owner] {
+				closure.add(machine, owner)
+			}
+			queue = append(queue, owner)
+		}
+	}
+	return closure
+}
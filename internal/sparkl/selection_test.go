@@ -0,0 +1,189 @@
+//spellchecker:words sparkl
+package sparkl_test
+
+//spellchecker:words path filepath reflect slices strings testing github drincw pathbuilder pbxml hangover internal sparkl storages triplestore impl wisski
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder/pbxml"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/sparkl/storages"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder nquads pname
+
+// selectionPathbuilder has persons with stays at places as child entities, and places with guest stays.
+const selectionPathbuilder = `<pathbuilderinterface>
+<path><id>person</id><weight>0</weight><enabled>1</enabled><group_id>0</group_id><bundle>person</bundle><field>person</field><cardinality>-1</cardinality><path_array><x>http://e/Person</x></path_array><datatype_property>empty</datatype_property><is_group>1</is_group><name>Person</name></path>
+<path><id>name</id><weight>0</weight><enabled>1</enabled><group_id>person</group_id><bundle>person</bundle><field>name</field><cardinality>1</cardinality><path_array><x>http://e/Person</x></path_array><datatype_property>http://e/name</datatype_property><is_group>0</is_group><name>Name</name></path>
+<path><id>stay</id><weight>1</weight><enabled>1</enabled><group_id>person</group_id><bundle>stay</bundle><field>stay</field><cardinality>-1</cardinality><path_array><x>http://e/Person</x><y>http://e/hasStay</y><x>http://e/Stay</x></path_array><datatype_property>empty</datatype_property><is_group>1</is_group><name>Stay</name></path>
+<path><id>at</id><weight>0</weight><enabled>1</enabled><group_id>stay</group_id><bundle>stay</bundle><field>at</field><cardinality>1</cardinality><path_array><x>http://e/Person</x><y>http://e/hasStay</y><x>http://e/Stay</x><y>http://e/at</y><x>http://e/Place</x></path_array><datatype_property>empty</datatype_property><is_group>0</is_group><name>At</name></path>
+<path><id>place</id><weight>1</weight><enabled>1</enabled><group_id>0</group_id><bundle>place</bundle><field>place</field><cardinality>-1</cardinality><path_array><x>http://e/Place</x></path_array><datatype_property>empty</datatype_property><is_group>1</is_group><name>Place</name></path>
+<path><id>pname</id><weight>0</weight><enabled>1</enabled><group_id>place</group_id><bundle>place</bundle><field>pname</field><cardinality>1</cardinality><path_array><x>http://e/Place</x></path_array><datatype_property>http://e/name</datatype_property><is_group>0</is_group><name>Name</name></path>
+<path><id>guest</id><weight>1</weight><enabled>1</enabled><group_id>place</group_id><bundle>place</bundle><field>guest</field><cardinality>-1</cardinality><path_array><x>http://e/Place</x><y>http://e/guest</y><x>http://e/Stay</x></path_array><datatype_property>empty</datatype_property><is_group>0</is_group><name>Guest</name></path>
+</pathbuilderinterface>
+`
+
+// selectionData is the data for selectionPathbuilder.
+//
+// Following references, alice reaches paris via her stay, paris reaches bob via the stay of bob, and bob reaches rome.
+// Rome is also a stay of carol, but must not select carol.
+// Jordan is both a person and a place.
+const selectionData = `<http://e/alice> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://e/Person> .
+<http://e/alice> <http://e/name> "Alice" .
+<http://e/alice> <http://e/hasStay> <http://e/s1> .
+<http://e/s1> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://e/Stay> .
+<http://e/s1> <http://e/at> <http://e/paris> .
+<http://e/bob> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://e/Person> .
+<http://e/bob> <http://e/hasStay> <http://e/s2> .
+<http://e/s2> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://e/Stay> .
+<http://e/s2> <http://e/at> <http://e/rome> .
+<http://e/carol> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://e/Person> .
+<http://e/carol> <http://e/hasStay> <http://e/rome> .
+<http://e/dave> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://e/Person> .
+<http://e/paris> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://e/Place> .
+<http://e/paris> <http://e/guest> <http://e/s2> .
+<http://e/rome> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://e/Place> .
+<http://e/rome> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://e/Stay> .
+<http://e/berlin> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://e/Place> .
+<http://e/jordan> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://e/Person> .
+<http://e/jordan> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://e/Place> .
+`
+
+func TestReadURIs(t *testing.T) {
+	t.Parallel()
+
+	input := "# selected entities\nhttp://e/alice\n\n  <http://e/bob>  \n#http://e/carol\nhttp://e/alice\n"
+	got, err := sparkl.ReadURIs(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[impl.Label]struct{}{"http://e/alice": {}, "http://e/bob": {}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadURIs() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadSelection(t *testing.T) {
+	t.Parallel()
+
+	pb, err := pbxml.Unmarshal([]byte(selectionPathbuilder))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "data.nq")
+	if err := os.WriteFile(path, []byte(selectionData), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := sparkl.LoadIndex(path, sparkl.Predicates{}, sparkl.NewEngine(""), sparkl.DefaultIndexOptions(&pb), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := index.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// uris returns the sorted uris of entities
+	uris := func(entities []wisski.Entity) (uris []impl.Label) {
+		for _, entity := range entities {
+			uris = append(uris, entity.URI)
+		}
+		slices.Sort(uris)
+		return uris
+	}
+
+	for _, tt := range []struct {
+		name      string
+		selection sparkl.Selection
+		want      map[string][]impl.Label
+		wantErr   bool
+	}{
+		{
+			name: "everything",
+			want: map[string][]impl.Label{
+				"person": {"http://e/alice", "http://e/bob", "http://e/carol", "http://e/dave", "http://e/jordan"},
+				"place":  {"http://e/berlin", "http://e/jordan", "http://e/paris", "http://e/rome"},
+			},
+		},
+		{
+			name:      "bundles",
+			selection: sparkl.Selection{Bundles: []string{"place"}},
+			want: map[string][]impl.Label{
+				"place": {"http://e/berlin", "http://e/jordan", "http://e/paris", "http://e/rome"},
+			},
+		},
+		{
+			name:      "unknown bundle",
+			selection: sparkl.Selection{Bundles: []string{"place", "event"}},
+			wantErr:   true,
+		},
+		{
+			name:      "child bundle",
+			selection: sparkl.Selection{Bundles: []string{"stay"}},
+			wantErr:   true,
+		},
+		{
+			name:      "uris",
+			selection: sparkl.Selection{URIs: map[impl.Label]struct{}{"http://e/alice": {}, "http://e/paris": {}, "http://e/s1": {}}},
+			want: map[string][]impl.Label{
+				"person": {"http://e/alice"},
+				"place":  {"http://e/paris"},
+			},
+		},
+		{
+			name:      "bundles and uris",
+			selection: sparkl.Selection{Bundles: []string{"person"}, URIs: map[impl.Label]struct{}{"http://e/alice": {}, "http://e/paris": {}}},
+			want: map[string][]impl.Label{
+				"person": {"http://e/alice"},
+			},
+		},
+		{
+			name:      "references through child entities",
+			selection: sparkl.Selection{Bundles: []string{"person"}, URIs: map[impl.Label]struct{}{"http://e/alice": {}}, References: true},
+			want: map[string][]impl.Label{
+				"person": {"http://e/alice", "http://e/bob"},
+				"place":  {"http://e/paris", "http://e/rome"},
+			},
+		},
+		{
+			name:      "references only start in selected bundles",
+			selection: sparkl.Selection{Bundles: []string{"place"}, URIs: map[impl.Label]struct{}{"http://e/alice": {}, "http://e/jordan": {}}, References: true},
+			want: map[string][]impl.Label{
+				"person": nil,
+				"place":  {"http://e/jordan"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := sparkl.LoadSelection(&pb, tt.selection, index, storages.NewBundleEngine(""), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadSelection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := make(map[string][]impl.Label, len(data))
+			for bundle, entities := range data {
+				got[bundle] = uris(entities)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadSelection() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
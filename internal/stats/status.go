@@ -5,7 +5,7 @@ package stats
 
 //spellchecker:words rewritable
 
-//spellchecker:words errors slog sync atomic github hangover internal triplestore igraph progress pkglib lazy perf
+//spellchecker:words errors slog sync atomic github hangover internal overview problems triplestore igraph progress pkglib lazy perf
 import (
 	"errors"
 	"fmt"
@@ -15,6 +15,8 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/FAU-CDI/hangover/internal/overview"
+	"github.com/FAU-CDI/hangover/internal/problems"
 	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
 	"github.com/FAU-CDI/hangover/pkg/progress"
 	"github.com/tkw1536/pkglib/lazy"
@@ -39,7 +41,9 @@ type Stats struct {
 	logger     *slog.Logger
 	rewritable *progress.Rewritable
 
-	istats lazy.Lazy[igraph.Stats]
+	istats   lazy.Lazy[igraph.Stats]
+	problems lazy.Lazy[*problems.Report]
+	overview lazy.Lazy[*overview.Overview]
 
 	current StageStats   // current holds information about the current stage
 	all     []StageStats // all hold information about the old stages
@@ -111,6 +115,46 @@ func (st *Stats) IndexStats() igraph.Stats {
 	return st.istats.Get(nil)
 }
 
+// StoreProblems stores the problems found in the data.
+// If st is nil or done, this call has no effect.
+func (st *Stats) StoreProblems(report *problems.Report) {
+	defer st.onUpdate()
+
+	if st == nil || st.done.Load() {
+		return
+	}
+
+	st.problems.Set(report)
+}
+
+// Problems returns the problems found in the data, or nil if none have been stored.
+func (st *Stats) Problems() *problems.Report {
+	if st == nil {
+		return nil
+	}
+	return st.problems.Get(nil)
+}
+
+// StoreOverview stores the overview of the data.
+// If st is nil or done, this call has no effect.
+func (st *Stats) StoreOverview(overview *overview.Overview) {
+	defer st.onUpdate()
+
+	if st == nil || st.done.Load() {
+		return
+	}
+
+	st.overview.Set(overview)
+}
+
+// Overview returns the overview of the data, or nil if none has been stored.
+func (st *Stats) Overview() *overview.Overview {
+	if st == nil {
+		return nil
+	}
+	return st.overview.Get(nil)
+}
+
 // Current returns a copy of the current StageStats.
 func (st *Stats) All() []StageStats {
 	if st == nil {
@@ -441,6 +485,12 @@ const (
 	StageExportIndex     Stage = "export"
 	StageExportSQL       Stage = "export/sql"
 	StageExportJSON      Stage = "export/json"
+	StageExportNDJSON    Stage = "export/ndjson"
+	StageExportParquet   Stage = "export/parquet"
+	StageExportCSV       Stage = "export/csv"
+	StageExportXLSX      Stage = "export/xlsx"
+	StageExportSearch    Stage = "export/search"
+	StageExportRDF       Stage = "export/rdf"
 	StageReadPathbuilder Stage = "pathbuilder"
 	StageScanSameAs      Stage = "index/sameas"
 	StageScanInverse     Stage = "index/inverse"
@@ -448,5 +498,8 @@ const (
 	StageExtractSameAs   Stage = "sameas"
 	StageExtractBundles  Stage = "bundles"
 	StageExtractCache    Stage = "cache"
+	StageFindProblems    Stage = "problems"
+	StageOverview        Stage = "overview"
+	StageValidateSHACL   Stage = "validate/shacl"
 	StageHandler         Stage = "handler"
 )
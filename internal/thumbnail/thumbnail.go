@@ -0,0 +1,294 @@
+// Package thumbnail generates and caches downscaled versions of images.
+//
+//spellchecker:words thumbnail
+package thumbnail
+
+//spellchecker:words bytes container crypto sha256 encoding errors image jpeg path filepath runtime strings sync time golang
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "image/gif" // register the gif format
+
+	"golang.org/x/image/draw"
+)
+
+// Defaults for a [Thumbnailer].
+const (
+	DefaultSize      = 320
+	DefaultMaxMemory = 64 << 20
+	MaxPixels        = 100_000_000 // images with more pixels are not decoded
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported image format")
+	ErrTooLarge    = errors.New("image too large")
+)
+
+// Thumbnail is a generated thumbnail.
+type Thumbnail struct {
+	Data        []byte
+	ContentType string
+	ModTime     time.Time // modification time of the original image
+}
+
+// Thumbnailer generates thumbnails of images inside a directory, and caches them.
+//
+// Thumbnails are cached on disk when CacheDir is set, and in memory otherwise.
+// A cached thumbnail is regenerated once the original image changes.
+type Thumbnailer struct {
+	Root      string // directory holding the original images
+	CacheDir  string // directory to cache thumbnails in; if empty, thumbnails are cached in memory
+	Size      int    // maximum width and height of thumbnails; defaults to [DefaultSize]
+	MaxMemory int64  // maximum total size of thumbnails cached in memory; defaults to [DefaultMaxMemory]
+
+	init sync.Once
+	sem  chan struct{} // limits the number of concurrently decoded images
+
+	m      sync.Mutex // protects the fields below
+	lru    list.List  // of *entry, most recently used first
+	memory map[string]*list.Element
+	used   int64
+}
+
+type entry struct {
+	key       string
+	thumbnail Thumbnail
+}
+
+// Supported checks if a thumbnail can be generated for the image with the given name.
+func Supported(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jpg", ".jpeg", ".png", ".gif":
+		return true
+	default:
+		return false
+	}
+}
+
+// Get returns a thumbnail of the image at the given path relative to the root directory.
+func (t *Thumbnailer) Get(rel string) (th Thumbnail, e error) {
+	t.init.Do(func() {
+		t.sem = make(chan struct{}, runtime.GOMAXPROCS(0))
+		t.memory = make(map[string]*list.Element)
+	})
+
+	if !Supported(rel) {
+		return th, ErrUnsupported
+	}
+
+	root, err := os.OpenRoot(t.Root)
+	if err != nil {
+		return th, fmt.Errorf("failed to open root: %w", err)
+	}
+	defer func() {
+		if e2 := root.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close root: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	info, err := root.Stat(rel)
+	if err != nil {
+		return th, fmt.Errorf("failed to stat image: %w", err)
+	}
+	key := t.key(rel, info)
+
+	// check the cache
+	if th, ok := t.cached(key); ok {
+		th.ModTime = info.ModTime()
+		return th, nil
+	}
+
+	// generate a new thumbnail
+	file, err := root.Open(rel)
+	if err != nil {
+		return th, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer func() {
+		if e2 := file.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close image: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	t.sem <- struct{}{}
+	th.Data, th.ContentType, err = Generate(file, t.size())
+	<-t.sem
+	if err != nil {
+		return th, err
+	}
+	th.ModTime = info.ModTime()
+
+	if err := t.store(key, th); err != nil {
+		return th, err
+	}
+	return th, nil
+}
+
+func (t *Thumbnailer) size() int {
+	if t.Size <= 0 {
+		return DefaultSize
+	}
+	return t.Size
+}
+
+// key returns the cache key for the given image.
+func (t *Thumbnailer) key(rel string, info os.FileInfo) string {
+	hash := sha256.New()
+	for _, part := range []string{rel, strconv.Itoa(t.size()), strconv.FormatInt(info.Size(), 10), strconv.FormatInt(info.ModTime().UnixNano(), 10)} {
+		_, _ = hash.Write([]byte(part)) // never fails
+		_, _ = hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (t *Thumbnailer) cached(key string) (th Thumbnail, ok bool) {
+	if t.CacheDir != "" {
+		for _, contentType := range []string{contentTypeJPEG, contentTypePNG} {
+			data, err := os.ReadFile(filepath.Join(t.CacheDir, key+extensions[contentType])) // #nosec G304 -- key is a hash
+			if err == nil {
+				return Thumbnail{Data: data, ContentType: contentType}, true
+			}
+		}
+		return th, false
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	element, ok := t.memory[key]
+	if !ok {
+		return th, false
+	}
+	t.lru.MoveToFront(element)
+	return element.Value.(*entry).thumbnail, true
+}
+
+func (t *Thumbnailer) store(key string, th Thumbnail) error {
+	if t.CacheDir != "" {
+		if err := os.MkdirAll(t.CacheDir, 0o750); err != nil {
+			return fmt.Errorf("failed to create cache directory: %w", err)
+		}
+
+		// write to a temporary file first, so that no partial thumbnails are read
+		name := filepath.Join(t.CacheDir, key+extensions[th.ContentType])
+		temp := name + ".tmp" + strconv.Itoa(os.Getpid())
+		if err := os.WriteFile(temp, th.Data, 0o600); err != nil {
+			return fmt.Errorf("failed to write thumbnail: %w", err)
+		}
+		if err := os.Rename(temp, name); err != nil {
+			return fmt.Errorf("failed to rename thumbnail: %w", err)
+		}
+		return nil
+	}
+
+	maxMemory := t.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = DefaultMaxMemory
+	}
+	size := int64(len(th.Data))
+	if size > maxMemory {
+		return nil
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if _, ok := t.memory[key]; ok {
+		return nil
+	}
+
+	// evict the least recently used thumbnails
+	for t.used+size > maxMemory {
+		oldest := t.lru.Back()
+		old := t.lru.Remove(oldest).(*entry)
+		delete(t.memory, old.key)
+		t.used -= int64(len(old.thumbnail.Data))
+	}
+
+	t.memory[key] = t.lru.PushFront(&entry{key: key, thumbnail: th})
+	t.used += size
+	return nil
+}
+
+const (
+	contentTypeJPEG = "image/jpeg"
+	contentTypePNG  = "image/png"
+)
+
+var extensions = map[string]string{
+	contentTypeJPEG: ".jpg",
+	contentTypePNG:  ".png",
+}
+
+// Generate decodes an image from reader, and encodes a version that fits into a square of the given size.
+// JPEG images are encoded as JPEG, all other images as PNG.
+// Images that already fit are re-encoded unchanged.
+func Generate(reader io.Reader, size int) (data []byte, contentType string, err error) {
+	// check the size before decoding the entire image
+	var buffer bytes.Buffer
+	config, format, err := image.DecodeConfig(io.TeeReader(reader, &buffer))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	src, _, err := image.Decode(io.MultiReader(&buffer, reader))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	dst := src
+	bounds := src.Bounds()
+	if width, height := bounds.Dx(), bounds.Dy(); width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, height*size/width)
+		} else {
+			width, height = max(1, width*size/height), size
+		}
+
+		scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), src, bounds, draw.Src, nil)
+		dst = scaled
+	}
+
+	var out bytes.Buffer
+	if format == "jpeg" {
+		contentType = contentTypeJPEG
+		err = jpeg.Encode(&out, dst, &jpeg.Options{Quality: 85})
+	} else {
+		contentType = contentTypePNG
+		err = png.Encode(&out, dst)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return out.Bytes(), contentType, nil
+}
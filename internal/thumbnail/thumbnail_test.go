@@ -0,0 +1,93 @@
+//spellchecker:words thumbnail
+package thumbnail_test
+
+//spellchecker:words bytes image color path filepath testing github hangover internal thumbnail
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/FAU-CDI/hangover/internal/thumbnail"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		for y := range height {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 0, A: 255}) // #nosec G115 -- test data
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		width, height int
+		wantW, wantH  int
+	}{
+		{400, 200, 100, 50},
+		{200, 400, 50, 100},
+		{80, 60, 80, 60},
+	}
+	for _, tt := range tests {
+		data, contentType, err := thumbnail.Generate(bytes.NewReader(encodePNG(t, tt.width, tt.height)), 100)
+		if err != nil {
+			t.Fatalf("Generate() returned error %v", err)
+		}
+		if contentType != "image/png" {
+			t.Errorf("Generate() returned content type %q, want %q", contentType, "image/png")
+		}
+
+		config, err := png.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("failed to decode thumbnail: %v", err)
+		}
+		if config.Width != tt.wantW || config.Height != tt.wantH {
+			t.Errorf("Generate(%dx%d) = %dx%d, want %dx%d", tt.width, tt.height, config.Width, config.Height, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestThumbnailer_Get(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "image.png"), encodePNG(t, 400, 400), 0o600); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+
+	for _, cacheDir := range []string{"", t.TempDir()} {
+		thumbnailer := &thumbnail.Thumbnailer{Root: root, CacheDir: cacheDir, Size: 100}
+
+		// get twice, the second time is served from the cache
+		for range 2 {
+			th, err := thumbnailer.Get("image.png")
+			if err != nil {
+				t.Fatalf("Get() returned error %v", err)
+			}
+			if th.ContentType != "image/png" || len(th.Data) == 0 {
+				t.Errorf("Get() returned unexpected thumbnail %q of %d bytes", th.ContentType, len(th.Data))
+			}
+		}
+
+		if _, err := thumbnailer.Get("../image.png"); err == nil {
+			t.Error("Get() outside of root did not return an error")
+		}
+		if _, err := thumbnailer.Get("document.pdf"); err == nil {
+			t.Error("Get() of unsupported file did not return an error")
+		}
+	}
+}
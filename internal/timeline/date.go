@@ -0,0 +1,176 @@
+//spellchecker:words timeline
+package timeline
+
+//spellchecker:words regexp strconv strings
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//spellchecker:words circa
+
+// Date is a day of the proleptic gregorian calendar.
+type Date struct {
+	Year  int
+	Month int // 1-12
+	Day   int // 1-31
+}
+
+// String formats the date as "YYYY-MM-DD".
+func (date Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", date.Year, date.Month, date.Day)
+}
+
+// MarshalText implements [encoding.TextMarshaler].
+func (date Date) MarshalText() ([]byte, error) {
+	return []byte(date.String()), nil
+}
+
+// Before checks if date is strictly before other.
+func (date Date) Before(other Date) bool {
+	if date.Year != other.Year {
+		return date.Year < other.Year
+	}
+	if date.Month != other.Month {
+		return date.Month < other.Month
+	}
+	return date.Day < other.Day
+}
+
+// Range is a normalized range of dates, including both ends.
+type Range struct {
+	Start, End  Date
+	Approximate bool // the range was marked as approximate, e.g. using "ca."
+}
+
+// Contains checks if the range overlaps the given year.
+func (rng Range) Contains(year int) bool {
+	return rng.Start.Year <= year && year <= rng.End.Year
+}
+
+// approximate matches markers of approximate dates at the start of a value.
+var approximate = regexp.MustCompile(`(?i)^(?:ca\.?|circa|c\.|um|about|approx\.?|~)\s*`)
+
+// separator matches the separator between the two ends of a range.
+var separator = regexp.MustCompile(`^\s*(?:-|–|—|/|\.\.|to|bis)\s*`)
+
+// Parse parses a date-like value into a range of dates.
+//
+// Supported are dates of the form "YYYY", "YYYY-MM", "YYYY-MM-DD" (optionally followed by a time), "DD.MM.YYYY", "MM.YYYY",
+// decades of the form "1850s", and ranges of these separated by "-", "/", "..", "to" or "bis" such as "1850-1860".
+// Values may be marked as approximate by a leading "ca.", "circa", "um" or "~", or a trailing "?".
+//
+// Returns false if the value is not date-like.
+func Parse(value string) (rng Range, ok bool) {
+	value = strings.TrimSpace(value)
+
+	if rest := approximate.ReplaceAllString(value, ""); rest != value {
+		rng.Approximate = true
+		value = rest
+	}
+	if rest, found := strings.CutSuffix(value, "?"); found {
+		rng.Approximate = true
+		value = strings.TrimSpace(rest)
+	}
+
+	start, end, rest, ok := parseDate(value)
+	if !ok {
+		return Range{}, false
+	}
+
+	if rest != "" {
+		sep := separator.FindString(rest)
+		if sep == "" {
+			return Range{}, false
+		}
+		rest = rest[len(sep):]
+		if approx := approximate.FindString(rest); approx != "" {
+			rng.Approximate = true
+			rest = rest[len(approx):]
+		}
+
+		_, end, rest, ok = parseDate(rest)
+		if !ok || rest != "" || end.Before(start) {
+			return Range{}, false
+		}
+	}
+
+	rng.Start, rng.End = start, end
+	return rng, true
+}
+
+var (
+	isoDate    = regexp.MustCompile(`^(\d{4})(?:-(\d{2})(?:-(\d{2})(?:[T ][0-9:.]+(?:Z|[+-]\d{2}:?\d{2})?)?)?)?`)
+	germanDate = regexp.MustCompile(`^(?:(\d{1,2})\.)?(\d{1,2})\.(\d{4})`)
+	decade     = regexp.MustCompile(`^(\d{3})0s`)
+)
+
+// parseDate parses a single date at the start of value.
+// It returns the first and last day the date may refer to, and the remaining part of value.
+func parseDate(value string) (start, end Date, rest string, ok bool) {
+	var year, month, day int
+
+	if match := decade.FindStringSubmatch(value); match != nil {
+		year, _ = strconv.Atoi(match[1])
+		return Date{year * 10, 1, 1}, Date{year*10 + 9, 12, 31}, value[len(match[0]):], true
+	}
+
+	if match := isoDate.FindStringSubmatch(value); match != nil {
+		year, _ = strconv.Atoi(match[1])
+		month, _ = strconv.Atoi(match[2])
+		day, _ = strconv.Atoi(match[3])
+		rest = value[len(match[0]):]
+
+		// "1850-1860" is a range of years, not the 18th month of 1850
+		if match[2] != "" && startsWithDigit(rest) {
+			month, day, rest = 0, 0, value[len(match[1]):]
+		}
+	} else if match := germanDate.FindStringSubmatch(value); match != nil {
+		day, _ = strconv.Atoi(match[1])
+		month, _ = strconv.Atoi(match[2])
+		year, _ = strconv.Atoi(match[3])
+		rest = value[len(match[0]):]
+	} else {
+		return Date{}, Date{}, "", false
+	}
+
+	// a year must not continue, e.g. "18500"
+	if startsWithDigit(rest) {
+		return Date{}, Date{}, "", false
+	}
+
+	switch {
+	case month == 0:
+		start, end = Date{year, 1, 1}, Date{year, 12, 31}
+	case month > 12:
+		return Date{}, Date{}, "", false
+	case day == 0:
+		start, end = Date{year, month, 1}, Date{year, month, daysIn(year, month)}
+	case day > daysIn(year, month):
+		return Date{}, Date{}, "", false
+	default:
+		start, end = Date{year, month, day}, Date{year, month, day}
+	}
+	return start, end, rest, true
+}
+
+func startsWithDigit(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
+
+// daysIn returns the number of days in the given month.
+func daysIn(year, month int) int {
+	switch month {
+	case 2:
+		if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+			return 29
+		}
+		return 28
+	case 4, 6, 9, 11:
+		return 30
+	default:
+		return 31
+	}
+}
@@ -0,0 +1,155 @@
+// Package timeline detects date-like field values and arranges entities chronologically.
+//
+//spellchecker:words timeline
+package timeline
+
+//spellchecker:words cmp slices strings github drincw pathbuilder hangover internal overview triplestore impl wisski
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/overview"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+// MinDateRatio is the minimal fraction of values of a field that must be date-like for the field to be included in a timeline.
+const MinDateRatio = 0.5
+
+// Timeline holds the dated entities of a bundle.
+type Timeline struct {
+	Fields []Field // fields with date-like values
+	Events []Event // ordered by start, then end date
+}
+
+// Field is a field with date-like values.
+type Field struct {
+	MachineName string
+	Name        string
+}
+
+// Event is a date-like value of a field.
+type Event struct {
+	URI   impl.Label
+	Title string
+	Field string // machine name of the field
+	Value string // value as found in the data
+	Range
+}
+
+// New creates a timeline for the given entities of a bundle.
+//
+// A field is part of the timeline if at least [MinDateRatio] of its values are date-like, see [Parse].
+// Values that are not date-like are ignored.
+func New(bundle *pathbuilder.Bundle, entities []wisski.Entity) *Timeline {
+	var timeline Timeline
+
+	fields := bundle.Fields()
+	for _, field := range fields {
+		var total int
+		var events []Event
+		for i := range entities {
+			for _, value := range entities[i].Fields[field.MachineName()] {
+				raw := strings.TrimSpace(value.Datum.Value)
+				if raw == "" {
+					continue
+				}
+				total++
+
+				rng, ok := Parse(raw)
+				if !ok {
+					continue
+				}
+				events = append(events, Event{
+					URI:   entities[i].URI,
+					Title: overview.Title(fields, &entities[i]),
+					Field: field.MachineName(),
+					Value: raw,
+					Range: rng,
+				})
+			}
+		}
+
+		if len(events) == 0 || float64(len(events)) < MinDateRatio*float64(total) {
+			continue
+		}
+		timeline.Fields = append(timeline.Fields, Field{MachineName: field.MachineName(), Name: field.Name})
+		timeline.Events = append(timeline.Events, events...)
+	}
+
+	slices.SortStableFunc(timeline.Events, func(a, b Event) int {
+		return cmp.Or(
+			compareDates(a.Start, b.Start),
+			compareDates(a.End, b.End),
+			cmp.Compare(a.URI, b.URI),
+		)
+	})
+	return &timeline
+}
+
+func compareDates(a, b Date) int {
+	return cmp.Or(cmp.Compare(a.Year, b.Year), cmp.Compare(a.Month, b.Month), cmp.Compare(a.Day, b.Day))
+}
+
+// Bucket counts the events overlapping a period of years.
+type Bucket struct {
+	Start int // first year of the period
+	Count int
+}
+
+// Decades returns the number of events overlapping every decade from the first to the last event.
+func (timeline *Timeline) Decades() []Bucket {
+	events := timeline.Events
+	if len(events) == 0 {
+		return []Bucket{}
+	}
+
+	first, last := events[0].Start.Year, events[0].End.Year
+	for _, event := range events {
+		first = min(first, event.Start.Year)
+		last = max(last, event.End.Year)
+	}
+	first, last = first/10, last/10
+
+	buckets := make([]Bucket, last-first+1)
+	for i := range buckets {
+		buckets[i].Start = (first + i) * 10
+	}
+	for _, event := range events {
+		for i := event.Start.Year / 10; i <= event.End.Year/10; i++ {
+			buckets[i-first].Count++
+		}
+	}
+	return buckets
+}
+
+// Years returns the number of events overlapping every year of the decade starting with the given year.
+func (timeline *Timeline) Years(decade int) []Bucket {
+	decade -= decade % 10
+
+	buckets := make([]Bucket, 10)
+	for i := range buckets {
+		buckets[i].Start = decade + i
+		for _, event := range timeline.Events {
+			if event.Contains(buckets[i].Start) {
+				buckets[i].Count++
+			}
+		}
+	}
+	return buckets
+}
+
+// In returns the events overlapping the given year.
+func (timeline *Timeline) In(year int) []Event {
+	events := []Event{}
+	for _, event := range timeline.Events {
+		if event.Contains(year) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
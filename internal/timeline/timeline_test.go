@@ -0,0 +1,114 @@
+//spellchecker:words timeline
+package timeline_test
+
+//spellchecker:words reflect testing github drincw pathbuilder hangover internal timeline triplestore impl wisski
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/timeline"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	rng := func(start, end string, approximate bool) timeline.Range {
+		parse := func(date string) (d timeline.Date) {
+			if _, err := fmt.Sscanf(date, "%d-%d-%d", &d.Year, &d.Month, &d.Day); err != nil {
+				t.Fatalf("invalid test date %q", date)
+			}
+			return d
+		}
+		return timeline.Range{Start: parse(start), End: parse(end), Approximate: approximate}
+	}
+
+	tests := []struct {
+		value string
+		want  timeline.Range
+		ok    bool
+	}{
+		{"1850", rng("1850-01-01", "1850-12-31", false), true},
+		{"1850-02", rng("1850-02-01", "1850-02-28", false), true},
+		{"1852-02", rng("1852-02-01", "1852-02-29", false), true},
+		{"1850-02-03", rng("1850-02-03", "1850-02-03", false), true},
+		{"1850-02-03T12:00:00Z", rng("1850-02-03", "1850-02-03", false), true},
+		{"03.02.1850", rng("1850-02-03", "1850-02-03", false), true},
+		{"2.1850", rng("1850-02-01", "1850-02-28", false), true},
+		{"1850s", rng("1850-01-01", "1859-12-31", false), true},
+		{"1850-1860", rng("1850-01-01", "1860-12-31", false), true},
+		{"1850 – 1860", rng("1850-01-01", "1860-12-31", false), true},
+		{"1850/1860", rng("1850-01-01", "1860-12-31", false), true},
+		{"1850 bis 1860", rng("1850-01-01", "1860-12-31", false), true},
+		{"1850-05-01 to 1851", rng("1850-05-01", "1851-12-31", false), true},
+		{"ca. 1900", rng("1900-01-01", "1900-12-31", true), true},
+		{"um 1900", rng("1900-01-01", "1900-12-31", true), true},
+		{"1900?", rng("1900-01-01", "1900-12-31", true), true},
+		{"1850-ca. 1860", rng("1850-01-01", "1860-12-31", true), true},
+
+		{"", timeline.Range{}, false},
+		{"unknown", timeline.Range{}, false},
+		{"18500", timeline.Range{}, false},
+		{"1850-13", timeline.Range{}, false},
+		{"1850-02-30", timeline.Range{}, false},
+		{"1860-1850", timeline.Range{}, false},
+		{"1850 Paris", timeline.Range{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := timeline.Parse(tt.value)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("Parse() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	bundle := &pathbuilder.Bundle{Path: pathbuilder.Path{ID: "festival", Name: "Festival"}}
+	bundle.ChildFields = []pathbuilder.Field{
+		{Path: pathbuilder.Path{ID: "name", Name: "Name"}},
+		{Path: pathbuilder.Path{ID: "date", Name: "Date"}},
+	}
+
+	value := func(v string) []wisski.FieldValue { return []wisski.FieldValue{{Datum: impl.Datum{Value: v}}} }
+	entities := []wisski.Entity{
+		{URI: "a", Fields: map[string][]wisski.FieldValue{"name": value("Kirmes 1905"), "date": value("1905-06")}},
+		{URI: "b", Fields: map[string][]wisski.FieldValue{"name": value("Kirmes"), "date": value("1898-1901")}},
+		{URI: "c", Fields: map[string][]wisski.FieldValue{"name": value("Kirmes"), "date": value("unknown")}},
+		{URI: "d", Fields: map[string][]wisski.FieldValue{"name": value("1900")}},
+	}
+
+	got := timeline.New(bundle, entities)
+
+	if want := []timeline.Field{{MachineName: "date", Name: "Date"}}; !reflect.DeepEqual(got.Fields, want) {
+		t.Errorf("New().Fields = %v, want %v", got.Fields, want)
+	}
+	if len(got.Events) != 2 || got.Events[0].URI != "b" || got.Events[1].URI != "a" || got.Events[1].Title != "Kirmes 1905" {
+		t.Errorf("New().Events = %v", got.Events)
+	}
+
+	wantDecades := []timeline.Bucket{{Start: 1890, Count: 1}, {Start: 1900, Count: 2}}
+	if decades := got.Decades(); !reflect.DeepEqual(decades, wantDecades) {
+		t.Errorf("Decades() = %v, want %v", decades, wantDecades)
+	}
+
+	years := got.Years(1905)
+	if len(years) != 10 || years[0] != (timeline.Bucket{Start: 1900, Count: 1}) || years[5] != (timeline.Bucket{Start: 1905, Count: 1}) || years[2] != (timeline.Bucket{Start: 1902, Count: 0}) {
+		t.Errorf("Years() = %v", years)
+	}
+
+	if in := got.In(1899); len(in) != 1 || in[0].URI != "b" {
+		t.Errorf("In() = %v", in)
+	}
+}
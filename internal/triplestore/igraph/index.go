@@ -3,11 +3,12 @@
 //spellchecker:words igraph
 package igraph
 
-//spellchecker:words errors sync atomic github hangover internal triplestore imap impl
+//spellchecker:words errors iter sync atomic github hangover internal triplestore imap impl
 import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"sync"
 	"sync/atomic"
 
@@ -73,7 +74,31 @@ func (index *Index) Triple(id impl.ID) (triple Triple, err error) {
 	if err != nil {
 		return triple, fmt.Errorf("failed to resolve id: %w", err)
 	}
+	return index.resolveTriple(id, t)
+}
+
+// Triples iterates over all triples in this index.
+// There is no guarantee on order.
+func (index *Index) Triples() iter.Seq2[Triple, error] {
+	return func(yield func(Triple, error) bool) {
+		err := index.triples.Iterate(func(id impl.ID, t IndexTriple) error {
+			triple, err := index.resolveTriple(id, t)
+			if err != nil {
+				return err
+			}
+			if !yield(triple, nil) {
+				return errAborted
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errAborted) {
+			yield(Triple{}, fmt.Errorf("failed to iterate triples: %w", err))
+		}
+	}
+}
 
+// resolveTriple resolves the labels of the triple with the given id.
+func (index *Index) resolveTriple(id impl.ID, t IndexTriple) (triple Triple, err error) {
 	triple.Role = t.Role
 
 	triple.Subject, err = index.labels.Reverse(t.Items[0].Literal)
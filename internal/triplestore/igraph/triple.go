@@ -170,3 +170,17 @@ const (
 	// Data represents a data triple.
 	Data
 )
+
+// String returns a human-readable name of this role.
+func (role Role) String() string {
+	switch role {
+	case Regular:
+		return "regular"
+	case Inverse:
+		return "inverse"
+	case Data:
+		return "data"
+	default:
+		return fmt.Sprintf("Role(%d)", uint8(role))
+	}
+}
@@ -0,0 +1,148 @@
+//spellchecker:words viewer
+package viewer
+
+//spellchecker:words context http strings github drincw pathbuilder hangover internal access wisski
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/access"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+type userKey struct{}
+
+// authenticate authenticates the user making the given request, and stores it in the request context.
+// If the request carries invalid credentials, an appropriate response is sent and ok is false.
+//
+// Admin endpoints use their own token, and are never authenticated against the policy.
+func (viewer *Viewer) authenticate(w http.ResponseWriter, r *http.Request) (req *http.Request, ok bool) {
+	if strings.HasPrefix(r.URL.Path, "/api/v1/admin/") {
+		return r, true
+	}
+
+	user, err := viewer.Access.Authenticate(r)
+	if err != nil {
+		viewer.challenge(w, r)
+		return r, false
+	}
+	return r.WithContext(context.WithValue(r.Context(), userKey{}, user)), true
+}
+
+// user returns the user making the given request.
+func (viewer *Viewer) user(r *http.Request) string {
+	user, _ := r.Context().Value(userKey{}).(string)
+	return user
+}
+
+// challenge asks the user to authenticate.
+// If users can not authenticate, sends a plain forbidden response instead.
+func (viewer *Viewer) challenge(w http.ResponseWriter, _ *http.Request) {
+	challenge := viewer.Access.Challenge()
+	if challenge == "" {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// htmlLogin asks the user to log in, and redirects to the index page once they have.
+func (viewer *Viewer) htmlLogin(w http.ResponseWriter, r *http.Request) {
+	if viewer.user(r) == access.Anonymous {
+		viewer.challenge(w, r)
+		return
+	}
+	http.Redirect(w, r, viewer.basePath(r)+"/", http.StatusSeeOther)
+}
+
+// allowed checks if the user making the request may see the given bundle.
+func (viewer *Viewer) allowed(r *http.Request, bundle *pathbuilder.Bundle) bool {
+	return viewer.Access.Allowed(viewer.user(r), bundle)
+}
+
+// allowedBundles returns for every bundle, including child bundles, if the user making the request may see it.
+func (viewer *Viewer) allowedBundles(r *http.Request) map[*pathbuilder.Bundle]bool {
+	allowed := make(map[*pathbuilder.Bundle]bool)
+
+	var walk func(bundles []*pathbuilder.Bundle)
+	walk = func(bundles []*pathbuilder.Bundle) {
+		for _, bundle := range bundles {
+			allowed[bundle] = viewer.allowed(r, bundle)
+			walk(bundle.ChildBundles)
+		}
+	}
+	walk(viewer.Pathbuilder.Bundles())
+
+	return allowed
+}
+
+// allowedAll checks if the user making the request may see every bundle.
+func (viewer *Viewer) allowedAll(r *http.Request) bool {
+	for _, allowed := range viewer.allowedBundles(r) {
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// checkBundle checks if the user making the request may see the bundle with the given machine name.
+// Bundles that do not exist are always allowed; looking them up will fail later.
+//
+// If the user may not see the bundle, an appropriate response is sent and false is returned.
+// Anonymous users are asked to authenticate, authenticated users receive a not found response.
+func (viewer *Viewer) checkBundle(w http.ResponseWriter, r *http.Request, machine string) bool {
+	return viewer.checkBundleIn(w, r, viewer.Pathbuilder, machine)
+}
+
+// checkBundleIn is like [Viewer.checkBundle], but looks up the bundle in the given pathbuilder.
+func (viewer *Viewer) checkBundleIn(w http.ResponseWriter, r *http.Request, pb *pathbuilder.Pathbuilder, machine string) bool {
+	bundle := pb.Bundle(machine)
+	if bundle == nil || viewer.allowed(r, bundle) {
+		return true
+	}
+
+	if viewer.user(r) == access.Anonymous {
+		viewer.challenge(w, r)
+	} else {
+		http.NotFound(w, r)
+	}
+	return false
+}
+
+// filterEntity returns a copy of entity (belonging to bundle) that only includes the child bundles
+// the user making the request may see.
+// If the user may see every bundle, entity is returned unchanged.
+func (viewer *Viewer) filterEntity(r *http.Request, bundle *pathbuilder.Bundle, entity *wisski.Entity) *wisski.Entity {
+	if viewer.Access == nil {
+		return entity
+	}
+
+	filtered := viewer.filterChildren(r, bundle, *entity)
+	return &filtered
+}
+
+func (viewer *Viewer) filterChildren(r *http.Request, bundle *pathbuilder.Bundle, entity wisski.Entity) wisski.Entity {
+	children := make(map[string][]wisski.Entity, len(entity.Children))
+	for _, child := range bundle.ChildBundles {
+		name := child.MachineName()
+		entities, ok := entity.Children[name]
+		if !ok || !viewer.allowed(r, child) {
+			continue
+		}
+
+		filtered := make([]wisski.Entity, len(entities))
+		for i, e := range entities {
+			filtered[i] = viewer.filterChildren(r, child, e)
+		}
+		children[name] = filtered
+	}
+	entity.Children = children
+	return entity
+}
@@ -0,0 +1,166 @@
+//spellchecker:words viewer
+package viewer_test
+
+//spellchecker:words encoding json http httptest reflect strings testing github drincw pathbuilder hangover internal access overview problems sparkl stats triplestore igraph imap impl viewer wisski
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/access"
+	"github.com/FAU-CDI/hangover/internal/overview"
+	"github.com/FAU-CDI/hangover/internal/problems"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/internal/triplestore/imap"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/viewer"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+// newAccessViewer returns a viewer serving a public person bundle and a secret bundle only authenticated users may see.
+// The user "admin" authenticates with the token "s3cret".
+func newAccessViewer(t *testing.T) *viewer.Viewer {
+	t.Helper()
+
+	pb := pathbuilder.NewPathbuilder()
+	person := pb.GetOrCreate("person")
+	person.Path = pathbuilder.Path{ID: "person", Name: "Person", IsGroup: true, PathArray: []string{"http://example.com/Person"}}
+	secret := pb.GetOrCreate("secret")
+	secret.Path = pathbuilder.Path{ID: "secret", Name: "Secret", IsGroup: true, PathArray: []string{"http://example.com/Secret"}}
+
+	identities := imap.MakeMemory[impl.Label, impl.Label](0)
+	cache, err := sparkl.NewCache(map[string][]wisski.Entity{
+		"person": {{URI: "http://example.com/alice"}},
+		"secret": {{URI: "http://example.com/bob"}},
+	}, &identities, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := &problems.Report{
+		Orphaned: []problems.Node{
+			{URI: "http://example.com/carol", Types: []impl.Label{"http://example.com/Person"}},
+			{URI: "http://example.com/dave", Types: []impl.Label{"http://example.com/Person", "http://example.com/Secret"}},
+			{URI: "http://example.com/eve", Types: []impl.Label{"http://example.com/Other"}},
+		},
+		OrphanedCount: 3,
+	}
+	summary := &overview.Overview{
+		Entities: 2,
+		Bundles:  []overview.Bundle{{MachineName: "person", Name: "Person", Entities: 1}, {MachineName: "secret", Name: "Secret", Entities: 1}},
+		Index:    igraph.Stats{DirectTriples: 2},
+		Triples:  2,
+		Classes:  []overview.Count{{Label: "http://example.com/Person", Count: 1}, {Label: "http://example.com/Secret", Count: 1}},
+	}
+
+	tokens, err := access.ParseTokens(strings.NewReader("admin:s3cret\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := stats.NewStats(io.Discard, false)
+	st.StoreIndexStats(summary.Index)
+	st.StoreProblems(report)
+	st.StoreOverview(summary)
+
+	handler := new(viewer.Viewer)
+	handler.Access = &access.Policy{
+		Tokens: tokens,
+		Rules:  access.Rules{"secret": {Authenticated: true}},
+	}
+	if err := handler.Replace(&cache, &pb, st); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := handler.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	return handler
+}
+
+// getJSON decodes the response to a get request for the given path, made with the given bearer token.
+func getJSON(t *testing.T, handler http.Handler, path, token string, response any) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s returned status %d", path, rec.Code)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestViewer_Access(t *testing.T) {
+	t.Parallel()
+
+	handler := newAccessViewer(t)
+
+	for _, tt := range []struct {
+		name       string
+		token      string
+		orphaned   []impl.Label
+		bundles    int
+		restricted bool
+	}{
+		{
+			name:       "anonymous",
+			orphaned:   []impl.Label{"http://example.com/carol"},
+			bundles:    1,
+			restricted: true,
+		},
+		{
+			name:     "all bundles",
+			token:    "s3cret",
+			orphaned: []impl.Label{"http://example.com/carol", "http://example.com/dave", "http://example.com/eve"},
+			bundles:  2,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var report problems.Report
+			getJSON(t, handler, "/api/v1/problems", tt.token, &report)
+
+			var orphaned []impl.Label
+			for _, node := range report.Orphaned {
+				orphaned = append(orphaned, node.URI)
+			}
+			if !reflect.DeepEqual(orphaned, tt.orphaned) {
+				t.Errorf("got orphaned nodes %v, want %v", orphaned, tt.orphaned)
+			}
+			if report.OrphanedCount != 3 {
+				t.Errorf("got %d orphaned nodes in total, want 3", report.OrphanedCount)
+			}
+
+			var summary overview.Overview
+			getJSON(t, handler, "/api/v1/overview", tt.token, &summary)
+
+			if len(summary.Bundles) != tt.bundles {
+				t.Errorf("got %d bundles, want %d", len(summary.Bundles), tt.bundles)
+			}
+			if summary.Restricted != tt.restricted {
+				t.Errorf("got restricted %t, want %t", summary.Restricted, tt.restricted)
+			}
+			if hasStatistics := summary.Triples != 0 || summary.Classes != nil; hasStatistics == tt.restricted {
+				t.Errorf("got statistics %v and classes %v, want them only if not restricted", summary.Triples, summary.Classes)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+//spellchecker:words viewer
+package viewer
+
+//spellchecker:words crypto subtle encoding json errors http strings time github drincw pathbuilder hangover internal sparkl stats
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/stats"
+)
+
+//spellchecker:words pathbuilder
+
+// ErrReloading is returned by a [Reloader] when a reload is already in progress.
+var ErrReloading = errors.New("a reload is already in progress")
+
+// Reloader reloads the dataset served by a viewer in the background.
+type Reloader interface {
+	// Start starts reloading the dataset in the background and returns immediately.
+	// When the reload succeeds, the new data is passed to [Viewer.Replace].
+	//
+	// If a reload is already in progress, returns [ErrReloading].
+	Start() error
+
+	// Status returns the status of the current or most recent reload.
+	Status() ReloadStatus
+}
+
+// ReloadStatus describes the status of a reload.
+type ReloadStatus struct {
+	Running bool // is a reload currently in progress?
+
+	Generation int // number of successful reloads
+
+	LastStart time.Time // time the last reload was started
+	LastEnd   time.Time // time the last reload finished
+	LastError string    // error of the last reload, if any
+}
+
+// Replace atomically replaces the data served by this viewer.
+// st holds the stats of loading the data, including the statistics of its index, the problems found in it and its overview.
+// st is closed, and replaces Stats.
+//
+// Replace waits for all requests using the old data to finish, and then closes the old cache.
+// It must not be called from within a request handler.
+func (viewer *Viewer) Replace(cache *sparkl.Cache, pb *pathbuilder.Pathbuilder, st *stats.Stats) error {
+	// requests must never see stats that are not done
+	st.Close()
+
+	old := func() *sparkl.Cache {
+		viewer.data.Lock()
+		defer viewer.data.Unlock()
+
+		old := viewer.Cache
+		viewer.Stats = st
+		viewer.Cache = cache
+		viewer.Pathbuilder = pb
+		viewer.index = st.IndexStats()
+		viewer.problems = st.Problems()
+		viewer.overview = st.Overview()
+		viewer.media.reset()
+		return old
+	}()
+
+	if old == nil || old == cache {
+		return nil
+	}
+	if err := old.Close(); err != nil {
+		return fmt.Errorf("failed to close old cache: %w", err)
+	}
+	return nil
+}
+
+// checkAdmin checks that the request carries the admin bearer token.
+// If it does not, an appropriate response is sent and false is returned.
+func (viewer *Viewer) checkAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if ok && viewer.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(viewer.AdminToken)) == 1 {
+		return true
+	}
+
+	w.Header().Set("WWW-Authenticate", `Bearer realm="hangover"`)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	return false
+}
+
+func (viewer *Viewer) jsonReload(w http.ResponseWriter, r *http.Request) error {
+	if !viewer.checkAdmin(w, r) {
+		return nil
+	}
+
+	code := http.StatusOK
+	if r.Method == http.MethodPost {
+		code = http.StatusAccepted
+
+		err := viewer.Reloader.Start()
+		switch {
+		case errors.Is(err, ErrReloading):
+			code = http.StatusConflict
+		case err != nil:
+			viewer.Stats.LogError("start reload", err)
+			code = http.StatusInternalServerError
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(viewer.Reloader.Status()); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
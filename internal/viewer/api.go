@@ -1,8 +1,10 @@
 //spellchecker:words viewer
 package viewer
 
-//spellchecker:words github drincw pathbuilder hangover internal stats triplestore igraph impl wisski pkglib perf
+//spellchecker:words http github drincw pathbuilder hangover internal stats triplestore igraph impl wisski pkglib perf
 import (
+	"net/http"
+
 	"github.com/FAU-CDI/drincw/pathbuilder"
 	"github.com/FAU-CDI/hangover/internal/stats"
 	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
@@ -38,7 +40,8 @@ func (viewer *Viewer) findEntity(bundle_machine string, uri impl.Label) (bundle
 	return
 }
 
-func (viewer *Viewer) getBundles() (bundles []*pathbuilder.Bundle, ok bool) {
+// getBundles returns the top-level bundles the user making the request may see.
+func (viewer *Viewer) getBundles(r *http.Request) (bundles []*pathbuilder.Bundle, ok bool) {
 	names := viewer.Cache.BundleNames()
 	bundles = make([]*pathbuilder.Bundle, 0, len(names))
 	for _, name := range names {
@@ -49,6 +52,9 @@ func (viewer *Viewer) getBundles() (bundles []*pathbuilder.Bundle, ok bool) {
 			// you should never hit this case.
 			continue
 		}
+		if !viewer.allowed(r, bundle) {
+			continue
+		}
 		bundles = append(bundles, bundle)
 	}
 	return bundles, true
@@ -70,9 +76,12 @@ func (viewer *Viewer) getEntityURIs(id string) (bundle *pathbuilder.Bundle, uris
 }
 
 // TODO: Make this stream.
-func (viewer *Viewer) getEntity(id string, uri impl.Label) (entity *wisski.Entity, ok bool) {
-	_, entity, ok = viewer.findEntity(id, uri)
-	return
+func (viewer *Viewer) getEntity(r *http.Request, id string, uri impl.Label) (entity *wisski.Entity, ok bool) {
+	bundle, entity, ok := viewer.findEntity(id, uri)
+	if !ok {
+		return nil, false
+	}
+	return viewer.filterEntity(r, bundle, entity), true
 }
 
 // Perf represents viewer performance.
@@ -0,0 +1,75 @@
+//spellchecker:words viewer
+package viewer
+
+//spellchecker:words context http path strings
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+type basePathKey struct{}
+
+// CleanBasePath normalizes a base path.
+// The returned path is either empty, or starts with a '/' and has no trailing '/'.
+func CleanBasePath(base string) string {
+	base = strings.TrimSpace(base)
+	if base == "" {
+		return ""
+	}
+
+	// path.Clean collapses leading slashes, so the result can never be a protocol-relative url.
+	base = path.Clean("/" + base)
+	if base == "/" {
+		return ""
+	}
+	return base
+}
+
+// stripBasePath removes the configured base path from the request path, and stores the
+// base path to use for links in the request context.
+//
+// If the request is outside of the base path, an appropriate response is sent and ok is false.
+func (viewer *Viewer) stripBasePath(w http.ResponseWriter, r *http.Request) (req *http.Request, ok bool) {
+	base := CleanBasePath(viewer.BasePath)
+	if base != "" {
+		switch {
+		case r.URL.Path == base:
+			http.Redirect(w, r, viewer.forwardedPrefix(r)+base+"/", http.StatusMovedPermanently)
+			return r, false
+		case !strings.HasPrefix(r.URL.Path, base+"/"):
+			http.NotFound(w, r)
+			return r, false
+		}
+
+		u := new(url.URL)
+		*u = *r.URL
+		u.Path = strings.TrimPrefix(r.URL.Path, base)
+		u.RawPath = ""
+
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = u
+		r = r2
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), basePathKey{}, viewer.forwardedPrefix(r)+base)), true
+}
+
+// forwardedPrefix returns the prefix a reverse proxy has stripped from the request.
+// If the X-Forwarded-Prefix header is not trusted, returns the empty string.
+func (viewer *Viewer) forwardedPrefix(r *http.Request) string {
+	if !viewer.TrustForwardedPrefix {
+		return ""
+	}
+	prefix, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Prefix"), ",")
+	return CleanBasePath(prefix)
+}
+
+// basePath returns the base path to prefix links with for the given request.
+func (viewer *Viewer) basePath(r *http.Request) string {
+	base, _ := r.Context().Value(basePathKey{}).(string)
+	return base
+}
@@ -0,0 +1,102 @@
+//spellchecker:words viewer
+package viewer_test
+
+//spellchecker:words http httptest strings testing github hangover internal viewer
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/hangover/internal/viewer"
+)
+
+func TestCleanBasePath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		base string
+		want string
+	}{
+		{"", ""},
+		{"/", ""},
+		{"  ", ""},
+		{"archive", "/archive"},
+		{"/archive/", "/archive"},
+		{" /archive/kirmes ", "/archive/kirmes"},
+		{"//example.com/x", "/example.com/x"},
+		{"/a/../b", "/b"},
+	}
+
+	for _, tt := range tests {
+		if got := viewer.CleanBasePath(tt.base); got != tt.want {
+			t.Errorf("CleanBasePath(%q) = %q, want %q", tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestViewer_BasePath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		base     string
+		trust    bool
+		forward  string
+		path     string
+		code     int
+		location string // expected Location header, if any
+		prefix   string // expected prefix of links, if any
+	}{
+		{name: "no base path", path: "/", code: http.StatusOK, prefix: ""},
+		{name: "inside base path", base: "/archive", path: "/archive/", code: http.StatusOK, prefix: "/archive"},
+		{name: "unclean base path", base: "archive/", path: "/archive/", code: http.StatusOK, prefix: "/archive"},
+		{name: "base path itself", base: "/archive", path: "/archive", code: http.StatusMovedPermanently, location: "/archive/"},
+		{name: "outside base path", base: "/archive", path: "/", code: http.StatusNotFound},
+		{name: "sibling of base path", base: "/archive", path: "/archived/", code: http.StatusNotFound},
+		{name: "forwarded prefix", base: "/archive", trust: true, forward: "/proxy", path: "/archive/", code: http.StatusOK, prefix: "/proxy/archive"},
+		{name: "forwarded prefix redirect", base: "/archive", trust: true, forward: "/proxy/", path: "/archive", code: http.StatusMovedPermanently, location: "/proxy/archive/"},
+		{name: "forwarded prefix without base path", trust: true, forward: "/proxy", path: "/", code: http.StatusOK, prefix: "/proxy"},
+		{name: "untrusted forwarded prefix", base: "/archive", forward: "/proxy", path: "/archive/", code: http.StatusOK, prefix: "/archive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the viewer has no data, so it serves the loading page
+			handler := viewer.NewViewer(io.Discard, false)
+			handler.BasePath = tt.base
+			handler.TrustForwardedPrefix = tt.trust
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.forward != "" {
+				req.Header.Set("X-Forwarded-Prefix", tt.forward)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.code)
+			}
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Errorf("got location %q, want %q", got, tt.location)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+
+			body := rec.Body.String()
+			for _, want := range []string{
+				`data-progress="` + tt.prefix + `/api/v1/progress"`,
+				`href="` + tt.prefix + `/assets/`,
+				`src="` + tt.prefix + `/assets/`,
+			} {
+				if !strings.Contains(body, want) {
+					t.Errorf("body does not contain %q", want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+//spellchecker:words viewer
+package viewer
+
+//spellchecker:words html template http strings github drincw pathbuilder hangover internal citation triplestore impl wisski gorilla
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/citation"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	"github.com/gorilla/mux"
+)
+
+//spellchecker:words pathbuilder
+
+// defaultCitationFormat is the format used when a citation request does not specify one.
+const defaultCitationFormat = "csl-json"
+
+type htmlCitationLink struct {
+	Label string
+	URL   template.URL
+}
+
+// citationLinks returns links to the citation of the given entity in every format.
+func citationLinks(base, bundle, uri string) []htmlCitationLink {
+	links := make([]htmlCitationLink, len(citation.Formats))
+	for i, format := range citation.Formats {
+		links[i] = htmlCitationLink{
+			Label: format.Label,
+			URL:   template.URL(base + "/api/v1/cite/" + url.PathEscape(bundle) + "?uri=" + url.QueryEscape(uri) + "&format=" + url.QueryEscape(format.Name)), // #nosec G203
+		}
+	}
+	return links
+}
+
+func (viewer *Viewer) jsonCite(w http.ResponseWriter, r *http.Request) error {
+	if viewer.jsonFallback(w, r) {
+		return nil
+	}
+
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = defaultCitationFormat
+	}
+	format, err := citation.FormatByName(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+
+	vars := mux.Vars(r)
+	if !viewer.checkBundle(w, r, vars["bundle"]) {
+		return nil
+	}
+
+	bundle, entity, ok := viewer.findEntity(vars["bundle"], impl.Label(vars["uri"]))
+	if !ok {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	cite := viewer.citation(r, bundle, viewer.filterEntity(r, bundle, entity))
+
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="entity`+format.Extension+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	if err := format.Write(w, cite); err != nil {
+		return fmt.Errorf("failed to write citation: %w", err)
+	}
+	return nil
+}
+
+// citation creates a citation for the given entity, linking to its page in the viewer.
+func (viewer *Viewer) citation(r *http.Request, bundle *pathbuilder.Bundle, entity *wisski.Entity) citation.Citation {
+	page := viewer.absoluteURL(r) + "/entity/" + url.PathEscape(bundle.MachineName()) + "?uri=" + url.QueryEscape(string(entity.URI))
+	return viewer.Citations.New(bundle, entity, page)
+}
+
+// absoluteURL returns the absolute url the viewer is served under for the given request, without a trailing '/'.
+//
+// The scheme and host are taken from the request, or from the X-Forwarded-Proto and X-Forwarded-Host headers
+// when the reverse proxy is trusted.
+func (viewer *Viewer) absoluteURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+
+	if viewer.TrustForwardedPrefix {
+		if proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ","); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if forwarded, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Host"), ","); strings.TrimSpace(forwarded) != "" {
+			host = strings.TrimSpace(forwarded)
+		}
+	}
+
+	return scheme + "://" + host + viewer.basePath(r)
+}
@@ -0,0 +1,103 @@
+//spellchecker:words viewer
+package viewer
+
+//spellchecker:words embed encoding json html template http sync github hangover internal assets coverage sparkl
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+
+	"github.com/FAU-CDI/hangover/internal/assets"
+	"github.com/FAU-CDI/hangover/internal/coverage"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+)
+
+// viewerCoverage caches the coverage report of the current data.
+type viewerCoverage struct {
+	m      sync.Mutex
+	cache  *sparkl.Cache // cache the report was computed for
+	report *coverage.Report
+}
+
+// getCoverage returns the coverage report of the current data, computing it if necessary.
+// The caller must hold the data lock for reading.
+func (viewer *Viewer) getCoverage() *coverage.Report {
+	viewer.coverage.m.Lock()
+	defer viewer.coverage.m.Unlock()
+
+	if viewer.coverage.cache != viewer.Cache || viewer.coverage.report == nil {
+		viewer.coverage.cache = viewer.Cache
+		viewer.coverage.report = coverage.New(viewer.Pathbuilder, viewer.Cache.Entities, viewer.index)
+	}
+	return viewer.coverage.report
+}
+
+// filterCoverage returns a copy of report that only includes the bundles the user making the request may see.
+func (viewer *Viewer) filterCoverage(r *http.Request, report *coverage.Report) *coverage.Report {
+	if viewer.Access == nil {
+		return report
+	}
+
+	var filter func(bundles []coverage.Bundle) []coverage.Bundle
+	filter = func(bundles []coverage.Bundle) []coverage.Bundle {
+		visible := make([]coverage.Bundle, 0, len(bundles))
+		for _, bundle := range bundles {
+			if !viewer.allowed(r, viewer.Pathbuilder.FindBundle(bundle.MachineName)) {
+				continue
+			}
+			bundle.Bundles = filter(bundle.Bundles)
+			visible = append(visible, bundle)
+		}
+		return visible
+	}
+
+	filtered := *report
+	filtered.Bundles = filter(report.Bundles)
+	return &filtered
+}
+
+//go:embed templates/coverage.html
+var coverageHTML string
+
+var coverageTemplate *template.Template = assets.Assetshangover.MustParseShared(
+	"coverage.html",
+	coverageHTML,
+	contextTemplateFuncs,
+)
+
+type htmlCoverageContext struct {
+	Globals  contextGlobal
+	Coverage *coverage.Report
+}
+
+func (viewer *Viewer) htmlCoverage(w http.ResponseWriter, r *http.Request) {
+	if viewer.htmlFallback(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+	err := coverageTemplate.Execute(w, htmlCoverageContext{
+		Globals:  viewer.contextGlobal(r),
+		Coverage: viewer.filterCoverage(r, viewer.getCoverage()),
+	})
+	if err != nil {
+		viewer.Stats.LogError("render coverage", err)
+	}
+}
+
+func (viewer *Viewer) jsonCoverage(w http.ResponseWriter, r *http.Request) error {
+	if viewer.jsonFallback(w, r) {
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(viewer.filterCoverage(r, viewer.getCoverage())); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
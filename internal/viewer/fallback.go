@@ -29,7 +29,7 @@ type htmlLoadingContext struct {
 	Progress stats.Progress
 }
 
-func (viewer *Viewer) htmlFallback(w http.ResponseWriter, _ *http.Request) (sent bool) {
+func (viewer *Viewer) htmlFallback(w http.ResponseWriter, r *http.Request) (sent bool) {
 	progress := viewer.Stats.Progress()
 	if progress.Done {
 		return false
@@ -38,7 +38,7 @@ func (viewer *Viewer) htmlFallback(w http.ResponseWriter, _ *http.Request) (sent
 	w.Header().Set("Content-Type", "text/html")
 	w.Header().Set("Retry-After", viewerRetrySeconds)
 	err := loadTemplate.Execute(w, htmlLoadingContext{
-		Globals:  viewer.contextGlobal(),
+		Globals:  viewer.contextGlobal(r),
 		Progress: progress,
 	})
 	if err != nil {
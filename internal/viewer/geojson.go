@@ -0,0 +1,75 @@
+//spellchecker:words viewer
+package viewer
+
+//spellchecker:words encoding json http slices sync github drincw pathbuilder hangover internal sparkl gorilla
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/geo"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/gorilla/mux"
+)
+
+//spellchecker:words geojson pathbuilder
+
+// viewerGeo caches the geojson features of the bundles of the current data.
+type viewerGeo struct {
+	m        sync.Mutex
+	cache    *sparkl.Cache // cache the features were computed for
+	features map[string][]geo.Feature
+}
+
+// getFeatures returns the features of the given top-level bundle, computing them if necessary.
+// The returned features do not have a url set, and must not be modified.
+// The caller must hold the data lock for reading.
+func (viewer *Viewer) getFeatures(bundle *pathbuilder.Bundle) []geo.Feature {
+	viewer.geo.m.Lock()
+	defer viewer.geo.m.Unlock()
+
+	if viewer.geo.cache != viewer.Cache || viewer.geo.features == nil {
+		viewer.geo.cache = viewer.Cache
+		viewer.geo.features = make(map[string][]geo.Feature)
+	}
+
+	machine := bundle.MachineName()
+	if _, ok := viewer.geo.features[machine]; !ok {
+		viewer.geo.features[machine] = viewer.Geo.Features(bundle, viewer.Cache.Entities(machine), nil)
+	}
+	return viewer.geo.features[machine]
+}
+
+func (viewer *Viewer) jsonGeoJSON(w http.ResponseWriter, r *http.Request) error {
+	if viewer.jsonFallback(w, r) {
+		return nil
+	}
+
+	vars := mux.Vars(r)
+	if !viewer.checkBundle(w, r, vars["bundle"]) {
+		return nil
+	}
+
+	bundle, ok := viewer.findBundle(vars["bundle"])
+	if !ok {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	base := viewer.absoluteURL(r) + "/entity/" + url.PathEscape(bundle.MachineName()) + "?uri="
+	features := slices.Clone(viewer.getFeatures(bundle))
+	for i := range features {
+		features[i].Properties.URL = base + url.QueryEscape(string(features[i].Properties.URI))
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(geo.NewFeatureCollection(features)); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
@@ -1,7 +1,7 @@
 //spellchecker:words viewer
 package viewer
 
-//spellchecker:words errors html template http strconv strings embed github drincw pathbuilder pbxml hangover internal assets stats triplestore impl wisski htmlx gorilla golang
+//spellchecker:words errors html template http strconv strings embed github drincw pathbuilder pbxml hangover internal access assets overview render stats triplestore impl wisski htmlx gorilla golang
 import (
 	"errors"
 	"fmt"
@@ -16,7 +16,10 @@ import (
 	"github.com/FAU-CDI/drincw/pathbuilder"
 	"github.com/FAU-CDI/drincw/pathbuilder/pbxml"
 	"github.com/FAU-CDI/hangover"
+	"github.com/FAU-CDI/hangover/internal/access"
 	"github.com/FAU-CDI/hangover/internal/assets"
+	"github.com/FAU-CDI/hangover/internal/overview"
+	"github.com/FAU-CDI/hangover/internal/render"
 	"github.com/FAU-CDI/hangover/internal/stats"
 	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
 	"github.com/FAU-CDI/hangover/internal/wisski"
@@ -31,12 +34,18 @@ var errEvenLength = errors.New("pairs must be of even length")
 
 var contextTemplateFuncs = template.FuncMap{
 	"renderhtml": func(html string, globals contextGlobal) (template.HTML, error) {
-		render, err := htmlx.ReplaceLinks(html, globals.ReplaceURL)
+		policy := globals.HTMLPolicy
+		if policy == nil {
+			policy = htmlx.DefaultPolicy()
+		}
+		sanitized, err := policy.Sanitize(html, globals.ReplaceURL)
 		if err != nil {
-			return "", fmt.Errorf("failed to replace links: %w", err)
+			return "", fmt.Errorf("failed to sanitize html: %w", err)
 		}
-		return template.HTML(render), nil // #nosec G203
+		return template.HTML(sanitized), nil // #nosec G203 -- sanitized above
 	},
+	"datevalue":   render.DateValue,
+	"numbervalue": render.NumberValue,
 	"combine": func(pairs ...any) (map[string]any, error) {
 		if len(pairs)%2 != 0 {
 			return nil, errEvenLength
@@ -123,10 +132,19 @@ type contextGlobal struct {
 	InterceptedPrefixes []string // urls that are redirected to this server
 	Footer              template.HTML
 	DisableForm         bool
+	BasePath            string        // prefix for all links to this server
+	Thumbnails          bool          // are thumbnails available for local images?
+	HTMLPolicy          *htmlx.Policy // policy to sanitize rendered html with
+
+	User     string // user making the request, empty when anonymous
+	CanLogin bool   // can the user log in?
 	RenderFlags
 }
 
 func (cg contextGlobal) ReplaceURL(u string) string {
+	if rel, ok := cg.MediaPath(u); ok {
+		return cg.BasePath + mediaURL(rel)
+	}
 	for _, prefix := range cg.InterceptedPrefixes {
 		if strings.HasPrefix(u, prefix) {
 			url, err := url.Parse(u)
@@ -136,14 +154,19 @@ func (cg contextGlobal) ReplaceURL(u string) string {
 			url.Scheme = ""
 			url.Host = ""
 			url.OmitHost = true
-			return url.String()
+			return cg.BasePath + url.String()
 		}
 	}
 	return u
 }
 
-func (viewer *Viewer) contextGlobal() (global contextGlobal) {
+func (viewer *Viewer) contextGlobal(r *http.Request) (global contextGlobal) {
 	global.Footer = viewer.Footer
+	global.BasePath = viewer.basePath(r)
+	global.Thumbnails = viewer.thumbnailsEnabled()
+	global.HTMLPolicy = viewer.htmlPolicy
+	global.User = viewer.user(r)
+	global.CanLogin = global.User == access.Anonymous && viewer.Access.Challenge() != ""
 	global.RenderFlags = viewer.RenderFlags
 	global.DisableForm = !viewer.Stats.Done()
 
@@ -163,8 +186,9 @@ func (viewer *Viewer) contextGlobal() (global contextGlobal) {
 }
 
 type htmlIndexContext struct {
-	Bundles []*pathbuilder.Bundle
-	Globals contextGlobal
+	Bundles  []*pathbuilder.Bundle
+	Overview *overview.Overview // may be nil
+	Globals  contextGlobal
 }
 
 type htmlLegalContext struct {
@@ -179,7 +203,7 @@ func (viewer *Viewer) htmlIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bundles, ok := viewer.getBundles()
+	bundles, ok := viewer.getBundles(r)
 	if !ok {
 		http.NotFound(w, r)
 		return
@@ -189,8 +213,9 @@ func (viewer *Viewer) htmlIndex(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 	err := indexTemplate.Execute(w, htmlIndexContext{
-		Globals: viewer.contextGlobal(),
-		Bundles: bundles,
+		Globals:  viewer.contextGlobal(r),
+		Bundles:  bundles,
+		Overview: viewer.getOverview(r),
 	})
 	if err != nil {
 		panic(err)
@@ -208,7 +233,7 @@ func (viewer *Viewer) htmlPerf(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 
 	err := perfTemplate.Execute(w, htmlPerfContext{
-		Globals: viewer.contextGlobal(),
+		Globals: viewer.contextGlobal(r),
 		Perf:    viewer.Perf(),
 	})
 	if err != nil {
@@ -220,7 +245,7 @@ func (viewer *Viewer) htmlLegal(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 	w.WriteHeader(http.StatusOK)
 	err := aboutTemplate.Execute(w, htmlLegalContext{
-		Globals:  viewer.contextGlobal(),
+		Globals:  viewer.contextGlobal(r),
 		License:  hangover.License,
 		Backend:  hangover.Notices,
 		Frontend: assets.Disclaimer,
@@ -245,6 +270,7 @@ type htmlBundleContext struct {
 	LastLink template.URL
 
 	URIS    []impl.Label
+	Images  map[impl.Label]*BundleImage // images to show next to entities, if any
 	Globals contextGlobal
 }
 
@@ -276,6 +302,10 @@ func (viewer *Viewer) htmlBundle(w http.ResponseWriter, r *http.Request) {
 }
 
 func (viewer *Viewer) htmlBundleWithLimit(w http.ResponseWriter, r *http.Request, bundleName string, limit, skip int) {
+	if !viewer.checkBundle(w, r, bundleName) {
+		return
+	}
+
 	bundle, entities, ok := viewer.getEntityURIs(bundleName)
 	if !ok {
 		http.NotFound(w, r)
@@ -296,7 +326,7 @@ func (viewer *Viewer) htmlBundleWithLimit(w http.ResponseWriter, r *http.Request
 
 	// prepare the context
 	context := htmlBundleContext{
-		Globals: viewer.contextGlobal(),
+		Globals: viewer.contextGlobal(r),
 
 		Total: total,
 
@@ -304,6 +334,8 @@ func (viewer *Viewer) htmlBundleWithLimit(w http.ResponseWriter, r *http.Request
 		URIS:   entities,
 	}
 
+	context.Images = viewer.bundleImages(context.Globals, bundle, entities)
+
 	context.PageStart = skip + 1
 	context.PageEnd = context.PageStart + len(entities) - 1
 
@@ -312,7 +344,7 @@ func (viewer *Viewer) htmlBundleWithLimit(w http.ResponseWriter, r *http.Request
 		if skip < 0 {
 			skip = 0
 		}
-		return template.URL("/bundle/" + url.PathEscape(bundleName) + "?limit=" + strconv.Itoa(limit) + "&" + "skip=" + strconv.Itoa(skip)) // #nosec G203
+		return template.URL(context.Globals.BasePath + "/bundle/" + url.PathEscape(bundleName) + "?limit=" + strconv.Itoa(limit) + "&" + "skip=" + strconv.Itoa(skip)) // #nosec G203
 	}
 
 	// add the previous link if there are previous pages
@@ -351,7 +383,7 @@ func (viewer *Viewer) htmlPathbuilder(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 	err := pbTemplate.Execute(w, htmlPathbuilderContext{
-		Globals:     viewer.contextGlobal(),
+		Globals:     viewer.contextGlobal(r),
 		Pathbuilder: viewer.Pathbuilder,
 	})
 	if err != nil {
@@ -386,7 +418,7 @@ func (viewer *Viewer) htmlTipsy(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 	err = tipsyTemplate.Execute(w, htmlTipsyContext{
-		Globals: viewer.contextGlobal(),
+		Globals: viewer.contextGlobal(r),
 
 		URL:      makeDataAttr("url", viewer.RenderFlags.Tipsy()),
 		Data:     makeDataAttr("data", string(xml)),
@@ -410,11 +442,14 @@ func (viewer *Viewer) htmlEntityResolve(w http.ResponseWriter, r *http.Request)
 		http.NotFound(w, r)
 		return
 	}
+	if !viewer.checkBundle(w, r, bundle) {
+		return
+	}
 
 	canon := viewer.Cache.Canonical(uri)
 
 	// redirect to the entity
-	target := "/entity/" + bundle + "?uri=" + url.PathEscape(string(canon))
+	target := viewer.basePath(r) + "/entity/" + bundle + "?uri=" + url.PathEscape(string(canon))
 	http.Redirect(w, r, target, http.StatusTemporaryRedirect)
 }
 
@@ -438,19 +473,24 @@ func (viewer *Viewer) sendToResolver(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	target := "/wisski/get?uri=" + url.PathEscape(string(uri))
+	target := viewer.basePath(r) + "/wisski/get?uri=" + url.PathEscape(string(uri))
 	http.Redirect(w, r, target, http.StatusTemporaryRedirect)
 }
 
 type htmlEntityContext struct {
 	Bundle        *pathbuilder.Bundle
 	Entity        *wisski.Entity
-	DownloadLinks struct {
-		Triples template.URL
-		Turtle  template.URL
-	}
-	Aliases []impl.Label
-	Globals contextGlobal
+	DownloadLinks *htmlDownloadLinks // nil for older versions
+	Aliases       []impl.Label
+	Versions      []htmlEntityVersion // versions the entity exists in, empty unless there are older versions
+	Changes       *htmlEntityChanges  // changes since the previous version, if any
+	Globals       contextGlobal
+}
+
+type htmlDownloadLinks struct {
+	Triples   template.URL
+	Turtle    template.URL
+	Citations []htmlCitationLink
 }
 
 func (viewer *Viewer) htmlEntity(w http.ResponseWriter, r *http.Request) {
@@ -460,23 +500,44 @@ func (viewer *Viewer) htmlEntity(w http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
 
-	bundle, entity, ok := viewer.findEntity(vars["bundle"], impl.Label(vars["uri"]))
+	versions := viewer.versions()
+	index, ok := findVersion(versions, r.URL.Query().Get("version"))
 	if !ok {
 		http.NotFound(w, r)
 		return
 	}
+	version := versions[index]
+
+	if !viewer.checkBundleIn(w, r, version.Pathbuilder, vars["bundle"]) {
+		return
+	}
+
+	bundle, entity, ok := findVersionEntity(version, vars["bundle"], impl.Label(vars["uri"]))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	entity = viewer.filterEntity(r, bundle, entity)
 
 	var context htmlEntityContext
 
-	context.Globals = viewer.contextGlobal()
+	context.Globals = viewer.contextGlobal(r)
 	context.Bundle = bundle
 	context.Entity = entity
-	context.Aliases = viewer.Cache.Aliases(entity.URI)
+	context.Aliases = version.Cache.Aliases(entity.URI)
+	context.Versions, context.Changes = viewer.entityVersions(r, versions, index, bundle, entity)
 
-	suffix := url.PathEscape(vars["bundle"]) + "?uri=" + url.QueryEscape(vars["uri"])
+	// downloads are only available for the current version
+	if index == len(versions)-1 {
+		suffix := url.PathEscape(vars["bundle"]) + "?uri=" + url.QueryEscape(vars["uri"])
 
-	context.DownloadLinks.Triples = template.URL("/api/v1/ntriples/" + suffix) // #nosec G203
-	context.DownloadLinks.Turtle = template.URL("/api/v1/turtle/" + suffix)    // #nosec G203
+		context.DownloadLinks = &htmlDownloadLinks{
+			Triples: template.URL(context.Globals.BasePath + "/api/v1/ntriples/" + suffix), // #nosec G203
+			Turtle:  template.URL(context.Globals.BasePath + "/api/v1/turtle/" + suffix),   // #nosec G203
+
+			Citations: citationLinks(context.Globals.BasePath, vars["bundle"], vars["uri"]),
+		}
+	}
 
 	w.Header().Set("Content-Type", "text/html")
 	w.WriteHeader(http.StatusOK)
@@ -40,7 +40,12 @@ func (viewer *Viewer) jsonIndex(w http.ResponseWriter, r *http.Request) error {
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(viewer.Cache.BundleNames()); err != nil {
+	bundles, _ := viewer.getBundles(r)
+	names := make([]string, len(bundles))
+	for i, bundle := range bundles {
+		names[i] = bundle.MachineName()
+	}
+	if err := json.NewEncoder(w).Encode(names); err != nil {
 		return fmt.Errorf("failed to encode json: %w", err)
 	}
 	return nil
@@ -52,6 +57,9 @@ func (viewer *Viewer) jsonBundle(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	vars := mux.Vars(r)
+	if !viewer.checkBundle(w, r, vars["bundle"]) {
+		return nil
+	}
 
 	_, uris, ok := viewer.getEntityURIs(vars["bundle"])
 	if !ok {
@@ -73,8 +81,11 @@ func (viewer *Viewer) jsonEntity(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	vars := mux.Vars(r)
+	if !viewer.checkBundle(w, r, vars["bundle"]) {
+		return nil
+	}
 
-	entity, ok := viewer.getEntity(vars["bundle"], impl.Label(vars["uri"]))
+	entity, ok := viewer.getEntity(r, vars["bundle"], impl.Label(vars["uri"]))
 	if !ok {
 		http.NotFound(w, r)
 		return nil
@@ -84,7 +95,10 @@ func (viewer *Viewer) jsonEntity(w http.ResponseWriter, r *http.Request) error {
 	w.WriteHeader(http.StatusOK)
 
 	// render the entity
-	if err := json.NewEncoder(w).Encode(entity); err != nil {
+	if err := json.NewEncoder(w).Encode(EntityWithThumbnails{
+		Entity:     entity,
+		Thumbnails: viewer.entityThumbnails(r, viewer.Pathbuilder.Bundle(vars["bundle"]), entity),
+	}); err != nil {
 		return fmt.Errorf("failed to encode json: %w", err)
 	}
 	return nil
@@ -96,8 +110,11 @@ func (viewer *Viewer) jsonNTriples(w http.ResponseWriter, r *http.Request) error
 	}
 
 	vars := mux.Vars(r)
+	if !viewer.checkBundle(w, r, vars["bundle"]) {
+		return nil
+	}
 
-	entity, ok := viewer.getEntity(vars["bundle"], impl.Label(vars["uri"]))
+	entity, ok := viewer.getEntity(r, vars["bundle"], impl.Label(vars["uri"]))
 	if !ok {
 		http.NotFound(w, r)
 		return nil
@@ -122,8 +139,11 @@ func (viewer *Viewer) jsonTurtle(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	vars := mux.Vars(r)
+	if !viewer.checkBundle(w, r, vars["bundle"]) {
+		return nil
+	}
 
-	entity, ok := viewer.getEntity(vars["bundle"], impl.Label(vars["uri"]))
+	entity, ok := viewer.getEntity(r, vars["bundle"], impl.Label(vars["uri"]))
 	if !ok {
 		http.NotFound(w, r)
 		return nil
@@ -0,0 +1,381 @@
+//spellchecker:words viewer
+package viewer
+
+//spellchecker:words encoding json errors http path slices strings sync github drincw pathbuilder hangover internal access render sparkl triplestore impl wisski htmlx
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/access"
+	"github.com/FAU-CDI/hangover/internal/render"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+	"github.com/FAU-CDI/hangover/pkg/htmlx"
+)
+
+//spellchecker:words pathbuilder itok
+
+const (
+	mediaSchemePublic = "public://"            // drupal stream wrapper for public files
+	mediaFilesPath    = "sites/default/files/" // path of public files relative to the public url
+	mediaStylesPath   = "styles/"              // path of image style derivatives relative to the files path
+)
+
+// MediaPath returns the path of the file referenced by uri relative to the media directory.
+//
+// uri may be a "public://" uri, or an url pointing to the files directory of one of the public urls.
+// Image style derivatives are mapped to their original images.
+// If uri does not reference a public file, or no media directory is configured, ok is false.
+func (rf RenderFlags) MediaPath(uri string) (rel string, ok bool) {
+	if rf.MediaDir == "" {
+		return "", false
+	}
+
+	rel, ok = strings.CutPrefix(uri, mediaSchemePublic)
+	if !ok {
+		for _, public := range rf.PublicURLs(nil) {
+			files, err := url.JoinPath(public, mediaFilesPath)
+			if err != nil {
+				continue
+			}
+			if rel, ok = strings.CutPrefix(uri, strings.TrimSuffix(files, "/")+"/"); ok {
+				break
+			}
+		}
+		if !ok {
+			return "", false
+		}
+	}
+
+	// remove the query string, e.g. "?itok=..." of image styles
+	rel, _, _ = strings.Cut(rel, "?")
+	if unescaped, err := url.PathUnescape(rel); err == nil {
+		rel = unescaped
+	}
+
+	// "styles/{style}/public/{path}" is a derivative of "{path}"
+	if style, ok := strings.CutPrefix(rel, mediaStylesPath); ok {
+		if _, original, ok := strings.Cut(style, "/public/"); ok {
+			rel = original
+		}
+	}
+
+	rel = cleanMediaPath(rel)
+	if rel == "" {
+		return "", false
+	}
+	return rel, true
+}
+
+// cleanMediaPath normalizes a path relative to the media directory.
+func cleanMediaPath(rel string) string {
+	// cleaning a rooted path removes any ".." segments
+	return strings.TrimPrefix(path.Clean("/"+rel), "/")
+}
+
+// mediaURL returns the path to the given media file on this server, without the base path.
+func mediaURL(rel string) string {
+	return (&url.URL{Path: "/media/" + rel}).EscapedPath()
+}
+
+// viewerMedia caches which bundles reference which media files, and which of them are missing, in the current data.
+type viewerMedia struct {
+	m       sync.Mutex
+	cache   *sparkl.Cache                    // cache the references were computed for
+	bundles map[string][]*pathbuilder.Bundle // bundles referencing each file, by path relative to the media directory
+
+	missing      []MissingMedia // referenced files missing from the media directory
+	foundMissing bool           // missing has been computed
+}
+
+// use discards the cached references unless they were computed for cache.
+// The caller must hold m.
+func (media *viewerMedia) use(cache *sparkl.Cache) {
+	if media.cache == cache {
+		return
+	}
+	media.cache = cache
+	media.bundles = nil
+	media.missing = nil
+	media.foundMissing = false
+}
+
+// reset discards the cached references, because the data has been replaced.
+func (media *viewerMedia) reset() {
+	media.m.Lock()
+	defer media.m.Unlock()
+
+	media.use(nil)
+}
+
+// getMediaBundles returns the bundles referencing each media file, computing them if necessary.
+// The caller must hold the data lock for reading.
+func (viewer *Viewer) getMediaBundles() map[string][]*pathbuilder.Bundle {
+	viewer.media.m.Lock()
+	defer viewer.media.m.Unlock()
+
+	viewer.media.use(viewer.Cache)
+	if viewer.media.bundles == nil {
+		bundles := make(map[string][]*pathbuilder.Bundle)
+		viewer.walkMedia(func(bundle *pathbuilder.Bundle, _ *wisski.Entity, uri string) {
+			rel, ok := viewer.RenderFlags.MediaPath(uri)
+			if !ok || slices.Contains(bundles[rel], bundle) {
+				return
+			}
+			bundles[rel] = append(bundles[rel], bundle)
+		})
+
+		viewer.media.bundles = bundles
+	}
+	return viewer.media.bundles
+}
+
+// checkMedia checks if the user making the request may see the media file rel.
+// This is the case when the file is referenced by an entity of a bundle the user may see.
+// Without an access policy, every file in the media directory may be seen.
+//
+// If the user may not see the file, an appropriate response is sent and false is returned.
+// Anonymous users are asked to authenticate, authenticated users receive a not found response.
+// The caller must hold the data lock for reading.
+func (viewer *Viewer) checkMedia(w http.ResponseWriter, r *http.Request, rel string) bool {
+	if viewer.Access == nil {
+		return true
+	}
+
+	for _, bundle := range viewer.getMediaBundles()[cleanMediaPath(rel)] {
+		if viewer.allowed(r, bundle) {
+			return true
+		}
+	}
+
+	if viewer.user(r) == access.Anonymous {
+		viewer.challenge(w, r)
+	} else {
+		http.NotFound(w, r)
+	}
+	return false
+}
+
+// serveMedia serves a file from the media directory.
+func (viewer *Viewer) serveMedia(w http.ResponseWriter, r *http.Request) {
+	rel := strings.TrimPrefix(r.URL.Path, "/media/")
+	if !viewer.checkMedia(w, r, rel) {
+		return
+	}
+
+	root, err := os.OpenRoot(viewer.RenderFlags.MediaDir)
+	if err != nil {
+		viewer.Stats.LogError("open media directory", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		if err := root.Close(); err != nil {
+			viewer.Stats.LogDebug("close media directory", "err", err)
+		}
+	}()
+
+	// os.Root rejects any path escaping the media directory
+	file, err := root.Open(rel)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			viewer.Stats.LogDebug("close media file", "err", err, "path", rel)
+		}
+	}()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	// ServeContent determines the content type and handles range requests
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+// MissingMedia describes a media file that is referenced in the data, but does not exist in the media directory.
+type MissingMedia struct {
+	URI    string     // uri referencing the file
+	Path   string     // path of the file relative to the media directory
+	Bundle string     // machine name of the bundle of the entity referencing the file
+	Entity impl.Label // entity referencing the file
+}
+
+// MissingMedia returns all media files referenced in the data that do not exist in the media directory.
+// If no media directory is configured, returns nil.
+//
+// The files are only checked once for the current data, files added to the media directory later are still reported.
+// The returned slice must not be modified.
+func (viewer *Viewer) MissingMedia() ([]MissingMedia, error) {
+	viewer.data.RLock()
+	defer viewer.data.RUnlock()
+
+	return viewer.missingMedia()
+}
+
+// LogMissingMedia logs all media files referenced in the data that do not exist in the media directory.
+// If no media directory is configured, does nothing.
+func (viewer *Viewer) LogMissingMedia() {
+	if viewer.RenderFlags.MediaDir == "" {
+		return
+	}
+
+	missing, err := viewer.MissingMedia()
+	if err != nil {
+		viewer.Stats.LogError("find missing media", err)
+		return
+	}
+	for _, m := range missing {
+		viewer.Stats.LogDebug("missing media file", "path", m.Path, "uri", m.URI, "entity", m.Entity)
+	}
+	if len(missing) > 0 {
+		viewer.Stats.Log("media files missing, see /api/v1/media/missing for details", "count", len(missing), "dir", viewer.RenderFlags.MediaDir)
+	}
+}
+
+// missingMedia implements MissingMedia, computing the missing files if necessary.
+// The caller must hold the data lock.
+func (viewer *Viewer) missingMedia() ([]MissingMedia, error) {
+	if viewer.RenderFlags.MediaDir == "" {
+		return nil, nil
+	}
+
+	viewer.media.m.Lock()
+	defer viewer.media.m.Unlock()
+
+	viewer.media.use(viewer.Cache)
+	if !viewer.media.foundMissing {
+		missing, err := viewer.findMissingMedia()
+		if err != nil {
+			return nil, err
+		}
+		viewer.media.missing = missing
+		viewer.media.foundMissing = true
+	}
+	return viewer.media.missing, nil
+}
+
+// findMissingMedia checks all media files referenced in the data.
+// The caller must hold the data lock.
+func (viewer *Viewer) findMissingMedia() (missing []MissingMedia, e error) {
+
+	root, err := os.OpenRoot(viewer.RenderFlags.MediaDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open media directory: %w", err)
+	}
+	defer func() {
+		if e2 := root.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close media directory: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	// exists caches the existence of files
+	exists := make(map[string]bool)
+	check := func(bundle *pathbuilder.Bundle, entity *wisski.Entity, uri string) {
+		rel, ok := viewer.RenderFlags.MediaPath(uri)
+		if !ok {
+			return
+		}
+
+		found, ok := exists[rel]
+		if !ok {
+			_, err := root.Stat(rel)
+			found = !errors.Is(err, fs.ErrNotExist)
+			exists[rel] = found
+		}
+		if !found {
+			missing = append(missing, MissingMedia{URI: uri, Path: rel, Bundle: bundle.MachineName(), Entity: entity.URI})
+		}
+	}
+
+	viewer.walkMedia(check)
+	return missing, nil
+}
+
+// walkMedia calls f for every uri that may reference a media file, together with the (possibly child) bundle and entity holding it.
+// The caller must hold the data lock for reading.
+func (viewer *Viewer) walkMedia(f func(bundle *pathbuilder.Bundle, entity *wisski.Entity, uri string)) {
+	var walk func(bundle *pathbuilder.Bundle, entity *wisski.Entity)
+	walk = func(bundle *pathbuilder.Bundle, entity *wisski.Entity) {
+		for _, field := range bundle.ChildFields {
+			for _, value := range entity.Fields[field.MachineName()] {
+				if viewer.RenderFlags.Renderer(field) != render.HTML {
+					f(bundle, entity, value.Datum.Value)
+					continue
+				}
+
+				// find any links inside of html
+				_, _ = htmlx.ReplaceLinks(value.Datum.Value, func(uri string) string {
+					f(bundle, entity, uri)
+					return uri
+				})
+			}
+		}
+		for _, child := range bundle.ChildBundles {
+			children := entity.Children[child.MachineName()]
+			for i := range children {
+				walk(child, &children[i])
+			}
+		}
+	}
+
+	for _, name := range viewer.Cache.BundleNames() {
+		bundle := viewer.Pathbuilder.Bundle(name)
+		if bundle == nil {
+			continue
+		}
+
+		entities := viewer.Cache.Entities(name)
+		for i := range entities {
+			walk(bundle, &entities[i])
+		}
+	}
+}
+
+func (viewer *Viewer) jsonMissingMedia(w http.ResponseWriter, r *http.Request) error {
+	if viewer.jsonFallback(w, r) {
+		return nil
+	}
+
+	missing, err := viewer.missingMedia()
+	if err != nil {
+		viewer.Stats.LogError("find missing media", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return nil
+	}
+
+	// only report files referenced by entities the user may see
+	visible := make([]MissingMedia, 0, len(missing))
+	for _, m := range missing {
+		if viewer.allowed(r, viewer.Pathbuilder.FindBundle(m.Bundle)) {
+			visible = append(visible, m)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(visible); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+//spellchecker:words viewer
+package viewer
+
+//spellchecker:words embed encoding json html template http github hangover internal assets overview
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/FAU-CDI/hangover/internal/assets"
+	"github.com/FAU-CDI/hangover/internal/overview"
+)
+
+// getOverview returns the overview of the current data, only including the bundles the user making the request may see.
+// Global statistics are only included if the user may see every bundle.
+// Returns nil if no overview is available.
+func (viewer *Viewer) getOverview(r *http.Request) *overview.Overview {
+	if viewer.Access == nil || viewer.overview == nil || viewer.allowedAll(r) {
+		return viewer.overview
+	}
+
+	return viewer.overview.Filter(func(machine string) bool {
+		bundle := viewer.Pathbuilder.Bundle(machine)
+		return bundle == nil || viewer.allowed(r, bundle)
+	})
+}
+
+//go:embed templates/az.html
+var azHTML string
+
+var azTemplate *template.Template = assets.Assetshangover.MustParseShared(
+	"az.html",
+	azHTML,
+	contextTemplateFuncs,
+)
+
+type htmlAZContext struct {
+	Globals contextGlobal
+	Letters []overview.Letter
+	Letter  string           // selected letter, if any
+	Entries []overview.Entry // entries of the selected letter
+	Names   map[string]string
+}
+
+func (viewer *Viewer) htmlAZ(w http.ResponseWriter, r *http.Request) {
+	if viewer.htmlFallback(w, r) {
+		return
+	}
+
+	summary := viewer.getOverview(r)
+	if summary == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	context := htmlAZContext{
+		Globals: viewer.contextGlobal(r),
+		Letters: summary.Letters,
+		Letter:  r.URL.Query().Get("letter"),
+		Names:   make(map[string]string, len(summary.Bundles)),
+	}
+	for _, bundle := range summary.Bundles {
+		context.Names[bundle.MachineName] = bundle.Name
+	}
+	if context.Letter != "" {
+		context.Entries = summary.Letter(context.Letter)
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+	if err := azTemplate.Execute(w, context); err != nil {
+		viewer.Stats.LogError("render a-z index", err)
+	}
+}
+
+func (viewer *Viewer) jsonOverview(w http.ResponseWriter, r *http.Request) error {
+	if viewer.jsonFallback(w, r) {
+		return nil
+	}
+
+	summary := viewer.getOverview(r)
+	if summary == nil {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	// entries are served by the a-z endpoint
+	response := *summary
+	response.Entries = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
+
+func (viewer *Viewer) jsonAZ(w http.ResponseWriter, r *http.Request) error {
+	if viewer.jsonFallback(w, r) {
+		return nil
+	}
+
+	summary := viewer.getOverview(r)
+	if summary == nil {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	// without a letter, list the letters only
+	var response any = summary.Letters
+	if letter := r.URL.Query().Get("letter"); letter != "" {
+		response = summary.Letter(letter)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+//spellchecker:words viewer
+package viewer
+
+//spellchecker:words embed encoding json html template http slices github hangover internal assets problems triplestore impl
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+
+	"github.com/FAU-CDI/hangover/internal/assets"
+	"github.com/FAU-CDI/hangover/internal/problems"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+)
+
+// filterProblems returns a copy of report that only lists entities in bundles the user making the request may see.
+// Orphaned nodes are in no bundle.
+// Unless the user may see every bundle, they are only listed if they have the class of a bundle the user may see, and none of a bundle they may not see.
+// The total number of problems is not changed.
+func (viewer *Viewer) filterProblems(r *http.Request, report *problems.Report) *problems.Report {
+	if viewer.Access == nil || report == nil || viewer.allowedAll(r) {
+		return report
+	}
+
+	allowed := viewer.allowedBundles(r)
+	visible := func(machine string) bool {
+		bundle := viewer.Pathbuilder.Bundle(machine)
+		return bundle == nil || allowed[bundle]
+	}
+
+	// classes holds if all bundles with a given class are visible
+	classes := make(map[impl.Label]bool)
+	for bundle, ok := range allowed {
+		if len(bundle.PathArray) == 0 {
+			continue
+		}
+		class := impl.Label(bundle.PathArray[len(bundle.PathArray)-1])
+		if previous, seen := classes[class]; seen {
+			ok = ok && previous
+		}
+		classes[class] = ok
+	}
+
+	filtered := *report
+	filtered.Dangling = slices.DeleteFunc(slices.Clone(report.Dangling), func(reference problems.Reference) bool {
+		bundle, ok := viewer.Cache.Bundle(reference.Subject)
+		return ok && !visible(bundle)
+	})
+	filtered.Orphaned = slices.DeleteFunc(slices.Clone(report.Orphaned), func(node problems.Node) bool {
+		var matched bool
+		for _, class := range node.Types {
+			ok, seen := classes[class]
+			if seen && !ok {
+				return true
+			}
+			matched = matched || seen
+		}
+		return !matched
+	})
+	filtered.Duplicates = slices.DeleteFunc(slices.Clone(report.Duplicates), func(duplicate problems.Duplicate) bool {
+		return slices.ContainsFunc(duplicate.Bundles, func(machine string) bool { return !visible(machine) })
+	})
+	return &filtered
+}
+
+//go:embed templates/problems.html
+var problemsHTML string
+
+var problemsTemplate *template.Template = assets.Assetshangover.MustParseShared(
+	"problems.html",
+	problemsHTML,
+	contextTemplateFuncs,
+)
+
+type htmlProblemsContext struct {
+	Globals  contextGlobal
+	Problems *problems.Report
+}
+
+func (viewer *Viewer) htmlProblems(w http.ResponseWriter, r *http.Request) {
+	if viewer.htmlFallback(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+	err := problemsTemplate.Execute(w, htmlProblemsContext{
+		Globals:  viewer.contextGlobal(r),
+		Problems: viewer.filterProblems(r, viewer.problems),
+	})
+	if err != nil {
+		viewer.Stats.LogError("render problems", err)
+	}
+}
+
+func (viewer *Viewer) jsonProblems(w http.ResponseWriter, r *http.Request) error {
+	if viewer.jsonFallback(w, r) {
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(viewer.filterProblems(r, viewer.problems)); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
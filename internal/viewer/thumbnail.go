@@ -0,0 +1,153 @@
+//spellchecker:words viewer
+package viewer
+
+//spellchecker:words bytes errors http path strings github drincw pathbuilder hangover internal render thumbnail triplestore impl wisski
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/render"
+	"github.com/FAU-CDI/hangover/internal/thumbnail"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+// thumbnailsEnabled checks if thumbnails are generated for locally available images.
+func (viewer *Viewer) thumbnailsEnabled() bool {
+	return viewer.Thumbnails != nil && viewer.RenderFlags.MediaDir != ""
+}
+
+// ThumbnailURL returns the url of the thumbnail of the image referenced by uri.
+// If no thumbnail is available, returns the empty string.
+func (cg contextGlobal) ThumbnailURL(uri string) string {
+	if !cg.Thumbnails {
+		return ""
+	}
+	rel, ok := cg.MediaPath(uri)
+	if !ok || !thumbnail.Supported(rel) {
+		return ""
+	}
+	return cg.BasePath + (&url.URL{Path: "/thumbnail/" + rel}).EscapedPath()
+}
+
+// serveThumbnail serves a thumbnail of an image from the media directory.
+func (viewer *Viewer) serveThumbnail(w http.ResponseWriter, r *http.Request) {
+	rel := strings.TrimPrefix(r.URL.Path, "/thumbnail/")
+	if !viewer.checkMedia(w, r, rel) {
+		return
+	}
+
+	th, err := viewer.Thumbnails.Get(rel)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) || errors.Is(err, thumbnail.ErrUnsupported):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, thumbnail.ErrTooLarge):
+		// too large to generate a thumbnail, use the original instead
+		http.Redirect(w, r, viewer.basePath(r)+mediaURL(rel), http.StatusTemporaryRedirect)
+		return
+	case err != nil:
+		viewer.Stats.LogError("generate thumbnail", err, "path", rel)
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", th.ContentType)
+	http.ServeContent(w, r, path.Base(rel), th.ModTime, bytes.NewReader(th.Data))
+}
+
+// BundleImage is an image shown next to an entity in a bundle listing.
+type BundleImage struct {
+	URL       string // url of the full image
+	Thumbnail string // url of the thumbnail
+}
+
+// bundleImages returns the first image of each of the given entities that has a thumbnail.
+func (viewer *Viewer) bundleImages(global contextGlobal, bundle *pathbuilder.Bundle, uris []impl.Label) map[impl.Label]*BundleImage {
+	if !global.Thumbnails || !global.ImageRender {
+		return nil
+	}
+
+	var fields []string
+	for _, field := range bundle.ChildFields {
+		if global.Renderer(field) == render.Image {
+			fields = append(fields, field.MachineName())
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	images := make(map[impl.Label]*BundleImage)
+	for _, uri := range uris {
+		entity, ok := viewer.Cache.Entity(uri, bundle.MachineName())
+		if !ok {
+			continue
+		}
+
+	fields:
+		for _, field := range fields {
+			for _, value := range entity.Fields[field] {
+				if thumb := global.ThumbnailURL(value.Datum.Value); thumb != "" {
+					images[uri] = &BundleImage{URL: global.ReplaceURL(value.Datum.Value), Thumbnail: thumb}
+					break fields
+				}
+			}
+		}
+	}
+	return images
+}
+
+// EntityWithThumbnails is an entity together with the thumbnails of its images.
+type EntityWithThumbnails struct {
+	*wisski.Entity
+	Thumbnails map[string]string `json:",omitempty"` // thumbnail urls by image uri
+}
+
+// entityThumbnails returns the thumbnail urls of all images inside entity and its children.
+func (viewer *Viewer) entityThumbnails(r *http.Request, bundle *pathbuilder.Bundle, entity *wisski.Entity) map[string]string {
+	if !viewer.thumbnailsEnabled() {
+		return nil
+	}
+	global := contextGlobal{
+		BasePath:    viewer.basePath(r),
+		Thumbnails:  true,
+		RenderFlags: viewer.RenderFlags,
+	}
+
+	thumbnails := make(map[string]string)
+
+	var walk func(bundle *pathbuilder.Bundle, entity *wisski.Entity)
+	walk = func(bundle *pathbuilder.Bundle, entity *wisski.Entity) {
+		for _, field := range bundle.ChildFields {
+			if global.Renderer(field) != render.Image {
+				continue
+			}
+			for _, value := range entity.Fields[field.MachineName()] {
+				if thumb := global.ThumbnailURL(value.Datum.Value); thumb != "" {
+					thumbnails[value.Datum.Value] = thumb
+				}
+			}
+		}
+		for _, child := range bundle.ChildBundles {
+			children := entity.Children[child.MachineName()]
+			for i := range children {
+				walk(child, &children[i])
+			}
+		}
+	}
+	walk(bundle, entity)
+
+	if len(thumbnails) == 0 {
+		return nil
+	}
+	return thumbnails
+}
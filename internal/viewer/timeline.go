@@ -0,0 +1,165 @@
+//spellchecker:words viewer
+package viewer
+
+//spellchecker:words embed encoding json html template http strconv sync github drincw pathbuilder hangover internal assets sparkl timeline gorilla
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/assets"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/timeline"
+	"github.com/gorilla/mux"
+)
+
+//spellchecker:words pathbuilder
+
+// viewerTimelines caches the timelines of the bundles of the current data.
+type viewerTimelines struct {
+	m         sync.Mutex
+	cache     *sparkl.Cache // cache the timelines were computed for
+	timelines map[string]*timeline.Timeline
+}
+
+// getTimeline returns the timeline of the given top-level bundle, computing it if necessary.
+// The caller must hold the data lock for reading.
+func (viewer *Viewer) getTimeline(bundle *pathbuilder.Bundle) *timeline.Timeline {
+	viewer.timelines.m.Lock()
+	defer viewer.timelines.m.Unlock()
+
+	if viewer.timelines.cache != viewer.Cache || viewer.timelines.timelines == nil {
+		viewer.timelines.cache = viewer.Cache
+		viewer.timelines.timelines = make(map[string]*timeline.Timeline)
+	}
+
+	machine := bundle.MachineName()
+	if _, ok := viewer.timelines.timelines[machine]; !ok {
+		viewer.timelines.timelines[machine] = timeline.New(bundle, viewer.Cache.Entities(machine))
+	}
+	return viewer.timelines.timelines[machine]
+}
+
+//go:embed templates/timeline.html
+var timelineHTML string
+
+var timelineTemplate *template.Template = assets.Assetshangover.MustParseShared(
+	"timeline.html",
+	timelineHTML,
+	contextTemplateFuncs,
+)
+
+type htmlTimelineContext struct {
+	Globals  contextGlobal
+	Bundle   *pathbuilder.Bundle
+	Timeline *timeline.Timeline
+
+	Decades []timeline.Bucket // all decades, unless a decade or year is selected
+	Decade  int               // selected decade, if any
+	Years   []timeline.Bucket // years of the selected decade
+	Year    int               // selected year, if any
+	Events  []timeline.Event  // events of the selected year
+
+	Max int // largest count of any bucket shown
+}
+
+// timelineQuery parses an optional year from the query parameter with the given name.
+func timelineQuery(r *http.Request, name string) (year int, ok bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, false
+	}
+	year, err := strconv.Atoi(value)
+	if err != nil || year < 0 {
+		return 0, false
+	}
+	return year, true
+}
+
+func (viewer *Viewer) htmlTimeline(w http.ResponseWriter, r *http.Request) {
+	if viewer.htmlFallback(w, r) {
+		return
+	}
+
+	vars := mux.Vars(r)
+	if !viewer.checkBundle(w, r, vars["bundle"]) {
+		return
+	}
+
+	bundle, ok := viewer.findBundle(vars["bundle"])
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	context := htmlTimelineContext{
+		Globals:  viewer.contextGlobal(r),
+		Bundle:   bundle,
+		Timeline: viewer.getTimeline(bundle),
+	}
+
+	// zoom into the selected year or decade
+	var buckets []timeline.Bucket
+	if year, ok := timelineQuery(r, "year"); ok {
+		context.Year = year
+		context.Decade = year - year%10
+		context.Events = context.Timeline.In(year)
+	} else if decade, ok := timelineQuery(r, "decade"); ok {
+		context.Decade = decade - decade%10
+		context.Years = context.Timeline.Years(decade)
+		buckets = context.Years
+	} else {
+		context.Decades = context.Timeline.Decades()
+		buckets = context.Decades
+	}
+	for _, bucket := range buckets {
+		context.Max = max(context.Max, bucket.Count)
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+	if err := timelineTemplate.Execute(w, context); err != nil {
+		viewer.Stats.LogError("render timeline", err)
+	}
+}
+
+// jsonTimeline holds the timeline of a bundle returned by the json api.
+type jsonTimeline struct {
+	Fields []timeline.Field
+	Events []timeline.Event
+}
+
+func (viewer *Viewer) jsonTimeline(w http.ResponseWriter, r *http.Request) error {
+	if viewer.jsonFallback(w, r) {
+		return nil
+	}
+
+	vars := mux.Vars(r)
+	if !viewer.checkBundle(w, r, vars["bundle"]) {
+		return nil
+	}
+
+	bundle, ok := viewer.findBundle(vars["bundle"])
+	if !ok {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	tl := viewer.getTimeline(bundle)
+	response := jsonTimeline{Fields: tl.Fields, Events: tl.Events}
+	if year, ok := timelineQuery(r, "year"); ok {
+		response.Events = tl.In(year)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
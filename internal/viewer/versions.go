@@ -0,0 +1,163 @@
+//spellchecker:words viewer
+package viewer
+
+//spellchecker:words errors html template http slices github drincw pathbuilder hangover internal diff sparkl triplestore impl wisski
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/FAU-CDI/drincw/pathbuilder"
+	"github.com/FAU-CDI/hangover/internal/diff"
+	"github.com/FAU-CDI/hangover/internal/sparkl"
+	"github.com/FAU-CDI/hangover/internal/triplestore/impl"
+	"github.com/FAU-CDI/hangover/internal/wisski"
+)
+
+//spellchecker:words pathbuilder
+
+// DefaultVersionName is the name of the current version, unless [Viewer.VersionName] is set.
+const DefaultVersionName = "current"
+
+// Version is an older version of the dataset served by a viewer.
+type Version struct {
+	Name        string // name of the version, e.g. the date it was exported
+	Cache       *sparkl.Cache
+	Pathbuilder *pathbuilder.Pathbuilder
+}
+
+// SetVersions replaces the older versions of the dataset, oldest first.
+//
+// SetVersions waits for all requests to finish, and then closes the caches of the previous versions.
+// It must not be called from within a request handler.
+func (viewer *Viewer) SetVersions(versions []Version) error {
+	old := func() []Version {
+		viewer.data.Lock()
+		defer viewer.data.Unlock()
+
+		old := viewer.Versions
+		viewer.Versions = versions
+		return old
+	}()
+
+	return closeVersions(old)
+}
+
+func closeVersions(versions []Version) error {
+	errs := make([]error, 0, len(versions))
+	for _, version := range versions {
+		if err := version.Cache.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close cache of version %q: %w", version.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// versions returns all versions of the dataset, including the current version, oldest first.
+func (viewer *Viewer) versions() []Version {
+	name := viewer.VersionName
+	if name == "" {
+		name = DefaultVersionName
+	}
+	return append(slices.Clip(viewer.Versions), Version{Name: name, Cache: viewer.Cache, Pathbuilder: viewer.Pathbuilder})
+}
+
+// findVersion returns the index of the version with the given name within versions.
+// The empty name refers to the current version.
+func findVersion(versions []Version, name string) (index int, ok bool) {
+	if name == "" {
+		return len(versions) - 1, true
+	}
+	index = slices.IndexFunc(versions, func(version Version) bool { return version.Name == name })
+	return index, index >= 0
+}
+
+// htmlEntityVersion is a version of an entity shown in the version selector.
+type htmlEntityVersion struct {
+	Name     string
+	URL      template.URL
+	Selected bool // is this the version being shown?
+}
+
+// htmlEntityChanges holds the changes of an entity since the previous version.
+type htmlEntityChanges struct {
+	Previous string       // name of the previous version
+	New      bool         // the entity did not exist in the previous version
+	Fields   []diff.Field // changed fields and child bundles
+}
+
+// entityVersions returns the versions the given entity exists in, and the changes since the previous version.
+// versions are all versions of the dataset, and index is the index of the version the entity belongs to.
+//
+// If there are no older versions of the dataset, returns nil.
+func (viewer *Viewer) entityVersions(r *http.Request, versions []Version, index int, bundle *pathbuilder.Bundle, entity *wisski.Entity) ([]htmlEntityVersion, *htmlEntityChanges) {
+	if len(versions) < 2 {
+		return nil, nil
+	}
+
+	machine := bundle.MachineName()
+	current := versions[index]
+
+	// find the entity in every version
+	var selector []htmlEntityVersion
+	var previous *wisski.Entity
+	for i, version := range versions {
+		other := entity
+		if i != index {
+			vBundle := version.Pathbuilder.Bundle(machine)
+			if vBundle == nil || !viewer.allowed(r, vBundle) {
+				continue
+			}
+			other = diff.Comparer{Before: version.Cache, After: current.Cache}.Match(entity.URI, machine)
+			if other == nil {
+				continue
+			}
+		}
+		if i == index-1 {
+			previous = other
+		}
+
+		target := viewer.basePath(r) + "/entity/" + url.PathEscape(machine) + "?uri=" + url.QueryEscape(string(other.URI))
+		if i != len(versions)-1 {
+			target += "&version=" + url.QueryEscape(version.Name)
+		}
+		selector = append(selector, htmlEntityVersion{
+			Name:     version.Name,
+			URL:      template.URL(target), // #nosec G203 -- escaped above
+			Selected: i == index,
+		})
+	}
+
+	// no previous version to compare to
+	if index == 0 {
+		return selector, nil
+	}
+
+	changes := &htmlEntityChanges{Previous: versions[index-1].Name}
+	if previous == nil {
+		changes.New = true
+		return selector, changes
+	}
+
+	prevBundle := versions[index-1].Pathbuilder.Bundle(machine)
+	previous = viewer.filterEntity(r, prevBundle, previous)
+	changes.Fields = diff.Comparer{Before: versions[index-1].Cache, After: current.Cache}.Fields(prevBundle, bundle, previous, entity)
+	return selector, changes
+}
+
+// findVersionEntity finds an entity within the given version.
+func findVersionEntity(version Version, machine string, uri impl.Label) (bundle *pathbuilder.Bundle, entity *wisski.Entity, ok bool) {
+	bundle = version.Pathbuilder.Bundle(machine)
+	if bundle == nil {
+		return nil, nil, false
+	}
+
+	entity, ok = version.Cache.Entity(uri, bundle.MachineName())
+	if !ok {
+		return nil, nil, false
+	}
+	return bundle, entity, true
+}
@@ -1,7 +1,7 @@
 //spellchecker:words viewer
 package viewer
 
-//spellchecker:words bytes html template http strings sync time github drincw pathbuilder hangover internal assets sparkl stats gorilla pkglib text
+//spellchecker:words bytes html template http strings sync time github drincw pathbuilder hangover internal access assets citation geo overview problems render sparkl stats thumbnail triplestore igraph htmlx gorilla pkglib text
 import (
 	"bytes"
 	"fmt"
@@ -15,9 +15,18 @@ import (
 
 	"github.com/FAU-CDI/drincw/pathbuilder"
 	"github.com/FAU-CDI/hangover"
+	"github.com/FAU-CDI/hangover/internal/access"
 	"github.com/FAU-CDI/hangover/internal/assets"
+	"github.com/FAU-CDI/hangover/internal/citation"
+	"github.com/FAU-CDI/hangover/internal/geo"
+	"github.com/FAU-CDI/hangover/internal/overview"
+	"github.com/FAU-CDI/hangover/internal/problems"
+	"github.com/FAU-CDI/hangover/internal/render"
 	"github.com/FAU-CDI/hangover/internal/sparkl"
 	"github.com/FAU-CDI/hangover/internal/stats"
+	"github.com/FAU-CDI/hangover/internal/thumbnail"
+	"github.com/FAU-CDI/hangover/internal/triplestore/igraph"
+	"github.com/FAU-CDI/hangover/pkg/htmlx"
 	"github.com/gorilla/mux"
 	"github.com/tkw1536/pkglib/text"
 )
@@ -26,14 +35,40 @@ import (
 
 // Viewer implements an [http.Handler] that displays WissKI Entities.
 type Viewer struct {
-	Stats *stats.Stats // Stats holds the current stats of the viewer
+	Stats *stats.Stats // Stats holds the current stats of the viewer; it is replaced together with the data by [Viewer.Replace]
 
-	mux       mux.Router
-	cspHeader string
+	mux        mux.Router
+	cspHeader  string
+	htmlPolicy *htmlx.Policy
 
+	data        sync.RWMutex // held for reading while serving requests, and for writing while replacing Cache and Pathbuilder
 	Cache       *sparkl.Cache
 	Pathbuilder *pathbuilder.Pathbuilder
 	RenderFlags RenderFlags
+	index       igraph.Stats       // statistics of the index Cache was extracted from
+	problems    *problems.Report   // problems found in the data Cache was extracted from; may be nil
+	overview    *overview.Overview // overview of the data Cache was extracted from; may be nil
+
+	coverage  viewerCoverage
+	timelines viewerTimelines
+	geo       viewerGeo
+	media     viewerMedia
+
+	Versions    []Version // older versions of the dataset, oldest first; see [Viewer.SetVersions]
+	VersionName string    // name of the current version; defaults to [DefaultVersionName]
+
+	AdminToken string   // bearer token required for admin endpoints; when empty admin endpoints are disabled
+	Reloader   Reloader // reloads the dataset in the background; may be nil
+
+	Access *access.Policy // determines who may see which bundles; nil allows everyone to see everything
+
+	Citations citation.Mappings // determines which fields entities are cited with; bundles without a mapping use their first field as title
+	Geo       geo.Mappings      // determines which fields hold geometries; bundles without a mapping have them detected automatically
+
+	Thumbnails *thumbnail.Thumbnailer // generates thumbnails of images in the media directory; may be nil
+
+	BasePath             string // path prefix the viewer is served under, e.g. "/archive/kirmes"
+	TrustForwardedPrefix bool   // additionally prefix links with the X-Forwarded-Prefix header set by a reverse proxy
 
 	Footer template.HTML // html to include in footer of every page
 	init   sync.Once
@@ -54,9 +89,16 @@ func (viewer *Viewer) Close() error {
 	if viewer == nil {
 		return nil
 	}
+
+	viewer.data.Lock()
+	defer viewer.data.Unlock()
+
 	if err := viewer.Cache.Close(); err != nil {
 		return fmt.Errorf("failed to close cache: %w", err)
 	}
+	if err := closeVersions(viewer.Versions); err != nil {
+		return fmt.Errorf("failed to close versions: %w", err)
+	}
 	return nil
 }
 
@@ -64,9 +106,18 @@ type RenderFlags struct {
 	PublicURL   string
 	TipsyURL    string
 	Predicates  sparkl.Predicates
-	StrictCSP   bool // use strict content-security-policy for images and media by only allowing content from public uris
-	HTMLRender  bool
-	ImageRender bool
+	StrictCSP   bool   // use strict content-security-policy for images and media by only allowing content from public uris
+	HTMLRender  bool   // render fields using [render.HTML] as sanitized html instead of text
+	HTMLStyles  bool   // keep inline styles when rendering html; requires a relaxed content-security-policy
+	ImageRender bool   // render fields using [render.Image] as images instead of links
+	MediaDir    string // directory holding a local mirror of public files, see [RenderFlags.MediaPath]
+
+	Renderers render.Registry // determines how the values of each field are rendered
+}
+
+// Renderer returns the renderer to use for values of the given field.
+func (rf RenderFlags) Renderer(field pathbuilder.Field) render.Renderer {
+	return rf.Renderers.For(field)
 }
 
 func (rf RenderFlags) PublicURLs(onError func(string, error)) (public []string) {
@@ -89,6 +140,13 @@ func (rf RenderFlags) PublicURLs(onError func(string, error)) (public []string)
 	return public
 }
 
+// HTMLPolicy returns the policy used to sanitize html content.
+func (rf RenderFlags) HTMLPolicy() *htmlx.Policy {
+	policy := htmlx.DefaultPolicy()
+	policy.Styles = rf.HTMLStyles
+	return policy
+}
+
 func (rf RenderFlags) Tipsy() string {
 	if strings.HasPrefix(rf.TipsyURL, "http://") || strings.HasPrefix(rf.TipsyURL, "https://") {
 		return rf.TipsyURL
@@ -101,8 +159,8 @@ func (rf RenderFlags) CSPHeader(onURIError func(string, error)) string {
 	// don't allow anything by default
 	header := "default-src 'none'; connect-src 'self'; script-src 'self' 'wasm-unsafe-eval'; font-src 'self'; "
 
-	if rf.HTMLRender {
-		// when rendering html, we explicitly want to allow inline styles.
+	if rf.HTMLRender && rf.HTMLStyles {
+		// when rendering html with styles, we explicitly want to allow inline styles.
 		header += "style-src 'self' 'unsafe-inline'; "
 	} else {
 		// by default only allow self styles
@@ -119,6 +177,10 @@ func (rf RenderFlags) CSPHeader(onURIError func(string, error)) string {
 	if rf.StrictCSP {
 		source = strings.Join(rf.PublicURLs(onURIError), " ")
 	}
+	if rf.MediaDir != "" {
+		// media is served locally
+		source = "'self' " + source
+	}
 
 	if rf.ImageRender || rf.HTMLRender {
 		header += "img-src " + source + ";"
@@ -143,15 +205,22 @@ func (viewer *Viewer) setupMux() {
 		viewer.mux.HandleFunc("/", viewer.htmlIndex)
 		viewer.mux.HandleFunc("/about", viewer.htmlLegal)
 		viewer.mux.HandleFunc("/pathbuilder", viewer.htmlPathbuilder)
+		if viewer.Access.Challenge() != "" {
+			viewer.mux.HandleFunc("/login", viewer.htmlLogin)
+		}
 
 		if viewer.RenderFlags.Tipsy() != "" {
 			viewer.mux.HandleFunc("/tipsy", viewer.htmlTipsy)
 		}
 		viewer.mux.HandleFunc("/perf", viewer.htmlPerf)
+		viewer.mux.HandleFunc("/coverage", viewer.htmlCoverage)
+		viewer.mux.HandleFunc("/problems", viewer.htmlProblems)
+		viewer.mux.HandleFunc("/az", viewer.htmlAZ)
 
 		viewer.mux.HandleFunc("/bundle/{bundle}", viewer.htmlBundle).Queries("limit", "{limit:\\d+}", "skip", "{skip:\\d+}")
 		viewer.mux.HandleFunc("/bundle/{bundle}", viewer.htmlBundle)
 
+		viewer.mux.HandleFunc("/timeline/{bundle}", viewer.htmlTimeline)
 		viewer.mux.HandleFunc("/entity/{bundle}", viewer.htmlEntity).Queries("uri", "{uri:.+}")
 
 		viewer.mux.HandleFunc("/wisski/get", viewer.htmlEntityResolve).Queries("uri", "{uri:.+}")
@@ -160,11 +229,30 @@ func (viewer *Viewer) setupMux() {
 		viewer.mux.HandleFunc("/api/v1", viewer.handlerError(viewer.jsonIndex))
 		viewer.mux.HandleFunc("/api/v1/progress", viewer.handlerError(viewer.jsonProgress))
 		viewer.mux.HandleFunc("/api/v1/perf", viewer.handlerError(viewer.jsonPerf))
+		viewer.mux.HandleFunc("/api/v1/coverage", viewer.handlerError(viewer.jsonCoverage))
+		viewer.mux.HandleFunc("/api/v1/problems", viewer.handlerError(viewer.jsonProblems))
+		viewer.mux.HandleFunc("/api/v1/overview", viewer.handlerError(viewer.jsonOverview))
+		viewer.mux.HandleFunc("/api/v1/az", viewer.handlerError(viewer.jsonAZ))
 		viewer.mux.HandleFunc("/api/v1/bundle/{bundle}", viewer.handlerError(viewer.jsonBundle))
+		viewer.mux.HandleFunc("/api/v1/timeline/{bundle}", viewer.handlerError(viewer.jsonTimeline))
+		viewer.mux.HandleFunc("/api/v1/geojson/{bundle}", viewer.handlerError(viewer.jsonGeoJSON))
 		viewer.mux.HandleFunc("/api/v1/entity/{bundle}", viewer.handlerError(viewer.jsonEntity)).Queries("uri", "{uri:.+}")
 
 		viewer.mux.HandleFunc("/api/v1/ntriples/{bundle}", viewer.handlerError(viewer.jsonNTriples)).Queries("uri", "{uri:.+}")
 		viewer.mux.HandleFunc("/api/v1/turtle/{bundle}", viewer.handlerError(viewer.jsonTurtle)).Queries("uri", "{uri:.+}")
+		viewer.mux.HandleFunc("/api/v1/cite/{bundle}", viewer.handlerError(viewer.jsonCite)).Queries("uri", "{uri:.+}")
+
+		if viewer.AdminToken != "" && viewer.Reloader != nil {
+			viewer.mux.HandleFunc("/api/v1/admin/reload", viewer.handlerError(viewer.jsonReload)).Methods(http.MethodGet, http.MethodPost)
+		}
+
+		if viewer.RenderFlags.MediaDir != "" {
+			viewer.mux.PathPrefix("/media/").HandlerFunc(viewer.serveMedia)
+			viewer.mux.HandleFunc("/api/v1/media/missing", viewer.handlerError(viewer.jsonMissingMedia))
+		}
+		if viewer.thumbnailsEnabled() {
+			viewer.mux.PathPrefix("/thumbnail/").HandlerFunc(viewer.serveThumbnail)
+		}
 
 		viewer.mux.PathPrefix("/assets/").Handler(assets.AssetHandler)
 
@@ -174,12 +262,19 @@ func (viewer *Viewer) setupMux() {
 		})
 
 		viewer.cspHeader = viewer.RenderFlags.CSPHeader(viewer.logPublicURI)
+		viewer.htmlPolicy = viewer.RenderFlags.HTMLPolicy()
 	})
 }
 func (viewer *Viewer) Prepare(cache *sparkl.Cache, pb *pathbuilder.Pathbuilder) {
 	if !viewer.Stats.Done() {
+		viewer.data.Lock()
 		viewer.Cache = cache
 		viewer.Pathbuilder = pb
+		viewer.index = viewer.Stats.IndexStats()
+		viewer.problems = viewer.Stats.Problems()
+		viewer.overview = viewer.Stats.Overview()
+		viewer.data.Unlock()
+
 		viewer.Stats.Close()
 	}
 
@@ -189,6 +284,19 @@ func (viewer *Viewer) Prepare(cache *sparkl.Cache, pb *pathbuilder.Pathbuilder)
 func (viewer *Viewer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	viewer.setupMux()
 
+	// hold on to the current data until the request is done
+	viewer.data.RLock()
+	defer viewer.data.RUnlock()
+
 	w.Header().Set("Content-Security-Policy", viewer.cspHeader)
+
+	r, ok := viewer.stripBasePath(w, r)
+	if !ok {
+		return
+	}
+	r, ok = viewer.authenticate(w, r)
+	if !ok {
+		return
+	}
 	viewer.mux.ServeHTTP(w, r)
 }
@@ -15,7 +15,7 @@ import (
 //spellchecker:words htmlx twiesing
 
 // ReplaceLinks parses source as a html fragment, and then replaces all paths
-// inside <a> and <img> elements with the replace function.
+// inside <a>, <img>, <audio>, <video> and <source> elements with the replace function.
 func ReplaceLinks(source string, replace func(string) string) (string, error) {
 	// NOTE(twiesing): we should better define what exactly this means
 	// and parse all sorts of other elements
@@ -33,7 +33,7 @@ func ReplaceLinks(source string, replace func(string) string) (string, error) {
 			if node.Type == html.ElementNode && node.Data == "a" {
 				replaceAttr(node.Attr, "href", replace)
 			}
-			if node.Type == html.ElementNode && node.Data == "img" {
+			if node.Type == html.ElementNode && (node.Data == "img" || node.Data == "audio" || node.Data == "video" || node.Data == "source") {
 				replaceAttr(node.Attr, "src", replace)
 			}
 		}
@@ -0,0 +1,182 @@
+//spellchecker:words htmlx
+package htmlx
+
+//spellchecker:words strings golang html atom
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+//spellchecker:words abbr bdi bdo blockquote colgroup colspan datetime dfn figcaption kbd rowspan samp tbody tfoot thead noscript frameset applet iframe mailto
+
+// Policy determines which parts of an html fragment are kept by [Policy.Sanitize].
+//
+// Elements not mentioned in the policy are unwrapped, that is replaced by their (sanitized) content.
+// Comments are always removed.
+type Policy struct {
+	Elements          map[string]bool            // elements to keep
+	Drop              map[string]bool            // elements to remove together with their content
+	Attributes        map[string]bool            // attributes to keep on any element
+	ElementAttributes map[string]map[string]bool // attributes to keep on specific elements
+	URLAttributes     map[string]bool            // attributes that contain urls
+	URLSchemes        map[string]bool            // schemes allowed in url attributes; relative urls are always allowed
+	Styles            bool                       // keep 'style' attributes
+}
+
+func set(values ...string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
+
+// DefaultPolicy returns a new policy that keeps text formatting, lists, tables, links, images and media,
+// but removes scripts, event handlers, styles, frames, forms and embedded objects.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Elements: set(
+			"a", "abbr", "b", "bdi", "bdo", "blockquote", "br", "caption", "cite", "code", "col", "colgroup",
+			"dd", "del", "details", "dfn", "div", "dl", "dt", "em", "figcaption", "figure",
+			"h1", "h2", "h3", "h4", "h5", "h6", "hr", "i", "img", "ins", "kbd", "li", "mark", "ol", "p", "pre",
+			"q", "s", "samp", "small", "span", "strike", "strong", "sub", "summary", "sup",
+			"table", "tbody", "td", "tfoot", "th", "thead", "time", "tr", "u", "ul",
+			"audio", "video", "source",
+		),
+		Drop: set(
+			"script", "style", "noscript", "template",
+			"iframe", "frame", "frameset", "object", "embed", "applet",
+			"form", "input", "button", "select", "option", "textarea",
+			"link", "meta", "base", "title", "svg", "math",
+		),
+		Attributes: set("title", "lang", "dir", "class"),
+		ElementAttributes: map[string]map[string]bool{
+			"a":          set("href"),
+			"img":        set("src", "alt", "width", "height"),
+			"audio":      set("src", "controls"),
+			"video":      set("src", "controls", "width", "height", "poster"),
+			"source":     set("src", "type"),
+			"td":         set("colspan", "rowspan"),
+			"th":         set("colspan", "rowspan", "scope"),
+			"ol":         set("start", "reversed"),
+			"time":       set("datetime"),
+			"q":          set("cite"),
+			"blockquote": set("cite"),
+			"del":        set("cite", "datetime"),
+			"ins":        set("cite", "datetime"),
+		},
+		URLAttributes: set("href", "src", "cite", "poster"),
+		URLSchemes:    set("http", "https", "mailto"),
+	}
+}
+
+// Sanitize parses source as a html fragment, and removes everything not allowed by policy.
+// If replace is not nil, it is called on every url that is kept, and its result is used instead.
+func (policy *Policy) Sanitize(source string, replace func(string) string) (string, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(source), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		return "", fmt.Errorf("failed to parse html fragment: %w", err)
+	}
+
+	// put all the nodes into a single container, so that they can be unwrapped and removed
+	container := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, node := range nodes {
+		container.AppendChild(node)
+	}
+	policy.sanitizeChildren(container, replace)
+
+	var builder strings.Builder
+	builder.Grow(len(source))
+	for child := container.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&builder, child); err != nil {
+			return "", fmt.Errorf("failed to render node: %w", err)
+		}
+	}
+	return builder.String(), nil
+}
+
+// sanitizeChildren sanitizes all children of parent in place.
+func (policy *Policy) sanitizeChildren(parent *html.Node, replace func(string) string) {
+	for child := parent.FirstChild; child != nil; {
+		next := child.NextSibling
+
+		switch {
+		case child.Type == html.TextNode:
+			// text is always safe
+		case child.Type != html.ElementNode || policy.Drop[child.Data]:
+			parent.RemoveChild(child)
+		case child.Namespace != "" || !policy.Elements[child.Data]:
+			// sanitize the content, then move it in place of the element
+			policy.sanitizeChildren(child, replace)
+			for grandchild := child.FirstChild; grandchild != nil; grandchild = child.FirstChild {
+				child.RemoveChild(grandchild)
+				parent.InsertBefore(grandchild, child)
+			}
+			parent.RemoveChild(child)
+		default:
+			child.Attr = policy.sanitizeAttributes(child.Data, child.Attr, replace)
+			policy.sanitizeChildren(child, replace)
+		}
+
+		child = next
+	}
+}
+
+// sanitizeAttributes returns the attributes of an element that are allowed by policy.
+func (policy *Policy) sanitizeAttributes(element string, attrs []html.Attribute, replace func(string) string) []html.Attribute {
+	kept := attrs[:0]
+	for _, attr := range attrs {
+		key := strings.ToLower(attr.Key)
+		switch {
+		case attr.Namespace != "" || strings.HasPrefix(key, "on"):
+			continue
+		case key == "style":
+			if !policy.Styles || !safeStyle(attr.Val) {
+				continue
+			}
+		case !policy.Attributes[key] && !policy.ElementAttributes[element][key]:
+			continue
+		case policy.URLAttributes[key]:
+			if !policy.safeURL(attr.Val) {
+				continue
+			}
+			if replace != nil {
+				attr.Val = replace(attr.Val)
+			}
+		}
+		kept = append(kept, attr)
+	}
+	return kept
+}
+
+// safeURL checks if the given url is relative, or uses one of the allowed schemes.
+func (policy *Policy) safeURL(value string) bool {
+	// browsers ignore whitespace and control characters inside urls
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || policy.URLSchemes[strings.ToLower(u.Scheme)]
+}
+
+// safeStyle checks that a style attribute does not contain any constructs that may execute code.
+func safeStyle(value string) bool {
+	value = strings.ToLower(value)
+	for _, bad := range []string{"expression", "javascript:", "vbscript:", "@import", "behavior", "-moz-binding"} {
+		if strings.Contains(value, bad) {
+			return false
+		}
+	}
+	return true
+}
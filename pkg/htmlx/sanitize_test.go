@@ -0,0 +1,70 @@
+//spellchecker:words htmlx
+package htmlx_test
+
+//spellchecker:words strings testing github hangover htmlx
+import (
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/hangover/pkg/htmlx"
+)
+
+//spellchecker:words onclick onerror iframe
+
+func TestPolicy_Sanitize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"formatting is kept", `<p>Hello <b>World</b></p>`, `<p>Hello <b>World</b></p>`},
+		{"scripts are removed", `a<script>alert(1)</script>b`, `ab`},
+		{"event handlers are removed", `<img src="a.png" onerror="alert(1)">`, `<img src="a.png"/>`},
+		{"javascript urls are removed", `<a href=" javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"iframes are removed", `<iframe src="https://example.com"></iframe>`, ``},
+		{"forms are removed", `<form action="/"><input name="x"></form>text`, `text`},
+		{"unknown elements are unwrapped", `<custom onclick="x"><b>bold</b></custom>`, `<b>bold</b>`},
+		{"styles are removed", `<p style="color: red">x</p>`, `<p>x</p>`},
+		{"comments are removed", `a<!-- comment -->b`, `ab`},
+		{"urls are replaced", `<a href="https://old.example/x">x</a>`, `<a href="/x">x</a>`},
+	}
+
+	policy := htmlx.DefaultPolicy()
+	replace := func(url string) string {
+		return strings.TrimPrefix(url, "https://old.example")
+	}
+	for _, tt := range tests {
+		got, err := policy.Sanitize(tt.source, replace)
+		if err != nil {
+			t.Errorf("%s: Sanitize() returned error %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: Sanitize(%q) = %q, want %q", tt.name, tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestPolicy_Sanitize_styles(t *testing.T) {
+	t.Parallel()
+
+	policy := htmlx.DefaultPolicy()
+	policy.Styles = true
+
+	for source, want := range map[string]string{
+		`<p style="color: red">x</p>`:                    `<p style="color: red">x</p>`,
+		`<p style="width: expression(alert(1))">x</p>`:   `<p>x</p>`,
+		`<p style="background: url(javascript:x)">x</p>`: `<p>x</p>`,
+	} {
+		got, err := policy.Sanitize(source, nil)
+		if err != nil {
+			t.Errorf("Sanitize() returned error %v", err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Sanitize(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Package htpasswd provides reading and verifying Apache-style htpasswd files.
+//
+//spellchecker:words htpasswd
+package htpasswd
+
+//spellchecker:words bufio crypto sha1 subtle encoding base64 errors strings golang bcrypt
+import (
+	"bufio"
+	"crypto/md5"  // #nosec G501 -- required by the apr1 format
+	"crypto/sha1" // #nosec G505 -- required by the {SHA} format
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+//spellchecker:words apr1 itoa
+
+// File holds the password hashes contained in an htpasswd file, indexed by user name.
+//
+// Supported hash formats are bcrypt ("$2y$"), apr1 ("$apr1$") and sha1 ("{SHA}").
+// A nil File contains no users.
+type File map[string]string
+
+var (
+	errMissingColon    = errors.New("missing ':'")
+	errEmptyUser       = errors.New("empty user name")
+	errUnsupportedHash = errors.New("unsupported hash format")
+)
+
+// Load reads an htpasswd file from the given path.
+func Load(path string) (f File, e error) {
+	file, err := os.Open(path) // #nosec G304 -- explicit parameter
+	if err != nil {
+		return nil, fmt.Errorf("failed to open htpasswd file: %w", err)
+	}
+	defer func() {
+		if e2 := file.Close(); e2 != nil {
+			e2 = fmt.Errorf("failed to close htpasswd file: %w", e2)
+			if e == nil {
+				e = e2
+			} else {
+				e = errors.Join(e, e2)
+			}
+		}
+	}()
+
+	return Parse(file)
+}
+
+// Parse parses an htpasswd file from the given reader.
+// Empty lines and lines starting with '#' are ignored.
+func Parse(reader io.Reader) (File, error) {
+	file := make(File)
+
+	scanner := bufio.NewScanner(reader)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		user, hash, ok := strings.Cut(line, ":")
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("line %d: %w", lineNo, errMissingColon)
+		case user == "":
+			return nil, fmt.Errorf("line %d: %w", lineNo, errEmptyUser)
+		case !supported(hash):
+			return nil, fmt.Errorf("line %d: user %q: %w", lineNo, user, errUnsupportedHash)
+		}
+		file[user] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read htpasswd file: %w", err)
+	}
+	return file, nil
+}
+
+const (
+	prefixSHA  = "{SHA}"
+	prefixAPR1 = "$apr1$"
+)
+
+// supported checks if the given hash is in a supported format.
+func supported(hash string) bool {
+	return isBcrypt(hash) || strings.HasPrefix(hash, prefixSHA) || strings.HasPrefix(hash, prefixAPR1)
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// Verify checks if the given user exists and has the given password.
+func (file File) Verify(user, password string) bool {
+	hash, ok := file[user]
+	if !ok {
+		return false
+	}
+
+	switch {
+	case isBcrypt(hash):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, prefixSHA):
+		sum := sha1.Sum([]byte(password)) // #nosec G401 -- required by the {SHA} format
+		return equal(prefixSHA+base64.StdEncoding.EncodeToString(sum[:]), hash)
+	case strings.HasPrefix(hash, prefixAPR1):
+		salt, _, _ := strings.Cut(strings.TrimPrefix(hash, prefixAPR1), "$")
+		return equal(apr1(password, salt), hash)
+	default:
+		return false
+	}
+}
+
+func equal(left, right string) bool {
+	return subtle.ConstantTimeCompare([]byte(left), []byte(right)) == 1
+}
+
+const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// apr1 computes the apache variant of the md5-crypt hash of password with the given salt.
+func apr1(password, salt string) string {
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+	pw, s := []byte(password), []byte(salt)
+
+	alternate := md5.New() // #nosec G401 -- required by the apr1 format
+	alternate.Write(pw)
+	alternate.Write(s)
+	alternate.Write(pw)
+	alt := alternate.Sum(nil)
+
+	ctx := md5.New() // #nosec G401 -- required by the apr1 format
+	ctx.Write(pw)
+	ctx.Write([]byte(prefixAPR1))
+	ctx.Write(s)
+	for i := len(pw); i > 0; i -= 16 {
+		ctx.Write(alt[:min(i, 16)])
+	}
+	for i := len(pw); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			ctx.Write([]byte{0})
+		} else {
+			ctx.Write(pw[:1])
+		}
+	}
+	final := ctx.Sum(nil)
+
+	for i := range 1000 {
+		round := md5.New() // #nosec G401 -- required by the apr1 format
+		if i&1 != 0 {
+			round.Write(pw)
+		} else {
+			round.Write(final)
+		}
+		if i%3 != 0 {
+			round.Write(s)
+		}
+		if i%7 != 0 {
+			round.Write(pw)
+		}
+		if i&1 != 0 {
+			round.Write(final)
+		} else {
+			round.Write(pw)
+		}
+		final = round.Sum(nil)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(prefixAPR1)
+	builder.WriteString(salt)
+	builder.WriteByte('$')
+
+	to64 := func(value uint32, n int) {
+		for ; n > 0; n-- {
+			builder.WriteByte(itoa64[value&0x3f])
+			value >>= 6
+		}
+	}
+	for _, group := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		to64(uint32(final[group[0]])<<16|uint32(final[group[1]])<<8|uint32(final[group[2]]), 4)
+	}
+	to64(uint32(final[11]), 2)
+
+	return builder.String()
+}
@@ -0,0 +1,62 @@
+//spellchecker:words htpasswd
+package htpasswd_test
+
+//spellchecker:words strings testing github hangover htpasswd
+import (
+	"strings"
+	"testing"
+
+	"github.com/FAU-CDI/hangover/pkg/htpasswd"
+)
+
+//spellchecker:words apr1
+
+const testFile = `
+# comments and empty lines are ignored
+
+bcrypt:$2a$05$FX9TCM0.X0ZtrZyii0TQweKl7ECPKO7cn9xiWGHZH8G1YgTTmzi5a
+apr1:$apr1$0123abcd$rX764cjRkAHh0oXJdi2EX0
+sha:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=
+`
+
+func TestFile_Verify(t *testing.T) {
+	t.Parallel()
+
+	file, err := htpasswd.Parse(strings.NewReader(testFile))
+	if err != nil {
+		t.Fatalf("Parse() returned error %v", err)
+	}
+
+	tests := []struct {
+		user     string
+		password string
+		want     bool
+	}{
+		{"bcrypt", "secret", true},
+		{"bcrypt", "wrong", false},
+		{"apr1", "secret", true},
+		{"apr1", "wrong", false},
+		{"sha", "secret", true},
+		{"sha", "wrong", false},
+		{"missing", "secret", false},
+	}
+	for _, tt := range tests {
+		if got := file.Verify(tt.user, tt.password); got != tt.want {
+			t.Errorf("Verify(%q, %q) = %v, want %v", tt.user, tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, source := range []string{
+		"no-colon",
+		":{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=",
+		"user:plaintext",
+	} {
+		if _, err := htpasswd.Parse(strings.NewReader(source)); err == nil {
+			t.Errorf("Parse(%q) did not return an error", source)
+		}
+	}
+}